@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"golang.org/x/oauth2"
+)
+
+// malEndpoint is the MyAnimeList OAuth2 endpoint as specified in:
+//
+// https://myanimelist.net/apiconfig/references/authorization
+var malEndpoint = oauth2.Endpoint{
+	AuthURL:   "https://myanimelist.net/v1/oauth2/authorize",
+	TokenURL:  "https://myanimelist.net/v1/oauth2/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+// credentials are stored by 'mal auth login'. The token can only be
+// refreshed by the client it was issued to so the client ID is kept with it.
+type credentials struct {
+	ClientID     string        `json:"client_id"`
+	ClientSecret string        `json:"client_secret,omitempty"`
+	Token        *oauth2.Token `json:"token,omitempty"`
+}
+
+func defaultCredentialsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "mal-credentials.json"
+	}
+	return filepath.Join(dir, "mal", "credentials.json")
+}
+
+func loadCredentials(path string) (*credentials, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := new(credentials)
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("reading credentials file %q: %v", path, err)
+	}
+	return c, nil
+}
+
+func saveCredentials(path string, c *credentials) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("saving credentials: %v", err)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o600); err != nil {
+		return fmt.Errorf("saving credentials: %v", err)
+	}
+	return nil
+}
+
+func (a *app) oauth2Config(clientID, clientSecret string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Endpoint:     a.endpoint,
+	}
+}
+
+// mal returns the client used by the commands. It uses the cached token if
+// there is one and falls back to the client ID which only allows access to
+// public information.
+func (a *app) mal(ctx context.Context) (*mal.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+	creds, err := loadCredentials(a.credentialsPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if creds != nil && creds.Token != nil {
+		conf := a.oauth2Config(creds.ClientID, creds.ClientSecret)
+		ts := &savingTokenSource{
+			src:  conf.TokenSource(ctx, creds.Token),
+			last: creds.Token,
+			save: func(t *oauth2.Token) error {
+				creds.Token = t
+				return saveCredentials(a.credentialsPath, creds)
+			},
+			stderr: a.stderr,
+		}
+		return mal.NewClient(oauth2.NewClient(ctx, ts)), nil
+	}
+	clientID := a.clientID
+	if clientID == "" && creds != nil {
+		clientID = creds.ClientID
+	}
+	if clientID == "" {
+		return nil, errNotAuthenticated
+	}
+	return mal.NewClient(&http.Client{Transport: &clientIDTransport{ClientID: clientID}}), nil
+}
+
+// savingTokenSource saves the token every time it is refreshed.
+type savingTokenSource struct {
+	src    oauth2.TokenSource
+	save   func(t *oauth2.Token) error
+	stderr io.Writer
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+func (s *savingTokenSource) Token() (*oauth2.Token, error) {
+	t, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil || t.AccessToken != s.last.AccessToken {
+		s.last = t
+		if err := s.save(t); err != nil {
+			fmt.Fprintf(s.stderr, "mal: could not save refreshed token: %v\n", err)
+		}
+	}
+	return t, nil
+}
+
+// clientIDTransport adds the client ID header which is enough to access
+// public information.
+type clientIDTransport struct {
+	Transport http.RoundTripper
+	ClientID  string
+}
+
+func (c *clientIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t := c.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+	req = req.Clone(req.Context())
+	req.Header.Add("X-MAL-CLIENT-ID", c.ClientID)
+	return t.RoundTrip(req)
+}
+
+func authLogin(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	noBrowser := fs.Bool("no-browser", false, "only print the authentication URL instead of also opening it in a browser")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return usagef("unexpected arguments %q", args)
+	}
+	if a.clientID == "" {
+		return usagef("a client ID is required, use -client-id or MAL_CLIENT_ID")
+	}
+	conf := a.oauth2Config(a.clientID, a.clientSecret)
+
+	// MyAnimeList currently only supports the plain code_challenge_method so
+	// the code verifier is sent as the code_challenge.
+	const codeVerifierLength = 128
+	codeVerifier, err := generateCodeVerifier(codeVerifierLength)
+	if err != nil {
+		return fmt.Errorf("generating code verifier: %v", err)
+	}
+	// The state protects against pasting the redirect of an authorization
+	// that was not started here. It can only be checked when the whole
+	// redirect URL is pasted; a bare code is accepted without the check.
+	state, err := randomState()
+	if err != nil {
+		return fmt.Errorf("generating state: %v", err)
+	}
+	authURL := conf.AuthCodeURL(state, oauth2.SetAuthURLParam("code_challenge", codeVerifier))
+	if !*noBrowser {
+		if err := a.openBrowser(authURL); err != nil {
+			fmt.Fprintln(a.stderr, "Could not open browser.")
+		}
+	}
+	fmt.Fprintf(a.stderr, "Open the following URL to allow access to your MyAnimeList account:\n\n%s\n\n", authURL)
+	fmt.Fprint(a.stderr, "After authenticating, paste the URL you were redirected to, or only its code to skip checking its state: ")
+	code, err := readCode(a.stdin, state)
+	if err != nil {
+		return err
+	}
+
+	token, err := conf.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	if err != nil {
+		return fmt.Errorf("exchanging code for token: %v", err)
+	}
+	creds := &credentials{ClientID: a.clientID, ClientSecret: a.clientSecret, Token: token}
+	if err := saveCredentials(a.credentialsPath, creds); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "Authentication was successful. Credentials saved to %s\n", a.credentialsPath)
+	return nil
+}
+
+// readCode reads the authorization code from r, either as the URL of the
+// redirect that contains it or as the bare code. The state of a redirect URL
+// must match state. A bare code carries no state, so it is accepted without
+// that check and the protection of the state is lost.
+func readCode(r io.Reader, state string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), 64*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("reading code: %v", err)
+		}
+		return "", errors.New("reading code: no code was entered")
+	}
+	code := strings.TrimSpace(scanner.Text())
+	if u, err := url.Parse(code); err == nil && u.Query().Get("code") != "" {
+		if u.Query().Get("state") != state {
+			return "", errors.New("reading code: the state of the redirect does not match, start the login again")
+		}
+		code = u.Query().Get("code")
+	}
+	if code == "" {
+		return "", errors.New("reading code: no code was entered")
+	}
+	return code, nil
+}
+
+// authStatus is the output of 'mal auth status'.
+type authStatus struct {
+	Credentials string    `json:"credentials"`
+	ClientID    string    `json:"client_id"`
+	HasToken    bool      `json:"has_token"`
+	Expiry      time.Time `json:"expiry"`
+	CanRefresh  bool      `json:"can_refresh"`
+}
+
+func authShowStatus(ctx context.Context, a *app, cmd *command, args []string) error {
+	args, err := parseFlags(a.flags(cmd), args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return usagef("unexpected arguments %q", args)
+	}
+	creds, err := loadCredentials(a.credentialsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return errNotAuthenticated
+	}
+	if err != nil {
+		return err
+	}
+	st := authStatus{Credentials: a.credentialsPath, ClientID: creds.ClientID}
+	if creds.Token != nil {
+		st.HasToken = true
+		st.Expiry = creds.Token.Expiry
+		st.CanRefresh = creds.Token.RefreshToken != ""
+	}
+	t := new(table)
+	t.field("Credentials", st.Credentials)
+	t.field("Client ID", st.ClientID)
+	t.field("Token", yesNo(st.HasToken))
+	t.field("Expiry", datetime(st.Expiry))
+	t.field("Refreshable", yesNo(st.CanRefresh))
+	return a.print(st, t)
+}
+
+func authLogout(ctx context.Context, a *app, cmd *command, args []string) error {
+	args, err := parseFlags(a.flags(cmd), args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return usagef("unexpected arguments %q", args)
+	}
+	if err := os.Remove(a.credentialsPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "Removed %s\n", a.credentialsPath)
+	return nil
+}
+
+// generateCodeVerifier generates a high-entropy cryptographic random string
+// with a length between 43 and 128 characters.
+func generateCodeVerifier(length int) (string, error) {
+	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+		"abcdefghijklmnopqrstvuwxyz" +
+		"0123456789-._~"
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	for i, b := range bytes {
+		bytes[i] = charset[b%byte(len(charset))]
+	}
+	return string(bytes), nil
+}
+
+// randomState generates the random state of an authorization request.
+func randomState() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "linux":
+		return exec.Command("xdg-open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		return exec.Command("open", url).Start()
+	default:
+		return fmt.Errorf("openBrowser: unsupported operating system: %v", runtime.GOOS)
+	}
+}
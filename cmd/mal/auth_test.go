@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newAuthServer starts a server with an OAuth2 token endpoint that issues
+// access tokens numbered by request and an API endpoint that reports the
+// credentials it received.
+func newAuthServer(t *testing.T) (*httptest.Server, *url.Values) {
+	t.Helper()
+	lastForm := new(url.Values)
+	issued := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		*lastForm = r.PostForm
+		issued++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`, issued)
+	})
+	mux.HandleFunc("/users/@me", func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get("Authorization")
+		if name == "" {
+			name = "client " + r.Header.Get("X-MAL-CLIENT-ID")
+		}
+		fmt.Fprintf(w, `{"id":1,"name":%q}`, name)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, lastForm
+}
+
+func newAuthApp(t *testing.T, srv *httptest.Server) (*app, string) {
+	t.Helper()
+	a, _, _, _ := newTestApp(t)
+	a.client = nil
+	a.endpoint = oauth2.Endpoint{
+		AuthURL:   srv.URL + "/authorize",
+		TokenURL:  srv.URL + "/token",
+		AuthStyle: oauth2.AuthStyleInParams,
+	}
+	return a, filepath.Join(t.TempDir(), "mal", "credentials.json")
+}
+
+// myName returns the name returned by the API of the auth server which
+// reports the credentials of the request.
+func myName(t *testing.T, a *app, srv *httptest.Server) string {
+	t.Helper()
+	c, err := a.mal(context.Background())
+	if err != nil {
+		t.Fatalf("mal returned error: %v", err)
+	}
+	u, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.BaseURL = u
+	user, _, err := c.User.MyInfo(context.Background())
+	if err != nil {
+		t.Fatalf("User.MyInfo returned error: %v", err)
+	}
+	return user.Name
+}
+
+func TestAuthLogin(t *testing.T) {
+	srv, form := newAuthServer(t)
+	a, path := newAuthApp(t, srv)
+	var authURL string
+	a.openBrowser = func(u string) error {
+		authURL = u
+		state := url.QueryEscape(authURLState(t, u))
+		a.stdin = strings.NewReader("http://localhost/callback?code=the-code&state=" + state + "\n")
+		return nil
+	}
+	stdout, stderr := a.stdout.(*bytes.Buffer), a.stderr.(*bytes.Buffer)
+
+	code := a.run(context.Background(), []string{"-credentials", path, "-client-id", "my-id", "auth", "login"})
+	if code != exitOK {
+		t.Fatalf("mal auth login exited with %d, stderr:\n%s", code, stderr.String())
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("browser opened invalid URL %q: %v", authURL, err)
+	}
+	challenge := u.Query().Get("code_challenge")
+	if len(challenge) != 128 || u.Query().Get("client_id") != "my-id" {
+		t.Errorf("browser opened %q, want the client ID and a code challenge", authURL)
+	}
+	if !strings.Contains(stderr.String(), authURL) {
+		t.Errorf("mal auth login did not print the authentication URL, stderr:\n%s", stderr.String())
+	}
+	if got := form.Get("code_verifier"); got != challenge {
+		t.Errorf("code_verifier = %q, want the code challenge %q", got, challenge)
+	}
+	if got := form.Get("code"); got != "the-code" {
+		t.Errorf("exchanged code = %q, want the code of the pasted URL", got)
+	}
+
+	creds, err := loadCredentials(path)
+	if err != nil {
+		t.Fatalf("loading saved credentials: %v", err)
+	}
+	if creds.ClientID != "my-id" || creds.Token.AccessToken != "access-1" {
+		t.Errorf("saved credentials = %+v, want the client ID and token", creds)
+	}
+
+	if got, want := myName(t, a, srv), "Bearer access-1"; got != want {
+		t.Errorf("API request credentials = %q, want %q", got, want)
+	}
+
+	code = a.run(context.Background(), []string{"-credentials", path, "-format", "json", "auth", "status"})
+	if code != exitOK {
+		t.Fatalf("mal auth status exited with %d, stderr:\n%s", code, stderr.String())
+	}
+	st := new(authStatus)
+	if err := json.Unmarshal(stdout.Bytes(), st); err != nil {
+		t.Fatalf("mal auth status output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if st.ClientID != "my-id" || !st.HasToken || !st.CanRefresh {
+		t.Errorf("mal auth status = %+v", st)
+	}
+	if strings.Contains(stdout.String(), "access-1") {
+		t.Errorf("mal auth status printed the token:\n%s", stdout.String())
+	}
+
+	if code := a.run(context.Background(), []string{"-credentials", path, "auth", "logout"}); code != exitOK {
+		t.Fatalf("mal auth logout exited with %d", code)
+	}
+	if code := a.run(context.Background(), []string{"-credentials", path, "auth", "status"}); code != exitAuth {
+		t.Errorf("mal auth status after logout exited with %d, want %d", code, exitAuth)
+	}
+}
+
+// authURLState returns the state of an authentication URL.
+func authURLState(t *testing.T, authURL string) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("browser opened invalid URL %q: %v", authURL, err)
+	}
+	state := u.Query().Get("state")
+	if len(state) != 32 {
+		t.Errorf("browser opened %q, want a random state", authURL)
+	}
+	return state
+}
+
+func TestAuthLoginStateMismatch(t *testing.T) {
+	srv, form := newAuthServer(t)
+	a, path := newAuthApp(t, srv)
+	a.openBrowser = func(u string) error { return nil }
+	a.stdin = strings.NewReader("http://localhost/callback?code=the-code&state=forged\n")
+
+	code := a.run(context.Background(), []string{"-credentials", path, "-client-id", "my-id", "auth", "login"})
+	if code == exitOK {
+		t.Fatal("mal auth login with a forged state succeeded")
+	}
+	if got := form.Get("code"); got != "" {
+		t.Errorf("exchanged code = %q, want no exchange", got)
+	}
+	if _, err := loadCredentials(path); err == nil {
+		t.Error("mal auth login with a forged state saved credentials")
+	}
+}
+
+func TestAuthLoginWithoutClientID(t *testing.T) {
+	srv, _ := newAuthServer(t)
+	a, path := newAuthApp(t, srv)
+	if code := a.run(context.Background(), []string{"-credentials", path, "auth", "login"}); code != exitUsage {
+		t.Errorf("mal auth login without client ID exited with %d, want %d", code, exitUsage)
+	}
+}
+
+func TestRefreshedTokenIsSaved(t *testing.T) {
+	srv, form := newAuthServer(t)
+	a, path := newAuthApp(t, srv)
+	a.credentialsPath = path
+	expired := &oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}
+	if err := saveCredentials(path, &credentials{ClientID: "my-id", Token: expired}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := myName(t, a, srv), "Bearer access-1"; got != want {
+		t.Errorf("API request credentials = %q, want %q", got, want)
+	}
+	if got := form.Get("grant_type"); got != "refresh_token" {
+		t.Errorf("token request grant_type = %q, want refresh_token", got)
+	}
+	creds, err := loadCredentials(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Token.AccessToken != "access-1" || creds.ClientID != "my-id" {
+		t.Errorf("credentials after refresh = %+v, want the refreshed token saved", creds)
+	}
+}
+
+func TestClientIDOnly(t *testing.T) {
+	srv, _ := newAuthServer(t)
+	a, path := newAuthApp(t, srv)
+	a.credentialsPath = path
+	a.clientID = "my-id"
+	if got, want := myName(t, a, srv), "client my-id"; got != want {
+		t.Errorf("API request credentials = %q, want %q", got, want)
+	}
+}
+
+func TestReadCode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"abc\n", "abc", false},
+		{"  abc  ", "abc", false},
+		{"https://example.com/callback?code=def&state=x", "def", false},
+		{"https://example.com/callback?code=def&state=y", "", true},
+		{"https://example.com/callback?code=def", "", true},
+		{"\n", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := readCode(strings.NewReader(tt.in), "x")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("readCode(%q) = %q, %v, want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRandomState(t *testing.T) {
+	a, err := randomState()
+	if err != nil {
+		t.Fatalf("randomState returned error: %v", err)
+	}
+	b, err := randomState()
+	if err != nil {
+		t.Fatalf("randomState returned error: %v", err)
+	}
+	if len(a) != 32 || a == b || url.QueryEscape(a) != a {
+		t.Errorf("randomState returned %q and %q, want two different URL safe strings of 32 characters", a, b)
+	}
+}
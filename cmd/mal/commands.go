@@ -0,0 +1,826 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// command is a subcommand of mal such as "anime search".
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(ctx context.Context, a *app, cmd *command, args []string) error
+}
+
+var commands = make(map[string]*command)
+
+func init() {
+	for _, c := range []*command{
+		{"auth login", "[-no-browser]", "Authenticate with the OAuth2 PKCE flow and save the credentials", authLogin},
+		{"auth status", "", "Show the saved credentials", authShowStatus},
+		{"auth logout", "", "Remove the saved credentials", authLogout},
+		{"anime search", "[-fields f] [-limit n] [-offset n] [-nsfw] <query>", "Search anime", animeSearch},
+		{"anime details", "[-fields f] <id>", "Show the details of an anime", animeDetails},
+		{"anime ranking", "[-fields f] [-limit n] [-offset n] [type]", "Show the top anime by ranking type", animeRanking},
+		{"anime seasonal", "[-fields f] [-limit n] [-offset n] [-sort score|users] [-nsfw] <year> <season>", "Show the anime of a season", animeSeasonal},
+		{"anime suggested", "[-fields f] [-limit n] [-offset n]", "Show anime suggested for the user", animeSuggested},
+		{"manga search", "[-fields f] [-limit n] [-offset n] [-nsfw] <query>", "Search manga", mangaSearch},
+		{"manga details", "[-fields f] <id>", "Show the details of a manga", mangaDetails},
+		{"manga ranking", "[-fields f] [-limit n] [-offset n] [type]", "Show the top manga by ranking type", mangaRanking},
+		{"me", "[-fields f]", "Show information about the authenticated user", me},
+		{"list show", "[-user name] [-status s] [-sort s] [-fields f] [-limit n] [-offset n] [-all] anime|manga", "Show an anime or manga list", listShow},
+		{"list update", "anime|manga [flags] <id>", "Update an entry of your list", listUpdate},
+		{"list delete", "anime|manga <id>", "Delete an entry from your list", listDelete},
+		{"list edit", "anime|manga", "Edit your list interactively", listEdit},
+		{"forum boards", "", "Show the forum boards", forumBoards},
+		{"forum topics", "[-q query] [-board id] [-subboard id] [-user name] [-topic-user name] [-limit n] [-offset n]", "Search forum topics", forumTopics},
+		{"forum topic", "[-limit n] [-offset n] <id>", "Show the posts of a forum topic", forumTopic},
+	} {
+		commands[c.name] = c
+	}
+}
+
+// findCommand returns the command named by the first one or two arguments
+// and the rest of the arguments.
+func findCommand(args []string) (*command, []string) {
+	if len(args) >= 2 {
+		if c, ok := commands[args[0]+" "+args[1]]; ok {
+			return c, args[2:]
+		}
+	}
+	if len(args) >= 1 {
+		if c, ok := commands[args[0]]; ok {
+			return c, args[1:]
+		}
+	}
+	return nil, nil
+}
+
+// errFlags is returned when the flags of a command could not be parsed. The
+// flag package has already reported the error.
+var errFlags = &usageError{}
+
+func (a *app) flags(cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet("mal "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: mal %s %s\n\n%s.\n", cmd.name, cmd.args, cmd.short)
+		if hasFlags(fs) {
+			fmt.Fprintln(a.stderr)
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	n := 0
+	fs.VisitAll(func(*flag.Flag) { n++ })
+	return n > 0
+}
+
+// parseFlags parses the flags of a command which may appear before or after
+// its arguments and returns the arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, errFlags
+		}
+		consumed := len(args) - fs.NArg()
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(rest, fs.Args()...), nil
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// pagingFlags adds the -limit and -offset flags.
+func pagingFlags(fs *flag.FlagSet, limit int) (*int, *int) {
+	return fs.Int("limit", limit, "maximum number of results"),
+		fs.Int("offset", 0, "offset of the first result")
+}
+
+// fieldsFlag adds the -fields flag. The fields returned when it is not set
+// are given by defaults.
+func fieldsFlag(fs *flag.FlagSet, defaults string) *string {
+	return fs.String("fields", defaults, "comma separated `list` of fields to return, for example: synopsis,my_list_status{tags}")
+}
+
+func fields(s string) mal.Fields {
+	if s == "" {
+		return nil
+	}
+	return mal.Fields{s}
+}
+
+func parseID(what, s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, usagef("invalid %s ID %q", what, s)
+	}
+	return id, nil
+}
+
+func oneOf(what, value string, valid ...string) error {
+	for _, v := range valid {
+		if value == v {
+			return nil
+		}
+	}
+	return usagef("invalid %s %q, must be one of: %s", what, value, strings.Join(valid, ", "))
+}
+
+func wantArgs(args []string, n int) error {
+	if len(args) != n {
+		return usagef("expected %d argument(s), got %d", n, len(args))
+	}
+	return nil
+}
+
+const (
+	animeListFields   = "media_type,num_episodes,start_season,mean,rank"
+	mangaListFields   = "media_type,num_volumes,num_chapters,mean,rank"
+	animeDetailFields = "alternative_titles,start_date,end_date,synopsis,mean,rank,popularity,num_list_users,media_type,status,genres,my_list_status,num_episodes,start_season,broadcast,source,average_episode_duration,rating,studios"
+	mangaDetailFields = "alternative_titles,start_date,synopsis,mean,rank,popularity,num_list_users,media_type,status,genres,my_list_status,num_volumes,num_chapters,authors{first_name,last_name},serialization"
+)
+
+var (
+	animeRankings = []string{"all", "airing", "upcoming", "tv", "ova", "movie", "special", "bypopularity", "favorite"}
+	mangaRankings = []string{"all", "manga", "oneshots", "doujin", "lightnovels", "novels", "manhwa", "manhua", "bypopularity", "favorite"}
+	animeSeasons  = []string{"winter", "spring", "summer", "fall"}
+	animeStatuses = []string{"watching", "completed", "on_hold", "dropped", "plan_to_watch"}
+	mangaStatuses = []string{"reading", "completed", "on_hold", "dropped", "plan_to_read"}
+	animeListSort = []string{"list_score", "list_updated_at", "anime_title", "anime_start_date", "anime_id"}
+	mangaListSort = []string{"list_score", "list_updated_at", "manga_title", "manga_start_date", "manga_id"}
+)
+
+func animeSearch(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	f := fieldsFlag(fs, animeListFields)
+	limit, offset := pagingFlags(fs, 10)
+	nsfw := fs.Bool("nsfw", false, "include results that are not safe for work")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return usagef("a search query is required")
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	list, _, err := c.Anime.List(ctx, strings.Join(args, " "), fields(*f), mal.Limit(*limit), mal.Offset(*offset), mal.NSFW(*nsfw))
+	if err != nil {
+		return err
+	}
+	return a.print(list, animeTable(list))
+}
+
+func animeDetails(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	f := fieldsFlag(fs, animeDetailFields)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 1); err != nil {
+		return err
+	}
+	id, err := parseID("anime", args[0])
+	if err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	anime, _, err := c.Anime.Details(ctx, id, fields(*f))
+	if err != nil {
+		return err
+	}
+	return a.print(anime, animeDetailsTable(anime))
+}
+
+func animeRanking(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	f := fieldsFlag(fs, animeListFields)
+	limit, offset := pagingFlags(fs, 10)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return usagef("expected at most 1 argument, got %d", len(args))
+	}
+	ranking := "all"
+	if len(args) == 1 {
+		ranking = args[0]
+	}
+	if err := oneOf("ranking type", ranking, animeRankings...); err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	list, _, err := c.Anime.Ranking(ctx, mal.AnimeRanking(ranking), fields(*f), mal.Limit(*limit), mal.Offset(*offset))
+	if err != nil {
+		return err
+	}
+	return a.print(list, animeTable(list))
+}
+
+func animeSeasonal(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	f := fieldsFlag(fs, animeListFields)
+	limit, offset := pagingFlags(fs, 10)
+	sort := fs.String("sort", "", "sort by `score` or by number of list users (users)")
+	nsfw := fs.Bool("nsfw", false, "include results that are not safe for work")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 2); err != nil {
+		return err
+	}
+	year, err := strconv.Atoi(args[0])
+	if err != nil {
+		return usagef("invalid year %q", args[0])
+	}
+	season := strings.ToLower(args[1])
+	if err := oneOf("season", season, animeSeasons...); err != nil {
+		return err
+	}
+	opts := []mal.SeasonalAnimeOption{fields(*f), mal.Limit(*limit), mal.Offset(*offset), mal.NSFW(*nsfw)}
+	switch *sort {
+	case "":
+	case "score":
+		opts = append(opts, mal.SortSeasonalByAnimeScore)
+	case "users":
+		opts = append(opts, mal.SortSeasonalByAnimeNumListUsers)
+	default:
+		return oneOf("sort", *sort, "score", "users")
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	list, _, err := c.Anime.Seasonal(ctx, year, mal.AnimeSeason(season), opts...)
+	if err != nil {
+		return err
+	}
+	return a.print(list, animeTable(list))
+}
+
+func animeSuggested(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	f := fieldsFlag(fs, animeListFields)
+	limit, offset := pagingFlags(fs, 10)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 0); err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	list, _, err := c.Anime.Suggested(ctx, fields(*f), mal.Limit(*limit), mal.Offset(*offset))
+	if err != nil {
+		return err
+	}
+	return a.print(list, animeTable(list))
+}
+
+func mangaSearch(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	f := fieldsFlag(fs, mangaListFields)
+	limit, offset := pagingFlags(fs, 10)
+	nsfw := fs.Bool("nsfw", false, "include results that are not safe for work")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return usagef("a search query is required")
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	list, _, err := c.Manga.List(ctx, strings.Join(args, " "), fields(*f), mal.Limit(*limit), mal.Offset(*offset), mal.NSFW(*nsfw))
+	if err != nil {
+		return err
+	}
+	return a.print(list, mangaTable(list))
+}
+
+func mangaDetails(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	f := fieldsFlag(fs, mangaDetailFields)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 1); err != nil {
+		return err
+	}
+	id, err := parseID("manga", args[0])
+	if err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	manga, _, err := c.Manga.Details(ctx, id, fields(*f))
+	if err != nil {
+		return err
+	}
+	return a.print(manga, mangaDetailsTable(manga))
+}
+
+func mangaRanking(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	f := fieldsFlag(fs, mangaListFields)
+	limit, offset := pagingFlags(fs, 10)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return usagef("expected at most 1 argument, got %d", len(args))
+	}
+	ranking := "all"
+	if len(args) == 1 {
+		ranking = args[0]
+	}
+	if err := oneOf("ranking type", ranking, mangaRankings...); err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	list, _, err := c.Manga.Ranking(ctx, mal.MangaRanking(ranking), fields(*f), mal.Limit(*limit), mal.Offset(*offset))
+	if err != nil {
+		return err
+	}
+	return a.print(list, mangaTable(list))
+}
+
+func me(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	f := fieldsFlag(fs, "anime_statistics,time_zone,is_supporter")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 0); err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	u, _, err := c.User.MyInfo(ctx, fields(*f))
+	if err != nil {
+		return err
+	}
+	return a.print(u, userTable(u))
+}
+
+func listShow(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	user := fs.String("user", "@me", "`name` of the user whose list to show")
+	status := fs.String("status", "", "only show entries with this `status`")
+	sort := fs.String("sort", "", "sort the list by `field`")
+	f := fieldsFlag(fs, "")
+	limit, offset := pagingFlags(fs, 100)
+	all := fs.Bool("all", false, "show the whole list instead of a single page")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 1); err != nil {
+		return err
+	}
+	kind := args[0]
+	if err := oneOf("list", kind, "anime", "manga"); err != nil {
+		return err
+	}
+	statuses, sorts, defaultFields := animeStatuses, animeListSort, "list_status,num_episodes"
+	if kind == "manga" {
+		statuses, sorts, defaultFields = mangaStatuses, mangaListSort, "list_status,num_volumes,num_chapters"
+	}
+	if *status != "" {
+		if err := oneOf("status", *status, statuses...); err != nil {
+			return err
+		}
+	}
+	if *sort != "" {
+		if err := oneOf("sort", *sort, sorts...); err != nil {
+			return err
+		}
+	}
+	if *f == "" {
+		*f = defaultFields
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+
+	if kind == "anime" {
+		opts := []mal.AnimeListOption{fields(*f), mal.Limit(*limit), mal.Offset(*offset)}
+		if *status != "" {
+			opts = append(opts, mal.AnimeStatus(*status))
+		}
+		if *sort != "" {
+			opts = append(opts, mal.SortAnimeList(*sort))
+		}
+		var list []mal.UserAnime
+		if *all {
+			err = c.User.WalkAnimeList(ctx, *user, func(page []mal.UserAnime) error {
+				list = append(list, page...)
+				return nil
+			}, opts...)
+		} else {
+			list, _, err = c.User.AnimeList(ctx, *user, opts...)
+		}
+		if err != nil {
+			return err
+		}
+		return a.print(list, animeListTable(list))
+	}
+
+	opts := []mal.MangaListOption{fields(*f), mal.Limit(*limit), mal.Offset(*offset)}
+	if *status != "" {
+		opts = append(opts, mal.MangaStatus(*status))
+	}
+	if *sort != "" {
+		opts = append(opts, mal.SortMangaList(*sort))
+	}
+	var list []mal.UserManga
+	if *all {
+		err = c.User.WalkMangaList(ctx, *user, func(page []mal.UserManga) error {
+			list = append(list, page...)
+			return nil
+		}, opts...)
+	} else {
+		list, _, err = c.User.MangaList(ctx, *user, opts...)
+	}
+	if err != nil {
+		return err
+	}
+	return a.print(list, mangaListTable(list))
+}
+
+// listFlags are the flags of 'mal list update' that are common to anime and
+// manga.
+type listFlags struct {
+	status   *string
+	score    *int
+	priority *int
+	tags     *string
+	comments *string
+	start    *string
+	finish   *string
+}
+
+func newListFlags(fs *flag.FlagSet, statuses []string) *listFlags {
+	return &listFlags{
+		status:   fs.String("status", "", "`status` of the entry, one of: "+strings.Join(statuses, ", ")),
+		score:    fs.Int("score", 0, "score from 0 to 10"),
+		priority: fs.Int("priority", 0, "priority from 0 to 2"),
+		tags:     fs.String("tags", "", "comma separated `list` of tags; empty to remove all tags"),
+		comments: fs.String("comments", "", "comments"),
+		start:    fs.String("start", "", "start `date` as YYYY-MM-DD; empty to remove it"),
+		finish:   fs.String("finish", "", "finish `date` as YYYY-MM-DD; empty to remove it"),
+	}
+}
+
+func parseDate(name, s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, usagef("invalid %s date %q, expected YYYY-MM-DD", name, s)
+	}
+	return t, nil
+}
+
+func splitTags(s string) mal.Tags {
+	tags := mal.Tags{}
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func listUpdate(ctx context.Context, a *app, cmd *command, args []string) error {
+	if len(args) == 0 {
+		return usagef("expected anime or manga")
+	}
+	kind, args := args[0], args[1:]
+	switch kind {
+	case "anime":
+		return listUpdateAnime(ctx, a, cmd, args)
+	case "manga":
+		return listUpdateManga(ctx, a, cmd, args)
+	case "-h", "-help", "--help":
+		a.flags(cmd).Usage()
+		return flag.ErrHelp
+	}
+	return oneOf("list", kind, "anime", "manga")
+}
+
+func listUpdateAnime(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	lf := newListFlags(fs, animeStatuses)
+	episodes := fs.Int("episodes", 0, "number of episodes watched")
+	rewatching := fs.Bool("rewatching", false, "whether the anime is being rewatched")
+	timesRewatched := fs.Int("times-rewatched", 0, "number of times the anime was rewatched")
+	rewatchValue := fs.Int("rewatch-value", 0, "rewatch value from 0 to 5")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 1); err != nil {
+		return err
+	}
+	id, err := parseID("anime", args[0])
+	if err != nil {
+		return err
+	}
+	var opts []mal.UpdateMyAnimeListStatusOption
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch f.Name {
+		case "status":
+			if err = oneOf("status", *lf.status, animeStatuses...); err == nil {
+				opts = append(opts, mal.AnimeStatus(*lf.status))
+			}
+		case "score":
+			opts = append(opts, mal.Score(*lf.score))
+		case "priority":
+			opts = append(opts, mal.Priority(*lf.priority))
+		case "tags":
+			opts = append(opts, splitTags(*lf.tags))
+		case "comments":
+			opts = append(opts, mal.Comments(*lf.comments))
+		case "start":
+			var t time.Time
+			if t, err = parseDate("start", *lf.start); err == nil {
+				opts = append(opts, mal.StartDate(t))
+			}
+		case "finish":
+			var t time.Time
+			if t, err = parseDate("finish", *lf.finish); err == nil {
+				opts = append(opts, mal.FinishDate(t))
+			}
+		case "episodes":
+			opts = append(opts, mal.NumEpisodesWatched(*episodes))
+		case "rewatching":
+			opts = append(opts, mal.IsRewatching(*rewatching))
+		case "times-rewatched":
+			opts = append(opts, mal.NumTimesRewatched(*timesRewatched))
+		case "rewatch-value":
+			opts = append(opts, mal.RewatchValue(*rewatchValue))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(opts) == 0 {
+		return usagef("nothing to update, set at least one flag")
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	st, _, err := c.Anime.UpdateMyListStatus(ctx, id, opts...)
+	if err != nil {
+		return err
+	}
+	return a.print(st, animeStatusTable(st))
+}
+
+func listUpdateManga(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	lf := newListFlags(fs, mangaStatuses)
+	volumes := fs.Int("volumes", 0, "number of volumes read")
+	chapters := fs.Int("chapters", 0, "number of chapters read")
+	rereading := fs.Bool("rereading", false, "whether the manga is being reread")
+	timesReread := fs.Int("times-reread", 0, "number of times the manga was reread")
+	rereadValue := fs.Int("reread-value", 0, "reread value from 0 to 5")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 1); err != nil {
+		return err
+	}
+	id, err := parseID("manga", args[0])
+	if err != nil {
+		return err
+	}
+	var opts []mal.UpdateMyMangaListStatusOption
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch f.Name {
+		case "status":
+			if err = oneOf("status", *lf.status, mangaStatuses...); err == nil {
+				opts = append(opts, mal.MangaStatus(*lf.status))
+			}
+		case "score":
+			opts = append(opts, mal.Score(*lf.score))
+		case "priority":
+			opts = append(opts, mal.Priority(*lf.priority))
+		case "tags":
+			opts = append(opts, splitTags(*lf.tags))
+		case "comments":
+			opts = append(opts, mal.Comments(*lf.comments))
+		case "start":
+			var t time.Time
+			if t, err = parseDate("start", *lf.start); err == nil {
+				opts = append(opts, mal.StartDate(t))
+			}
+		case "finish":
+			var t time.Time
+			if t, err = parseDate("finish", *lf.finish); err == nil {
+				opts = append(opts, mal.FinishDate(t))
+			}
+		case "volumes":
+			opts = append(opts, mal.NumVolumesRead(*volumes))
+		case "chapters":
+			opts = append(opts, mal.NumChaptersRead(*chapters))
+		case "rereading":
+			opts = append(opts, mal.IsRereading(*rereading))
+		case "times-reread":
+			opts = append(opts, mal.NumTimesReread(*timesReread))
+		case "reread-value":
+			opts = append(opts, mal.RereadValue(*rereadValue))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(opts) == 0 {
+		return usagef("nothing to update, set at least one flag")
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	st, _, err := c.Manga.UpdateMyListStatus(ctx, id, opts...)
+	if err != nil {
+		return err
+	}
+	return a.print(st, mangaStatusTable(st))
+}
+
+func listDelete(ctx context.Context, a *app, cmd *command, args []string) error {
+	args, err := parseFlags(a.flags(cmd), args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 2); err != nil {
+		return err
+	}
+	kind := args[0]
+	if err := oneOf("list", kind, "anime", "manga"); err != nil {
+		return err
+	}
+	id, err := parseID(kind, args[1])
+	if err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	if kind == "anime" {
+		_, err = c.Anime.DeleteMyListItem(ctx, id)
+	} else {
+		_, err = c.Manga.DeleteMyListItem(ctx, id)
+	}
+	return err
+}
+
+func forumBoards(ctx context.Context, a *app, cmd *command, args []string) error {
+	args, err := parseFlags(a.flags(cmd), args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 0); err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	f, _, err := c.Forum.Boards(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(f, boardsTable(f))
+}
+
+func forumTopics(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	q := fs.String("q", "", "search `query`")
+	board := fs.Int("board", 0, "only show topics of the board with this `ID`")
+	subboard := fs.Int("subboard", 0, "only show topics of the subboard with this `ID`")
+	user := fs.String("user", "", "only show topics with posts by this user `name`")
+	topicUser := fs.String("topic-user", "", "only show topics created by this user `name`")
+	limit, offset := pagingFlags(fs, 10)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 && *q == "" {
+		*q = strings.Join(args, " ")
+	} else if err := wantArgs(args, 0); err != nil {
+		return err
+	}
+	opts := []mal.TopicsOption{mal.Limit(*limit), mal.Offset(*offset)}
+	if *q != "" {
+		opts = append(opts, mal.Query(*q))
+	}
+	if *board != 0 {
+		opts = append(opts, mal.BoardID(*board))
+	}
+	if *subboard != 0 {
+		opts = append(opts, mal.SubboardID(*subboard))
+	}
+	if *user != "" {
+		opts = append(opts, mal.UserName(*user))
+	}
+	if *topicUser != "" {
+		opts = append(opts, mal.TopicUserName(*topicUser))
+	}
+	if len(opts) == 2 {
+		return usagef("at least one of a query, board, subboard or user is required")
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	topics, _, err := c.Forum.Topics(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return a.print(topics, topicsTable(topics))
+}
+
+func forumTopic(ctx context.Context, a *app, cmd *command, args []string) error {
+	fs := a.flags(cmd)
+	limit, offset := pagingFlags(fs, 100)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 1); err != nil {
+		return err
+	}
+	id, err := parseID("topic", args[0])
+	if err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	d, _, err := c.Forum.TopicDetails(ctx, id, mal.Limit(*limit), mal.Offset(*offset))
+	if err != nil {
+		return err
+	}
+	return a.print(d, topicTables(d)...)
+}
@@ -0,0 +1,186 @@
+// Command mal is a command-line client for the MyAnimeList API.
+//
+// Usage:
+//
+//	mal [global flags] <command> [flags] [arguments]
+//
+// Run 'mal auth login' once to authenticate using the OAuth2 PKCE flow. The
+// token is cached together with the client ID and it is refreshed as needed.
+// Commands that only read public information can also be used with just a
+// client ID, given with -client-id or the MAL_CLIENT_ID environment variable.
+//
+// Exit codes:
+//
+//	0  success
+//	1  failure (network errors, unexpected responses, etc.)
+//	2  invalid command line usage
+//	3  authentication is required or the token was rejected (401, 403)
+//	4  the requested item was not found (404)
+//	5  the API rejected the request (other 4xx)
+//	6  the API failed (5xx)
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"golang.org/x/oauth2"
+)
+
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitAuth
+	exitNotFound
+	exitBadRequest
+	exitServerError
+)
+
+func main() {
+	a := &app{
+		stdin:       os.Stdin,
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		getenv:      os.Getenv,
+		openBrowser: openBrowser,
+		endpoint:    malEndpoint,
+	}
+	os.Exit(a.run(context.Background(), os.Args[1:]))
+}
+
+// app holds the global state of a single invocation of the command.
+type app struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+	getenv         func(key string) string
+	openBrowser    func(url string) error
+	endpoint       oauth2.Endpoint
+
+	format          string
+	credentialsPath string
+	clientID        string
+	clientSecret    string
+
+	// client, if set, is used instead of creating one from the credentials.
+	client *mal.Client
+}
+
+// usageError is returned by commands that were called incorrectly.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errNotAuthenticated is returned when a command needs credentials and none
+// were found.
+var errNotAuthenticated = errors.New("not authenticated: run 'mal auth login' or provide a client ID with -client-id or MAL_CLIENT_ID")
+
+func (a *app) run(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("mal", flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.StringVar(&a.format, "format", "table", "output `format`: table, json or yaml")
+	fs.StringVar(&a.credentialsPath, "credentials", defaultCredentialsPath(), "`file` where the client ID and OAuth2 token are stored")
+	fs.StringVar(&a.clientID, "client-id", a.getenv("MAL_CLIENT_ID"), "your registered MyAnimeList.net application client `ID` (default $MAL_CLIENT_ID)")
+	fs.StringVar(&a.clientSecret, "client-secret", a.getenv("MAL_CLIENT_SECRET"), "your registered MyAnimeList.net application client `secret`; optional if you chose App Type 'other' (default $MAL_CLIENT_SECRET)")
+	fs.Usage = func() { a.usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	switch a.format {
+	case "table", "json", "yaml":
+	default:
+		fmt.Fprintf(a.stderr, "mal: unknown output format %q\n", a.format)
+		return exitUsage
+	}
+
+	cmd, cmdArgs := findCommand(fs.Args())
+	if cmd == nil {
+		if fs.Arg(0) == "help" {
+			a.usage(fs)
+			return exitOK
+		}
+		if fs.NArg() == 0 {
+			a.usage(fs)
+			return exitUsage
+		}
+		fmt.Fprintf(a.stderr, "mal: unknown command %q\n", strings.Join(fs.Args(), " "))
+		fmt.Fprintln(a.stderr, "Run 'mal help' for usage.")
+		return exitUsage
+	}
+	err := cmd.run(ctx, a, cmd, cmdArgs)
+	if err == nil {
+		return exitOK
+	}
+	if err == errFlags {
+		return exitUsage
+	}
+	var uerr *usageError
+	if errors.As(err, &uerr) {
+		fmt.Fprintf(a.stderr, "mal %s: %v\n", cmd.name, err)
+		fmt.Fprintf(a.stderr, "usage: mal %s %s\n", cmd.name, cmd.args)
+		return exitUsage
+	}
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	fmt.Fprintf(a.stderr, "mal %s: %v\n", cmd.name, err)
+	return exitCode(err)
+}
+
+// exitCode returns the exit code of a command that failed with err.
+func exitCode(err error) int {
+	if errors.Is(err, errNotAuthenticated) {
+		return exitAuth
+	}
+	var errResp *mal.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return exitError
+	}
+	switch code := errResp.Response.StatusCode; {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return exitAuth
+	case code == http.StatusNotFound:
+		return exitNotFound
+	case code >= 400 && code < 500:
+		return exitBadRequest
+	case code >= 500:
+		return exitServerError
+	}
+	return exitError
+}
+
+func (a *app) usage(fs *flag.FlagSet) {
+	fmt.Fprintln(a.stderr, "Usage: mal [global flags] <command> [flags] [arguments]")
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %-16s %s\n", name, commands[name].short)
+	}
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Global flags:")
+	fs.PrintDefaults()
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "Run 'mal <command> -h' for the flags of a command.")
+}
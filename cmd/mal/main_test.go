@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/maltest"
+)
+
+// fixturesPath is the fixtures of the maltest package which the tests of the
+// commands share.
+const fixturesPath = "../../mal/maltest/testdata/fixtures.json"
+
+// newTestApp returns an app that uses a fake API seeded with the fixtures at
+// fixturesPath and writes to the returned buffers.
+func newTestApp(t *testing.T) (a *app, srv *maltest.Server, stdout, stderr *bytes.Buffer) {
+	t.Helper()
+	f, err := maltest.LoadFixtures(fixturesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv = maltest.NewServer(f)
+	t.Cleanup(srv.Close)
+	srv.Now = func() time.Time { return time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	stdout, stderr = new(bytes.Buffer), new(bytes.Buffer)
+	a = &app{
+		stdin:       strings.NewReader(""),
+		stdout:      stdout,
+		stderr:      stderr,
+		getenv:      func(string) string { return "" },
+		openBrowser: func(string) error { return nil },
+		client:      srv.Client(),
+	}
+	return a, srv, stdout, stderr
+}
+
+// run runs the command line and resets the output buffers before running.
+func run(a *app, stdout, stderr *bytes.Buffer, cmdline ...string) int {
+	stdout.Reset()
+	stderr.Reset()
+	return a.run(context.Background(), cmdline)
+}
+
+var spaces = regexp.MustCompile(` +`)
+
+// squash replaces runs of spaces with a single space so that the expected
+// table rows do not depend on the column widths.
+func squash(s string) string { return spaces.ReplaceAllString(s, " ") }
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"anime", "search", "cowboy", "-limit", "5"}, []string{"ID TITLE", "5 Cowboy Bebop: Tengoku no Tobira movie 1 summer 2001 8.40 5"}},
+		{[]string{"anime", "details", "1"}, []string{"Title: Cowboy Bebop", "Duration: 24 min. per ep.", "My Status: completed, 26/26 episodes, score 10"}},
+		{[]string{"anime", "ranking", "movie"}, []string{"Cowboy Bebop: Tengoku no Tobira"}},
+		{[]string{"anime", "seasonal", "-sort", "users", "1998", "Spring"}, []string{"1 Cowboy Bebop", "6 Trigun"}},
+		{[]string{"anime", "suggested"}, []string{"5 Cowboy Bebop: Tengoku no Tobira"}},
+		{[]string{"manga", "search", "death"}, []string{"21 Death Note"}},
+		{[]string{"manga", "details", "2"}, []string{"Chapters: 370", "My Status: reading, 100/370 chapters, score 9"}},
+		{[]string{"manga", "ranking", "bypopularity"}, []string{"21 Death Note"}},
+		{[]string{"me"}, []string{"Name: foo", "Time Zone: Asia/Tokyo", "Episodes: 29"}},
+		{[]string{"list", "show", "anime"}, []string{"1 Cowboy Bebop completed 10 26/26 2021-01-02", "6 Trigun watching 7 3/26 2021-01-03"}},
+		{[]string{"list", "show", "-status", "watching", "-all", "-limit", "1", "anime"}, []string{"6 Trigun"}},
+		{[]string{"list", "show", "-user", "foo", "manga"}, []string{"2 Berserk reading 9 0/? 100/370"}},
+		{[]string{"forum", "boards"}, []string{"5 2 MyAnimeList / Updates & Announcements / Anime DB"}},
+		{[]string{"forum", "topics", "-board", "5"}, []string{"101 Site update", "100 Best of Spring"}},
+		{[]string{"forum", "topic", "100"}, []string{"Poll: Best?", "Bebop 2 66.67%", "3 1002 baz 2021-04-03 00:00 Trigun"}},
+	}
+	for _, tt := range tests {
+		a, _, stdout, stderr := newTestApp(t)
+		if code := run(a, stdout, stderr, tt.args...); code != exitOK {
+			t.Errorf("mal %s exited with %d, stderr:\n%s", strings.Join(tt.args, " "), code, stderr)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(squash(stdout.String()), squash(want)) {
+				t.Errorf("mal %s output does not contain %q:\n%s", strings.Join(tt.args, " "), want, stdout)
+			}
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"help"}, exitOK},
+		{[]string{"anime", "search", "-h"}, exitOK},
+		{nil, exitUsage},
+		{[]string{"anime", "watch"}, exitUsage},
+		{[]string{"-format", "xml", "me"}, exitUsage},
+		{[]string{"anime", "details"}, exitUsage},
+		{[]string{"anime", "details", "-unknown", "1"}, exitUsage},
+		{[]string{"anime", "ranking", "best"}, exitUsage},
+		{[]string{"anime", "seasonal", "1998", "monsoon"}, exitUsage},
+		{[]string{"list", "show", "-status", "reading", "anime"}, exitUsage},
+		{[]string{"list", "update", "anime", "1"}, exitUsage},
+		{[]string{"list", "update", "anime", "-start", "01/02/2022", "1"}, exitUsage},
+		{[]string{"forum", "topics"}, exitUsage},
+		{[]string{"anime", "details", "999"}, exitNotFound},
+		{[]string{"list", "update", "anime", "999", "-score", "1"}, exitNotFound},
+		{[]string{"list", "delete", "manga", "21"}, exitNotFound},
+		{[]string{"list", "show", "nobody"}, exitUsage},
+		{[]string{"anime", "search", "ab"}, exitBadRequest},
+	}
+	for _, tt := range tests {
+		a, _, stdout, stderr := newTestApp(t)
+		if got := run(a, stdout, stderr, tt.args...); got != tt.want {
+			t.Errorf("mal %s exited with %d, want %d, stderr:\n%s", strings.Join(tt.args, " "), got, tt.want, stderr)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	errResp := func(code int) error {
+		return &mal.ErrorResponse{Response: &http.Response{StatusCode: code, Request: &http.Request{}}}
+	}
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("dial tcp: connection refused"), exitError},
+		{errNotAuthenticated, exitAuth},
+		{errResp(http.StatusUnauthorized), exitAuth},
+		{errResp(http.StatusForbidden), exitAuth},
+		{errResp(http.StatusNotFound), exitNotFound},
+		{errResp(http.StatusBadRequest), exitBadRequest},
+		{errResp(http.StatusTooManyRequests), exitBadRequest},
+		{errResp(http.StatusBadGateway), exitServerError},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestNotAuthenticated(t *testing.T) {
+	a, _, stdout, stderr := newTestApp(t)
+	a.client = nil
+	credentials := filepath.Join(t.TempDir(), "credentials.json")
+	if got := run(a, stdout, stderr, "-credentials", credentials, "me"); got != exitAuth {
+		t.Errorf("mal me without credentials exited with %d, want %d", got, exitAuth)
+	}
+	if !strings.Contains(stderr.String(), "mal auth login") {
+		t.Errorf("mal me without credentials stderr = %q, want a hint to log in", stderr)
+	}
+}
+
+func TestListUpdateDelete(t *testing.T) {
+	a, srv, stdout, stderr := newTestApp(t)
+
+	code := run(a, stdout, stderr, "list", "update", "anime", "5", "-status", "completed", "-score", "8", "-tags", "movie, space", "-start", "2021-12-31")
+	if code != exitOK {
+		t.Fatalf("mal list update anime exited with %d, stderr:\n%s", code, stderr)
+	}
+	if want := "Tags: movie, space"; !strings.Contains(squash(stdout.String()), want) {
+		t.Errorf("mal list update anime output does not contain %q:\n%s", want, stdout)
+	}
+	list := srv.AnimeList("foo")
+	want := mal.AnimeListStatus{
+		Status:    mal.AnimeStatusCompleted,
+		Score:     8,
+		Tags:      []string{"movie", "space"},
+		StartDate: "2021-12-31",
+		UpdatedAt: time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if got := list[len(list)-1].Status; !reflect.DeepEqual(got, want) {
+		t.Errorf("anime list status after update\nhave: %+v\nwant: %+v", got, want)
+	}
+
+	code = run(a, stdout, stderr, "-format", "json", "list", "update", "manga", "-chapters", "120", "-rereading=false", "2")
+	if code != exitOK {
+		t.Fatalf("mal list update manga exited with %d, stderr:\n%s", code, stderr)
+	}
+	st := new(mal.MangaListStatus)
+	if err := json.Unmarshal(stdout.Bytes(), st); err != nil {
+		t.Fatalf("mal list update manga output is not JSON: %v\n%s", err, stdout)
+	}
+	if st.NumChaptersRead != 120 || st.Score != 9 {
+		t.Errorf("mal list update manga returned %+v, want only the chapters updated", st)
+	}
+
+	if code := run(a, stdout, stderr, "list", "delete", "anime", "1"); code != exitOK {
+		t.Fatalf("mal list delete exited with %d, stderr:\n%s", code, stderr)
+	}
+	if list := srv.AnimeList("foo"); len(list) != 2 {
+		t.Errorf("anime list after delete has %d entries, want 2", len(list))
+	}
+}
+
+func TestFormats(t *testing.T) {
+	a, _, stdout, stderr := newTestApp(t)
+
+	if code := run(a, stdout, stderr, "-format", "json", "anime", "details", "-fields", "num_episodes", "1"); code != exitOK {
+		t.Fatalf("mal anime details exited with %d, stderr:\n%s", code, stderr)
+	}
+	anime := new(mal.Anime)
+	if err := json.Unmarshal(stdout.Bytes(), anime); err != nil {
+		t.Fatalf("JSON output could not be decoded: %v\n%s", err, stdout)
+	}
+	if anime.Title != "Cowboy Bebop" || anime.NumEpisodes != 26 || anime.Mean != 0 {
+		t.Errorf("JSON output decoded to %+v, want the requested fields only", anime)
+	}
+
+	if code := run(a, stdout, stderr, "-format", "yaml", "forum", "topics", "-q", "best"); code != exitOK {
+		t.Fatalf("mal forum topics exited with %d, stderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{"- id: 100\n  title: Best of Spring\n", "  created_by:\n    id: 0\n    name: foo\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("YAML output does not contain %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	a, _, _, _ := newTestApp(t)
+	fs := a.flags(commands["anime search"])
+	limit := fs.Int("limit", 10, "")
+	args, err := parseFlags(fs, []string{"cowboy", "-limit", "3", "bebop", "--", "-x"})
+	if err != nil {
+		t.Fatalf("parseFlags returned error: %v", err)
+	}
+	if want := []string{"cowboy", "bebop", "-x"}; !reflect.DeepEqual(args, want) || *limit != 3 {
+		t.Errorf("parseFlags = %q with limit %d, want %q with limit 3", args, *limit, want)
+	}
+}
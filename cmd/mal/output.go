@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// table is the tabular form of a command result. A table without a header
+// holds field names and values.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func newTable(header ...string) *table {
+	return &table{header: header}
+}
+
+// add adds a row. Tabs and newlines are replaced with spaces so that each row
+// stays on one line.
+func (t *table) add(cells ...string) {
+	row := make([]string, len(cells))
+	for i, c := range cells {
+		row[i] = strings.Join(strings.Fields(c), " ")
+	}
+	t.rows = append(t.rows, row)
+}
+
+// field adds a row with a field name and its value unless the value is empty.
+func (t *table) field(name, value string) {
+	if value != "" {
+		t.add(name+":", value)
+	}
+}
+
+// print writes v in the output format of the app. In the table format, the
+// tables are written instead, separated by empty lines.
+func (a *app) print(v interface{}, tables ...*table) error {
+	switch a.format {
+	case "json":
+		enc := json.NewEncoder(a.stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		return writeYAML(a.stdout, v)
+	}
+	return writeTables(a.stdout, tables...)
+}
+
+// writeTables writes tables with aligned columns, separated by empty lines.
+func writeTables(w io.Writer, tables ...*table) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		if t.header != nil {
+			fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		}
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// num formats n leaving zero values empty.
+func num(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// score formats a mean score leaving zero values empty.
+func score(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// progress formats the progress of a list entry such as "3/26". The total is
+// "?" when it is unknown.
+func progress(n, total int) string {
+	if total == 0 {
+		return strconv.Itoa(n) + "/?"
+	}
+	return strconv.Itoa(n) + "/" + strconv.Itoa(total)
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func datetime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func season(s mal.StartSeason) string {
+	if s.Year == 0 {
+		return ""
+	}
+	return s.Season + " " + strconv.Itoa(s.Year)
+}
+
+func dateRange(start, end string) string {
+	switch {
+	case start == "" && end == "":
+		return ""
+	case end == "":
+		return start + " to ?"
+	}
+	return start + " to " + end
+}
+
+func genres(list []mal.Genre) string {
+	names := make([]string, len(list))
+	for i, g := range list {
+		names[i] = g.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func studios(list []mal.Studio) string {
+	names := make([]string, len(list))
+	for i, s := range list {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func authors(list []mal.Author) string {
+	names := make([]string, len(list))
+	for i, a := range list {
+		names[i] = strings.TrimSpace(a.Person.FirstName + " " + a.Person.LastName)
+		if a.Role != "" {
+			names[i] += " (" + a.Role + ")"
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func serialization(list []mal.Serialization) string {
+	names := make([]string, len(list))
+	for i, s := range list {
+		names[i] = s.Node.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// excerpt returns the first n characters of s on a single line.
+func excerpt(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func animeTable(list []mal.Anime) *table {
+	t := newTable("ID", "TITLE", "TYPE", "EPISODES", "SEASON", "MEAN", "RANK")
+	for _, a := range list {
+		t.add(strconv.Itoa(a.ID), a.Title, a.MediaType, num(a.NumEpisodes), season(a.StartSeason), score(a.Mean), num(a.Rank))
+	}
+	return t
+}
+
+func mangaTable(list []mal.Manga) *table {
+	t := newTable("ID", "TITLE", "TYPE", "VOLUMES", "CHAPTERS", "MEAN", "RANK")
+	for _, m := range list {
+		t.add(strconv.Itoa(m.ID), m.Title, m.MediaType, num(m.NumVolumes), num(m.NumChapters), score(m.Mean), num(m.Rank))
+	}
+	return t
+}
+
+func animeDetailsTable(a *mal.Anime) *table {
+	t := new(table)
+	t.field("ID", strconv.Itoa(a.ID))
+	t.field("Title", a.Title)
+	t.field("English", a.AlternativeTitles.En)
+	t.field("Japanese", a.AlternativeTitles.Ja)
+	t.field("Type", a.MediaType)
+	t.field("Status", a.Status)
+	t.field("Episodes", num(a.NumEpisodes))
+	t.field("Aired", dateRange(a.StartDate, a.EndDate))
+	t.field("Season", season(a.StartSeason))
+	t.field("Broadcast", strings.TrimSpace(a.Broadcast.DayOfTheWeek+" "+a.Broadcast.StartTime))
+	if a.AverageEpisodeDuration > 0 {
+		t.field("Duration", fmt.Sprintf("%d min. per ep.", a.AverageEpisodeDuration/60))
+	}
+	t.field("Source", a.Source)
+	t.field("Rating", a.Rating)
+	t.field("Genres", genres(a.Genres))
+	t.field("Studios", studios(a.Studios))
+	t.field("Mean", score(a.Mean))
+	t.field("Rank", num(a.Rank))
+	t.field("Popularity", num(a.Popularity))
+	t.field("Members", num(a.NumListUsers))
+	if s := a.MyListStatus; s.Status != "" {
+		t.field("My Status", fmt.Sprintf("%s, %s episodes, score %d", s.Status, progress(s.NumEpisodesWatched, a.NumEpisodes), s.Score))
+	}
+	t.field("Synopsis", a.Synopsis)
+	return t
+}
+
+func mangaDetailsTable(m *mal.Manga) *table {
+	t := new(table)
+	t.field("ID", strconv.Itoa(m.ID))
+	t.field("Title", m.Title)
+	t.field("English", m.AlternativeTitles.En)
+	t.field("Japanese", m.AlternativeTitles.Ja)
+	t.field("Type", m.MediaType)
+	t.field("Status", m.Status)
+	t.field("Volumes", num(m.NumVolumes))
+	t.field("Chapters", num(m.NumChapters))
+	t.field("Published", m.StartDate)
+	t.field("Authors", authors(m.Authors))
+	t.field("Serialization", serialization(m.Serialization))
+	t.field("Genres", genres(m.Genres))
+	t.field("Mean", score(m.Mean))
+	t.field("Rank", num(m.Rank))
+	t.field("Popularity", num(m.Popularity))
+	t.field("Members", num(m.NumListUsers))
+	if s := m.MyListStatus; s.Status != "" {
+		t.field("My Status", fmt.Sprintf("%s, %s chapters, score %d", s.Status, progress(s.NumChaptersRead, m.NumChapters), s.Score))
+	}
+	t.field("Synopsis", m.Synopsis)
+	return t
+}
+
+func userTable(u *mal.User) *table {
+	t := new(table)
+	t.field("ID", strconv.FormatInt(u.ID, 10))
+	t.field("Name", u.Name)
+	t.field("Gender", u.Gender)
+	t.field("Birthday", u.Birthday)
+	t.field("Location", u.Location)
+	t.field("Time Zone", u.TimeZone)
+	t.field("Joined", date(u.JoinedAt))
+	t.field("Supporter", yesNo(u.IsSupporter))
+	if st := u.AnimeStatistics; st.NumItems > 0 {
+		t.field("Anime", strconv.Itoa(st.NumItems))
+		t.field("Episodes", strconv.Itoa(st.NumEpisodes))
+		t.field("Days", strconv.FormatFloat(st.NumDays, 'f', 2, 64))
+		t.field("Mean Score", score(st.MeanScore))
+	}
+	return t
+}
+
+func animeListTable(list []mal.UserAnime) *table {
+	t := newTable("ID", "TITLE", "STATUS", "SCORE", "EPISODES", "UPDATED")
+	for _, a := range list {
+		s := a.Status
+		t.add(strconv.Itoa(a.Anime.ID), a.Anime.Title, string(s.Status), num(s.Score), progress(s.NumEpisodesWatched, a.Anime.NumEpisodes), date(s.UpdatedAt))
+	}
+	return t
+}
+
+func mangaListTable(list []mal.UserManga) *table {
+	t := newTable("ID", "TITLE", "STATUS", "SCORE", "VOLUMES", "CHAPTERS", "UPDATED")
+	for _, m := range list {
+		s := m.Status
+		t.add(strconv.Itoa(m.Manga.ID), m.Manga.Title, string(s.Status), num(s.Score), progress(s.NumVolumesRead, m.Manga.NumVolumes), progress(s.NumChaptersRead, m.Manga.NumChapters), date(s.UpdatedAt))
+	}
+	return t
+}
+
+func listStatusTable(status string, score int, progress map[string]int, priority int, tags []string, comments, start, finish string, updated time.Time) *table {
+	t := new(table)
+	t.field("Status", status)
+	t.field("Score", num(score))
+	for _, k := range []string{"Episodes", "Volumes", "Chapters"} {
+		if n, ok := progress[k]; ok {
+			t.field(k, strconv.Itoa(n))
+		}
+	}
+	t.field("Priority", num(priority))
+	t.field("Tags", strings.Join(tags, ", "))
+	t.field("Comments", comments)
+	t.field("Started", start)
+	t.field("Finished", finish)
+	t.field("Updated", datetime(updated))
+	return t
+}
+
+func animeStatusTable(s *mal.AnimeListStatus) *table {
+	return listStatusTable(string(s.Status), s.Score, map[string]int{"Episodes": s.NumEpisodesWatched}, s.Priority, s.Tags, s.Comments, s.StartDate, s.FinishDate, s.UpdatedAt)
+}
+
+func mangaStatusTable(s *mal.MangaListStatus) *table {
+	return listStatusTable(string(s.Status), s.Score, map[string]int{"Volumes": s.NumVolumesRead, "Chapters": s.NumChaptersRead}, s.Priority, s.Tags, s.Comments, s.StartDate, s.FinishDate, s.UpdatedAt)
+}
+
+func boardsTable(f *mal.Forum) *table {
+	t := newTable("BOARD", "SUBBOARD", "PATH")
+	for _, p := range f.Paths() {
+		sub := ""
+		if p.Subboard != nil {
+			sub = strconv.Itoa(p.Subboard.ID)
+		}
+		t.add(strconv.Itoa(p.Board.ID), sub, p.String())
+	}
+	return t
+}
+
+func topicsTable(list []mal.Topic) *table {
+	t := newTable("ID", "TITLE", "POSTS", "CREATED BY", "LAST POST", "LAST POST BY")
+	for _, tp := range list {
+		t.add(strconv.Itoa(tp.ID), tp.Title, strconv.Itoa(tp.NumberOfPosts), tp.CreatedBy.Name, datetime(tp.LastPostCreatedAt), tp.LastPostCreatedBy.Name)
+	}
+	return t
+}
+
+func topicTables(d mal.TopicDetails) []*table {
+	info := new(table)
+	info.field("Title", d.Title)
+	tables := []*table{info}
+	if p := d.Poll; p != nil {
+		info.field("Poll", p.Question)
+		info.field("Votes", strconv.Itoa(p.TotalVotes()))
+		info.field("Closed", yesNo(p.Closed))
+		results := newTable("OPTION", "VOTES", "PERCENT")
+		for _, r := range p.Results() {
+			results.add(r.Text, strconv.Itoa(r.Votes), strconv.FormatFloat(r.Percent, 'f', 2, 64)+"%")
+		}
+		tables = append(tables, results)
+	}
+	posts := newTable("#", "ID", "AUTHOR", "CREATED", "BODY")
+	for _, p := range d.Posts {
+		posts.add(strconv.Itoa(p.Number), strconv.Itoa(p.ID), p.CreatedBy.Name, datetime(p.CreatedAt), excerpt(p.Body, 60))
+	}
+	return append(tables, posts)
+}
@@ -0,0 +1,693 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+const shellHelp = `Commands:
+  list                       show the entries that match the filter
+  filter [<field><op><value> ...]
+                             keep the entries that match all the conditions,
+                             or all entries without conditions; fields are
+                             status, score, progress, volumes, tag and title;
+                             operators are = != < <= > >= and ~ (contains)
+  select all|<n>[-<m>],...   select entries by their number in the list
+  unselect all|<n>[-<m>],... unselect entries
+  selected                   show the selected entries
+  set <field>=<value> ...    edit the selected entries; fields are status,
+                             score, progress, volumes and tags; use
+                             progress+=<n>, tags+=<tag> and tags-=<tag> to
+                             change the current values
+  diff                       show the changes that have not been applied
+  reset                      discard the changes that have not been applied
+  apply                      update the list with the changes after confirming
+  help                       show this help
+  quit                       leave without applying the changes`
+
+// listState is the part of an entry of a list that can be edited in the
+// shell. Progress is the number of episodes watched or chapters read.
+type listState struct {
+	status   string
+	score    int
+	progress int
+	volumes  int
+	tags     []string
+}
+
+func (s listState) clone() listState {
+	s.tags = append([]string(nil), s.tags...)
+	return s
+}
+
+// shellEntry is an entry of the list being edited.
+type shellEntry struct {
+	id           int
+	title        string
+	total        int
+	totalVolumes int
+	orig, cur    listState
+}
+
+func (e *shellEntry) modified() bool {
+	return len(e.changes("")) > 0
+}
+
+// changes describes the pending changes of the entry, naming the progress
+// field progressName.
+func (e *shellEntry) changes(progressName string) []string {
+	var c []string
+	if e.orig.status != e.cur.status {
+		c = append(c, fmt.Sprintf("status: %s -> %s", e.orig.status, e.cur.status))
+	}
+	if e.orig.score != e.cur.score {
+		c = append(c, fmt.Sprintf("score: %d -> %d", e.orig.score, e.cur.score))
+	}
+	if e.orig.progress != e.cur.progress {
+		c = append(c, fmt.Sprintf("%s: %d -> %d", progressName, e.orig.progress, e.cur.progress))
+	}
+	if e.orig.volumes != e.cur.volumes {
+		c = append(c, fmt.Sprintf("volumes: %d -> %d", e.orig.volumes, e.cur.volumes))
+	}
+	if !equalStrings(e.orig.tags, e.cur.tags) {
+		c = append(c, fmt.Sprintf("tags: [%s] -> [%s]", strings.Join(e.orig.tags, ", "), strings.Join(e.cur.tags, ", ")))
+	}
+	return c
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// shell edits the anime or manga list of the authenticated user.
+type shell struct {
+	kind     string
+	client   *mal.Client
+	in       *bufio.Scanner
+	out      io.Writer
+	errOut   io.Writer
+	prompt   bool
+	entries  []*shellEntry
+	view     []*shellEntry
+	selected map[*shellEntry]bool
+	// failed is the error of the last update that failed. It is cleared when
+	// all the changes are applied.
+	failed error
+}
+
+func (s *shell) progressName() string {
+	if s.kind == "manga" {
+		return "chapters"
+	}
+	return "episodes"
+}
+
+func (s *shell) statuses() []string {
+	if s.kind == "manga" {
+		return mangaStatuses
+	}
+	return animeStatuses
+}
+
+func listEdit(ctx context.Context, a *app, cmd *command, args []string) error {
+	args, err := parseFlags(a.flags(cmd), args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, 1); err != nil {
+		return err
+	}
+	if err := oneOf("list", args[0], "anime", "manga"); err != nil {
+		return err
+	}
+	c, err := a.mal(ctx)
+	if err != nil {
+		return err
+	}
+	s := &shell{
+		kind:     args[0],
+		client:   c,
+		in:       bufio.NewScanner(a.stdin),
+		out:      a.stdout,
+		errOut:   a.stderr,
+		prompt:   isTerminal(a.stdin),
+		selected: make(map[*shellEntry]bool),
+	}
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	return s.run(ctx)
+}
+
+// isTerminal reports whether r is a terminal. The prompts are only shown on
+// terminals so that scripts can pipe commands.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func (s *shell) load(ctx context.Context) error {
+	if s.kind == "anime" {
+		return s.client.User.WalkAnimeList(ctx, "@me", func(page []mal.UserAnime) error {
+			for _, a := range page {
+				st := listState{
+					status:   string(a.Status.Status),
+					score:    a.Status.Score,
+					progress: a.Status.NumEpisodesWatched,
+					tags:     a.Status.Tags,
+				}
+				s.entries = append(s.entries, &shellEntry{id: a.Anime.ID, title: a.Anime.Title, total: a.Anime.NumEpisodes, orig: st, cur: st.clone()})
+			}
+			return nil
+		}, mal.Fields{"list_status{tags}", "num_episodes"}, mal.SortAnimeListByAnimeTitle, mal.Limit(1000))
+	}
+	return s.client.User.WalkMangaList(ctx, "@me", func(page []mal.UserManga) error {
+		for _, m := range page {
+			st := listState{
+				status:   string(m.Status.Status),
+				score:    m.Status.Score,
+				progress: m.Status.NumChaptersRead,
+				volumes:  m.Status.NumVolumesRead,
+				tags:     m.Status.Tags,
+			}
+			s.entries = append(s.entries, &shellEntry{id: m.Manga.ID, title: m.Manga.Title, total: m.Manga.NumChapters, totalVolumes: m.Manga.NumVolumes, orig: st, cur: st.clone()})
+		}
+		return nil
+	}, mal.Fields{"list_status{tags}", "num_chapters", "num_volumes"}, mal.SortMangaListByMangaTitle, mal.Limit(1000))
+}
+
+func (s *shell) readLine(prompt string) (string, bool) {
+	if s.prompt {
+		fmt.Fprint(s.errOut, prompt)
+	}
+	if !s.in.Scan() {
+		return "", false
+	}
+	return strings.TrimSpace(s.in.Text()), true
+}
+
+func (s *shell) run(ctx context.Context) error {
+	s.view = s.entries
+	fmt.Fprintf(s.errOut, "Loaded %d %s entries. Type 'help' for the commands.\n", len(s.entries), s.kind)
+	for {
+		line, ok := s.readLine(s.kind + "> ")
+		if !ok {
+			break
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		name, args := fields[0], fields[1:]
+		var err error
+		switch name {
+		case "list", "ls":
+			err = s.list()
+		case "filter":
+			err = s.filter(args)
+		case "select":
+			err = s.selectEntries(args, true)
+		case "unselect":
+			err = s.selectEntries(args, false)
+		case "selected":
+			err = s.writeEntries(s.selection())
+		case "set":
+			err = s.set(args)
+		case "diff":
+			err = s.diff()
+		case "reset":
+			s.reset()
+		case "apply":
+			err = s.apply(ctx)
+		case "help":
+			fmt.Fprintln(s.out, shellHelp)
+		case "quit", "exit":
+			return s.quit()
+		default:
+			err = fmt.Errorf("unknown command %q, type 'help' for the commands", name)
+		}
+		if err != nil {
+			fmt.Fprintf(s.errOut, "error: %v\n", err)
+		}
+	}
+	if err := s.in.Err(); err != nil {
+		return err
+	}
+	return s.quit()
+}
+
+func (s *shell) quit() error {
+	if n := len(s.pending()); n > 0 {
+		fmt.Fprintf(s.errOut, "Discarded %d changes that were not applied.\n", n)
+	}
+	return s.failed
+}
+
+func (s *shell) list() error {
+	return s.writeEntries(s.view)
+}
+
+func (s *shell) writeEntries(entries []*shellEntry) error {
+	header := []string{"#", "ID", "TITLE", "STATUS", "SCORE", strings.ToUpper(s.progressName())}
+	if s.kind == "manga" {
+		header = append(header, "VOLUMES")
+	}
+	t := newTable(append(header, "TAGS")...)
+	for i, e := range entries {
+		n := strconv.Itoa(i + 1)
+		if s.selected[e] {
+			n = "*" + n
+		}
+		title := e.title
+		if e.modified() {
+			title += " (modified)"
+		}
+		row := []string{n, strconv.Itoa(e.id), title, e.cur.status, num(e.cur.score), progress(e.cur.progress, e.total)}
+		if s.kind == "manga" {
+			row = append(row, progress(e.cur.volumes, e.totalVolumes))
+		}
+		t.add(append(row, strings.Join(e.cur.tags, ","))...)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(s.errOut, "No entries.")
+		return nil
+	}
+	return writeTables(s.out, t)
+}
+
+var conditionRE = regexp.MustCompile(`^([a-z]+)(!=|<=|>=|=|<|>|~)(.*)$`)
+
+// condition is a filter condition such as "score>=7".
+type condition struct {
+	field, op, value string
+	n                int
+}
+
+func parseCondition(s string) (condition, error) {
+	m := conditionRE.FindStringSubmatch(s)
+	if m == nil {
+		return condition{}, fmt.Errorf("invalid condition %q", s)
+	}
+	c := condition{field: m[1], op: m[2], value: m[3]}
+	switch c.field {
+	case "status", "tag", "title":
+		if c.op != "=" && c.op != "!=" && c.op != "~" {
+			return condition{}, fmt.Errorf("invalid operator %q for %s", c.op, c.field)
+		}
+	case "score", "progress", "episodes", "chapters", "volumes":
+		if c.op == "~" {
+			return condition{}, fmt.Errorf("invalid operator %q for %s", c.op, c.field)
+		}
+		n, err := strconv.Atoi(c.value)
+		if err != nil {
+			return condition{}, fmt.Errorf("invalid number %q in condition %q", c.value, s)
+		}
+		c.n = n
+	default:
+		return condition{}, fmt.Errorf("unknown field %q in condition %q", c.field, s)
+	}
+	return c, nil
+}
+
+func (c condition) match(e *shellEntry) bool {
+	var n int
+	switch c.field {
+	case "status":
+		return c.matchString(e.cur.status)
+	case "title":
+		return c.matchString(e.title)
+	case "tag":
+		// tag!=x matches the entries without the tag x.
+		has := condition{op: c.op, value: c.value}
+		if c.op == "!=" {
+			has.op = "="
+		}
+		for _, t := range e.cur.tags {
+			if has.matchString(t) {
+				return c.op != "!="
+			}
+		}
+		return c.op == "!="
+	case "score":
+		n = e.cur.score
+	case "progress", "episodes", "chapters":
+		n = e.cur.progress
+	case "volumes":
+		n = e.cur.volumes
+	}
+	switch c.op {
+	case "=":
+		return n == c.n
+	case "!=":
+		return n != c.n
+	case "<":
+		return n < c.n
+	case "<=":
+		return n <= c.n
+	case ">":
+		return n > c.n
+	}
+	return n >= c.n
+}
+
+func (c condition) matchString(s string) bool {
+	switch c.op {
+	case "~":
+		return strings.Contains(strings.ToLower(s), strings.ToLower(c.value))
+	case "!=":
+		return !strings.EqualFold(s, c.value)
+	}
+	return strings.EqualFold(s, c.value)
+}
+
+func (s *shell) filter(args []string) error {
+	conds := make([]condition, len(args))
+	for i, a := range args {
+		c, err := parseCondition(a)
+		if err != nil {
+			return err
+		}
+		conds[i] = c
+	}
+	s.view = nil
+next:
+	for _, e := range s.entries {
+		for _, c := range conds {
+			if !c.match(e) {
+				continue next
+			}
+		}
+		s.view = append(s.view, e)
+	}
+	return s.list()
+}
+
+// parseRanges parses the numbers of entries such as "1,3-5" which must be
+// between 1 and max.
+func parseRanges(spec string, max int) ([]int, error) {
+	var ns []int
+	for _, part := range strings.Split(spec, ",") {
+		from, to := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			from, to = part[:i], part[i+1:]
+		}
+		a, err1 := strconv.Atoi(from)
+		b, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || a < 1 || b < a || b > max {
+			return nil, fmt.Errorf("invalid entry numbers %q, the list has %d entries", part, max)
+		}
+		for n := a; n <= b; n++ {
+			ns = append(ns, n)
+		}
+	}
+	return ns, nil
+}
+
+func (s *shell) selectEntries(args []string, selected bool) error {
+	if len(args) == 0 {
+		return errors.New("expected all or entry numbers such as 1,3-5")
+	}
+	var entries []*shellEntry
+	if len(args) == 1 && args[0] == "all" {
+		entries = s.view
+		if !selected {
+			entries = s.entries
+		}
+	} else {
+		ns, err := parseRanges(strings.Join(args, ","), len(s.view))
+		if err != nil {
+			return err
+		}
+		for _, n := range ns {
+			entries = append(entries, s.view[n-1])
+		}
+	}
+	for _, e := range entries {
+		if selected {
+			s.selected[e] = true
+		} else {
+			delete(s.selected, e)
+		}
+	}
+	fmt.Fprintf(s.errOut, "%d entries selected.\n", len(s.selected))
+	return nil
+}
+
+// selection returns the selected entries in list order.
+func (s *shell) selection() []*shellEntry {
+	var sel []*shellEntry
+	for _, e := range s.entries {
+		if s.selected[e] {
+			sel = append(sel, e)
+		}
+	}
+	return sel
+}
+
+var assignmentRE = regexp.MustCompile(`^([a-z]+)(\+=|-=|=)(.*)$`)
+
+func (s *shell) set(args []string) error {
+	sel := s.selection()
+	if len(sel) == 0 {
+		return errors.New("no entries are selected")
+	}
+	if len(args) == 0 {
+		return errors.New("expected assignments such as status=completed")
+	}
+	// Apply the edits to copies so that nothing changes when one of them
+	// is invalid.
+	states := make([]listState, len(sel))
+	for i, e := range sel {
+		states[i] = e.cur.clone()
+	}
+	for _, arg := range args {
+		m := assignmentRE.FindStringSubmatch(arg)
+		if m == nil {
+			return fmt.Errorf("invalid assignment %q", arg)
+		}
+		for i, e := range sel {
+			if err := s.assign(e, &states[i], m[1], m[2], m[3]); err != nil {
+				return fmt.Errorf("%s: %v", e.title, err)
+			}
+		}
+	}
+	for i, e := range sel {
+		e.cur = states[i]
+	}
+	return s.writeEntries(sel)
+}
+
+func (s *shell) assign(e *shellEntry, st *listState, field, op, value string) error {
+	if field == "tags" {
+		return assignTags(st, op, value)
+	}
+	if field == s.progressName() {
+		field = "progress"
+	}
+	if field == "status" {
+		if op != "=" {
+			return fmt.Errorf("invalid operator %q for status", op)
+		}
+		if err := oneOf("status", value, s.statuses()...); err != nil {
+			return err
+		}
+		st.status = value
+		return nil
+	}
+	var p *int
+	max := 0
+	switch {
+	case field == "score":
+		p, max = &st.score, 10
+	case field == "progress":
+		p, max = &st.progress, e.total
+	case field == "volumes" && s.kind == "manga":
+		p, max = &st.volumes, e.totalVolumes
+	default:
+		return fmt.Errorf("unknown field %q", field)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid number %q for %s", value, field)
+	}
+	switch op {
+	case "+=":
+		n = *p + n
+	case "-=":
+		n = *p - n
+	}
+	if n < 0 || max > 0 && n > max {
+		return fmt.Errorf("%s %d is out of range 0-%d", field, n, max)
+	}
+	*p = n
+	return nil
+}
+
+func assignTags(st *listState, op, value string) error {
+	var tags []string
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	switch op {
+	case "=":
+		st.tags = tags
+	case "+=":
+		for _, t := range tags {
+			if !containsFold(st.tags, t) {
+				st.tags = append(st.tags, t)
+			}
+		}
+	case "-=":
+		var kept []string
+		for _, t := range st.tags {
+			if !containsFold(tags, t) {
+				kept = append(kept, t)
+			}
+		}
+		st.tags = kept
+	}
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// pending returns the entries with changes that were not applied.
+func (s *shell) pending() []*shellEntry {
+	var p []*shellEntry
+	for _, e := range s.entries {
+		if e.modified() {
+			p = append(p, e)
+		}
+	}
+	return p
+}
+
+func (s *shell) diff() error {
+	p := s.pending()
+	if len(p) == 0 {
+		fmt.Fprintln(s.errOut, "No changes.")
+		return nil
+	}
+	t := newTable("ID", "TITLE", "CHANGES")
+	for _, e := range p {
+		t.add(strconv.Itoa(e.id), e.title, strings.Join(e.changes(s.progressName()), "; "))
+	}
+	return writeTables(s.out, t)
+}
+
+func (s *shell) reset() {
+	for _, e := range s.entries {
+		e.cur = e.orig.clone()
+	}
+	fmt.Fprintln(s.errOut, "Discarded the changes.")
+}
+
+func (s *shell) apply(ctx context.Context) error {
+	p := s.pending()
+	if len(p) == 0 {
+		fmt.Fprintln(s.errOut, "No changes.")
+		return nil
+	}
+	if err := s.diff(); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.errOut, "Apply %d changes? [y/N] ", len(p))
+	answer, _ := s.readLine("")
+	if !s.prompt {
+		fmt.Fprintln(s.errOut)
+	}
+	if a := strings.ToLower(answer); a != "y" && a != "yes" {
+		fmt.Fprintln(s.errOut, "Not applied.")
+		return nil
+	}
+	s.failed = nil
+	applied := 0
+	for _, e := range p {
+		if err := s.update(ctx, e); err != nil {
+			s.failed = err
+			fmt.Fprintf(s.errOut, "error: updating %d %s: %v\n", e.id, e.title, err)
+			continue
+		}
+		e.orig = e.cur.clone()
+		applied++
+	}
+	fmt.Fprintf(s.errOut, "Applied %d of %d changes.\n", applied, len(p))
+	if s.failed != nil {
+		return errors.New("some changes were not applied, use diff to see them and apply to retry")
+	}
+	return nil
+}
+
+// update sends the changed fields of the entry.
+func (s *shell) update(ctx context.Context, e *shellEntry) error {
+	o, c := e.orig, e.cur
+	tagsChanged := !equalStrings(o.tags, c.tags)
+	tags := mal.Tags(append([]string{}, c.tags...))
+	sort.Strings(tags)
+	if s.kind == "anime" {
+		var opts []mal.UpdateMyAnimeListStatusOption
+		if o.status != c.status {
+			opts = append(opts, mal.AnimeStatus(c.status))
+		}
+		if o.score != c.score {
+			opts = append(opts, mal.Score(c.score))
+		}
+		if o.progress != c.progress {
+			opts = append(opts, mal.NumEpisodesWatched(c.progress))
+		}
+		if tagsChanged {
+			opts = append(opts, tags)
+		}
+		_, _, err := s.client.Anime.UpdateMyListStatus(ctx, e.id, opts...)
+		return err
+	}
+	var opts []mal.UpdateMyMangaListStatusOption
+	if o.status != c.status {
+		opts = append(opts, mal.MangaStatus(c.status))
+	}
+	if o.score != c.score {
+		opts = append(opts, mal.Score(c.score))
+	}
+	if o.progress != c.progress {
+		opts = append(opts, mal.NumChaptersRead(c.progress))
+	}
+	if o.volumes != c.volumes {
+		opts = append(opts, mal.NumVolumesRead(c.volumes))
+	}
+	if tagsChanged {
+		opts = append(opts, tags)
+	}
+	_, _, err := s.client.Manga.UpdateMyListStatus(ctx, e.id, opts...)
+	return err
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/maltest"
+)
+
+func TestListEdit(t *testing.T) {
+	a, srv, stdout, stderr := newTestApp(t)
+	a.stdin = strings.NewReader(`filter status=watching
+select all
+set progress+=2 score=8 tags+=western
+diff
+apply
+n
+filter
+select 1
+set status=bogus
+set progress=27
+apply
+yes
+quit
+`)
+	if code := run(a, stdout, stderr, "list", "edit", "anime"); code != exitOK {
+		t.Fatalf("mal list edit exited with %d, stderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{
+		"*1 6 Trigun (modified) watching 8 5/26 western",
+		"6 Trigun score: 7 -> 8; episodes: 3 -> 5; tags: [] -> [western]",
+	} {
+		if !strings.Contains(squash(stdout.String()), want) {
+			t.Errorf("mal list edit output does not contain %q:\n%s", want, stdout)
+		}
+	}
+	for _, want := range []string{"Not applied.", `invalid status "bogus"`, "progress 27 is out of range 0-26", "Applied 1 of 1 changes."} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("mal list edit stderr does not contain %q:\n%s", want, stderr)
+		}
+	}
+
+	list := srv.AnimeList("foo")
+	got := list[1].Status
+	want := mal.AnimeListStatus{Status: mal.AnimeStatusWatching, Score: 8, NumEpisodesWatched: 5, Tags: []string{"western"}}
+	got.UpdatedAt = want.UpdatedAt
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("anime list status after apply\nhave: %+v\nwant: %+v", got, want)
+	}
+	if got := list[0].Status; got.Score != 10 || got.NumEpisodesWatched != 26 {
+		t.Errorf("unselected entry was updated: %+v", got)
+	}
+}
+
+func TestListEditManga(t *testing.T) {
+	a, srv, stdout, stderr := newTestApp(t)
+	a.stdin = strings.NewReader(`select 1
+set chapters=120 volumes=10 status=completed
+reset
+set tags=dark,fantasy
+apply
+y
+`)
+	if code := run(a, stdout, stderr, "list", "edit", "manga"); code != exitOK {
+		t.Fatalf("mal list edit exited with %d, stderr:\n%s", code, stderr)
+	}
+	if want := "2 Berserk tags: [] -> [dark, fantasy]"; !strings.Contains(squash(stdout.String()), want) {
+		t.Errorf("mal list edit diff does not contain %q:\n%s", want, stdout)
+	}
+	got := srv.MangaList("foo")[0].Status
+	if got.Status != mal.MangaStatusReading || got.NumChaptersRead != 100 || !reflect.DeepEqual(got.Tags, []string{"dark", "fantasy"}) {
+		t.Errorf("manga list status after apply = %+v, want only the tags updated", got)
+	}
+}
+
+func TestListEditDiscardsPending(t *testing.T) {
+	a, _, stdout, stderr := newTestApp(t)
+	a.stdin = strings.NewReader("select 1-2\nset score=1\nfly\n")
+	if code := run(a, stdout, stderr, "list", "edit", "anime"); code != exitOK {
+		t.Fatalf("mal list edit exited with %d, stderr:\n%s", code, stderr)
+	}
+	for _, want := range []string{`unknown command "fly"`, "Discarded 2 changes"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("mal list edit stderr does not contain %q:\n%s", want, stderr)
+		}
+	}
+}
+
+func TestListEditFailedUpdate(t *testing.T) {
+	a, _, stdout, stderr := newTestApp(t)
+	f, err := maltest.LoadFixtures(fixturesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := maltest.NewUnstartedServer(f)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	a.client.BaseURL, _ = url.Parse(srv.URL + "/")
+	a.stdin = strings.NewReader("select 2\nset score=5\napply\ny\n")
+	if code := run(a, stdout, stderr, "list", "edit", "anime"); code != exitServerError {
+		t.Errorf("mal list edit with failed update exited with %d, want %d, stderr:\n%s", code, exitServerError, stderr)
+	}
+	if want := "Discarded 1 changes"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("mal list edit stderr does not contain %q:\n%s", want, stderr)
+	}
+}
+
+func TestCondition(t *testing.T) {
+	e := &shellEntry{title: "Cowboy Bebop", cur: listState{status: "completed", score: 9, progress: 26, tags: []string{"Space"}}}
+	tests := []struct {
+		cond string
+		want bool
+	}{
+		{"status=completed", true},
+		{"status!=completed", false},
+		{"title~bebop", true},
+		{"title=bebop", false},
+		{"score>=9", true},
+		{"score<9", false},
+		{"progress=26", true},
+		{"tag=space", true},
+		{"tag~spa", true},
+		{"tag!=space", false},
+		{"tag!=western", true},
+	}
+	for _, tt := range tests {
+		c, err := parseCondition(tt.cond)
+		if err != nil {
+			t.Errorf("parseCondition(%q) returned error: %v", tt.cond, err)
+			continue
+		}
+		if got := c.match(e); got != tt.want {
+			t.Errorf("%q matched = %v, want %v", tt.cond, got, tt.want)
+		}
+	}
+	for _, cond := range []string{"score~9", "status<watching", "score=high", "rank=1", "score"} {
+		if _, err := parseCondition(cond); err == nil {
+			t.Errorf("parseCondition(%q) returned no error", cond)
+		}
+	}
+}
+
+func TestParseRanges(t *testing.T) {
+	got, err := parseRanges("1,3-5", 5)
+	if want := []int{1, 3, 4, 5}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRanges = %v, %v, want %v", got, err, want)
+	}
+	for _, spec := range []string{"0", "6", "3-1", "a", "1-"} {
+		if _, err := parseRanges(spec, 5); err == nil {
+			t.Errorf("parseRanges(%q) returned no error", spec)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// writeYAML writes v as a YAML document. The value is first encoded as JSON so
+// the same field names and omissions apply as in the JSON output, and the
+// order of the fields is kept.
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := decodeYAMLNode(dec)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if s, ok := n.inline(); ok {
+		b.WriteString(s + "\n")
+	} else {
+		n.write(&b, 0, false)
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+type yamlKind int
+
+const (
+	yamlScalar yamlKind = iota
+	yamlMapping
+	yamlSequence
+)
+
+// yamlNode is a decoded JSON value. Mappings keep the order of their keys.
+type yamlNode struct {
+	kind     yamlKind
+	scalar   string
+	keys     []string
+	children []*yamlNode
+}
+
+func decodeYAMLNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &yamlNode{kind: yamlSequence}
+		if t == '{' {
+			n.kind = yamlMapping
+		}
+		for dec.More() {
+			if n.kind == yamlMapping {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			child, err := decodeYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &yamlNode{scalar: yamlString(t)}, nil
+	case json.Number:
+		return &yamlNode{scalar: t.String()}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(t)}, nil
+	case nil:
+		return &yamlNode{scalar: "null"}, nil
+	}
+	return nil, fmt.Errorf("unexpected JSON token %v", tok)
+}
+
+// inline returns the node as it is written on the same line as its key,
+// which is possible for scalars and empty collections.
+func (n *yamlNode) inline() (string, bool) {
+	switch {
+	case n.kind == yamlScalar:
+		return n.scalar, true
+	case len(n.children) > 0:
+		return "", false
+	case n.kind == yamlMapping:
+		return "{}", true
+	}
+	return "[]", true
+}
+
+// write writes the entries of a mapping or a sequence indented by indent
+// spaces. If continued is true, the first entry continues the current line
+// after a sequence "- " indicator.
+func (n *yamlNode) write(b *strings.Builder, indent int, continued bool) {
+	pad := strings.Repeat(" ", indent)
+	for i, c := range n.children {
+		if i > 0 || !continued {
+			b.WriteString(pad)
+		}
+		if n.kind == yamlMapping {
+			b.WriteString(yamlString(n.keys[i]) + ":")
+		} else {
+			b.WriteString("-")
+		}
+		if s, ok := c.inline(); ok {
+			b.WriteString(" " + s + "\n")
+			continue
+		}
+		if n.kind == yamlSequence && c.kind == yamlMapping {
+			b.WriteString(" ")
+			c.write(b, indent+2, true)
+			continue
+		}
+		b.WriteString("\n")
+		c.write(b, indent+2, false)
+	}
+}
+
+// yamlString returns s as a plain scalar when it cannot be mistaken for
+// anything else and as a double-quoted scalar otherwise.
+func yamlString(s string) string {
+	if yamlPlain(s) {
+		return s
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func yamlPlain(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return false
+	}
+	if r := []rune(s)[0]; !unicode.IsLetter(r) && r != '_' && r != '/' {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) || r == '\u2028' || r == '\u2029' || r == '\ufeff' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteYAML(t *testing.T) {
+	type node struct {
+		ID    int      `json:"id"`
+		Title string   `json:"title"`
+		Tags  []string `json:"tags"`
+	}
+	type doc struct {
+		Name    string            `json:"name"`
+		Nodes   []node            `json:"nodes"`
+		Empty   []int             `json:"empty"`
+		Nested  map[string]string `json:"nested"`
+		None    map[string]string `json:"none"`
+		Matrix  [][]int           `json:"matrix"`
+		Nil     *node             `json:"nil"`
+		Enabled bool              `json:"enabled"`
+		Mean    float64           `json:"mean"`
+	}
+	v := doc{
+		Name: "Cowboy Bebop",
+		Nodes: []node{
+			{ID: 1, Title: "Session #1: Asteroid Blues", Tags: []string{"space", "yes"}},
+			{ID: 2, Title: "2001"},
+		},
+		Empty:   []int{},
+		Nested:  map[string]string{"b": "", "a": "line\nbreak"},
+		None:    map[string]string{},
+		Matrix:  [][]int{{1, 2}, {}},
+		Enabled: true,
+		Mean:    8.75,
+	}
+	want := `name: Cowboy Bebop
+nodes:
+  - id: 1
+    title: "Session #1: Asteroid Blues"
+    tags:
+      - space
+      - "yes"
+  - id: 2
+    title: "2001"
+    tags: null
+empty: []
+nested:
+  a: "line\nbreak"
+  b: ""
+none: {}
+matrix:
+  -
+    - 1
+    - 2
+  - []
+nil: null
+enabled: true
+mean: 8.75
+`
+	var b bytes.Buffer
+	if err := writeYAML(&b, v); err != nil {
+		t.Fatalf("writeYAML returned error: %v", err)
+	}
+	if got := b.String(); got != want {
+		t.Errorf("writeYAML wrote\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	if err := writeYAML(&b, "true"); err != nil {
+		t.Fatalf("writeYAML returned error: %v", err)
+	}
+	if got, want := b.String(), "\"true\"\n"; got != want {
+		t.Errorf("writeYAML of scalar wrote %q, want %q", got, want)
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Trigun", "Trigun"},
+		{"finished_airing", "finished_airing"},
+		{"Cowboy Bebop: The Movie", `"Cowboy Bebop: The Movie"`},
+		{"Re:Zero", "Re:Zero"},
+		{"", `""`},
+		{"No", `"No"`},
+		{"null", `"null"`},
+		{"2021-01-02T00:00:00Z", `"2021-01-02T00:00:00Z"`},
+		{"-1", `"-1"`},
+		{"*star", `"*star"`},
+		{" padded", `" padded"`},
+		{"ends with:", `"ends with:"`},
+		{"tab\there", `"tab\there"`},
+		{"Steins;Gate <0>", "Steins;Gate <0>"},
+		{"進撃の巨人", "進撃の巨人"},
+	}
+	for _, tt := range tests {
+		if got := yamlString(tt.in); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Package airing exports the airing schedules of the anime in MyAnimeList
+// lists as iCalendar (RFC 5545) feeds that calendar apps can subscribe to.
+//
+// Every anime with a known broadcast day and time and start date becomes an
+// event that repeats weekly for its number of episodes, or until its end date
+// when the number of episodes is not known yet. Broadcast times are in Japan
+// Standard Time, so the events are written in the Asia/Tokyo time zone and
+// calendar apps show them in the local time of the subscriber:
+//
+//	list, err := airing.Fetch(ctx, c.User, "@me")
+//	if err != nil {
+//		return err
+//	}
+//	err = airing.FromAnimeList("Airing", list).WriteICS(w)
+//
+// Handler serves the calendar of a user over HTTP.
+package airing
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+const siteURL = "https://myanimelist.net"
+
+// tzid is the time zone of the broadcast times.
+const tzid = "Asia/Tokyo"
+
+// jst is Japan Standard Time which has not observed daylight saving time
+// since 1951, so a fixed zone is exact and does not depend on the time zone
+// database of the system.
+var jst = time.FixedZone("JST", 9*60*60)
+
+// defaultDuration is the duration of the events of anime whose average
+// episode duration is not known.
+const defaultDuration = 24 * time.Minute
+
+// DefaultStatuses are the statuses of the list entries that Fetch returns by
+// default.
+var DefaultStatuses = []mal.AnimeStatus{mal.AnimeStatusWatching, mal.AnimeStatusPlanToWatch}
+
+// Fields are the anime fields that FromAnimeList uses.
+var Fields = mal.Fields{"list_status", "broadcast", "start_date", "end_date", "num_episodes", "average_episode_duration"}
+
+// pageSize is the largest page size allowed for anime lists.
+const pageSize = 1000
+
+// Fetch returns the entries of the anime list of the user indicated by
+// username (or use @me) that have one of statuses, or the DefaultStatuses if
+// none are given, with the Fields that FromAnimeList uses.
+func Fetch(ctx context.Context, s *mal.UserService, username string, statuses ...mal.AnimeStatus) ([]mal.UserAnime, error) {
+	if len(statuses) == 0 {
+		statuses = DefaultStatuses
+	}
+	var list []mal.UserAnime
+	for _, st := range statuses {
+		err := s.WalkAnimeList(ctx, username, func(page []mal.UserAnime) error {
+			list = append(list, page...)
+			return nil
+		}, st, Fields, mal.Limit(pageSize))
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s anime: %w", st, err)
+		}
+	}
+	return list, nil
+}
+
+// Calendar is an iCalendar feed of airing anime.
+type Calendar struct {
+	// Name is the name that calendar apps show for the calendar.
+	Name string
+	// Stamp is the time the calendar was created. It defaults to the time
+	// the calendar is written.
+	Stamp  time.Time
+	Events []Event
+}
+
+// Event is the weekly broadcast of an anime.
+type Event struct {
+	AnimeID int
+	Title   string
+	// Start is the first broadcast.
+	Start    time.Time
+	Duration time.Duration
+	// Count is the number of broadcasts, or 0 if it is not known.
+	Count int
+	// Until, if Count is 0, is the time of the last broadcast or the zero
+	// time if it is not known either, in which case the event repeats
+	// indefinitely.
+	Until time.Time
+	// Status and NumEpisodesWatched are the list status of the anime.
+	Status             mal.AnimeStatus
+	NumEpisodesWatched int
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// FromAnimeList returns a calendar with the events of the anime in list,
+// sorted by their first broadcast. Anime without a weekly broadcast time or a
+// full start date are skipped.
+func FromAnimeList(name string, list []mal.UserAnime) *Calendar {
+	c := &Calendar{Name: name}
+	for _, ua := range list {
+		if e, ok := animeEvent(ua); ok {
+			c.Events = append(c.Events, e)
+		}
+	}
+	sort.SliceStable(c.Events, func(i, j int) bool {
+		a, b := c.Events[i], c.Events[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		return a.AnimeID < b.AnimeID
+	})
+	return c
+}
+
+func animeEvent(ua mal.UserAnime) (Event, bool) {
+	a := ua.Anime
+	day, ok := weekdays[strings.ToLower(a.Broadcast.DayOfTheWeek)]
+	if !ok {
+		return Event{}, false
+	}
+	clock, ok := parseClock(a.Broadcast.StartTime)
+	if !ok {
+		return Event{}, false
+	}
+	start, err := time.ParseInLocation("2006-01-02", a.StartDate, jst)
+	if err != nil {
+		return Event{}, false
+	}
+	start = start.AddDate(0, 0, (int(day)-int(start.Weekday())+7)%7).Add(clock)
+
+	e := Event{
+		AnimeID:            a.ID,
+		Title:              a.Title,
+		Start:              start,
+		Duration:           time.Duration(a.AverageEpisodeDuration) * time.Second,
+		Count:              a.NumEpisodes,
+		Status:             ua.Status.Status,
+		NumEpisodesWatched: ua.Status.NumEpisodesWatched,
+	}
+	if e.Duration <= 0 {
+		e.Duration = defaultDuration
+	}
+	if e.Count <= 0 {
+		e.Count = 0
+		if end, err := time.ParseInLocation("2006-01-02", a.EndDate, jst); err == nil {
+			e.Until = end.Add(clock)
+		}
+	}
+	return e, true
+}
+
+// parseClock parses a broadcast time such as "23:30" into the time since
+// midnight. Late night times past midnight such as "25:30" are allowed.
+func parseClock(s string) (time.Duration, bool) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return 0, false
+	}
+	h, err := strconv.Atoi(s[:i])
+	if err != nil || h < 0 || h > 29 {
+		return 0, false
+	}
+	m, err := strconv.Atoi(s[i+1:])
+	if err != nil || m < 0 || m > 59 {
+		return 0, false
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, true
+}
+
+// WriteICS writes the calendar in the iCalendar format.
+func (c *Calendar) WriteICS(w io.Writer) error {
+	stamp := c.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//go-myanimelist//airing//EN")
+	iw.line("CALSCALE", "GREGORIAN")
+	iw.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		iw.line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	iw.line("BEGIN", "VTIMEZONE")
+	iw.line("TZID", tzid)
+	iw.line("BEGIN", "STANDARD")
+	iw.line("DTSTART", "19700101T000000")
+	iw.line("TZOFFSETFROM", "+0900")
+	iw.line("TZOFFSETTO", "+0900")
+	iw.line("TZNAME", "JST")
+	iw.line("END", "STANDARD")
+	iw.line("END", "VTIMEZONE")
+	for _, e := range c.Events {
+		iw.line("BEGIN", "VEVENT")
+		iw.line("UID", fmt.Sprintf("anime-%d@myanimelist.net", e.AnimeID))
+		iw.line("DTSTAMP", stamp.UTC().Format(utcLayout))
+		iw.line("DTSTART;TZID="+tzid, e.Start.In(jst).Format(localLayout))
+		iw.line("DURATION", formatDuration(e.Duration))
+		rule := "FREQ=WEEKLY"
+		if e.Count > 0 {
+			rule += ";COUNT=" + strconv.Itoa(e.Count)
+		} else if !e.Until.IsZero() {
+			rule += ";UNTIL=" + e.Until.UTC().Format(utcLayout)
+		}
+		iw.line("RRULE", rule)
+		iw.line("SUMMARY", escapeText(e.Title))
+		iw.line("DESCRIPTION", escapeText(e.description()))
+		iw.line("URL", siteURL+"/anime/"+strconv.Itoa(e.AnimeID))
+		iw.line("END", "VEVENT")
+	}
+	iw.line("END", "VCALENDAR")
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+)
+
+var statusNames = map[mal.AnimeStatus]string{
+	mal.AnimeStatusWatching:    "Watching",
+	mal.AnimeStatusCompleted:   "Completed",
+	mal.AnimeStatusOnHold:      "On hold",
+	mal.AnimeStatusDropped:     "Dropped",
+	mal.AnimeStatusPlanToWatch: "Plan to watch",
+}
+
+func (e Event) description() string {
+	var b strings.Builder
+	if name, ok := statusNames[e.Status]; ok {
+		b.WriteString(name + ". ")
+	}
+	if e.Count > 0 {
+		fmt.Fprintf(&b, "%d of %d episodes watched.", e.NumEpisodesWatched, e.Count)
+	} else {
+		fmt.Fprintf(&b, "%d episodes watched.", e.NumEpisodesWatched)
+	}
+	return b.String()
+}
+
+// formatDuration formats d as an iCalendar duration such as PT1H30M.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d <= 0 {
+		return "PT0S"
+	}
+	s := "PT"
+	if h := d / time.Hour; h > 0 {
+		s += strconv.Itoa(int(h)) + "H"
+	}
+	if m := d % time.Hour / time.Minute; m > 0 {
+		s += strconv.Itoa(int(m)) + "M"
+	}
+	if sec := d % time.Minute / time.Second; sec > 0 {
+		s += strconv.Itoa(int(sec)) + "S"
+	}
+	return s
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escapeText escapes the special characters of an iCalendar TEXT value.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// maxLine is the length in octets that content lines are folded at.
+const maxLine = 75
+
+// icsWriter writes content lines, folding them at maxLine octets and ending
+// them with CRLF. It keeps the first error.
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (iw *icsWriter) line(name, value string) {
+	if iw.err != nil {
+		return
+	}
+	s := name + ":" + value
+	limit := maxLine
+	for len(s) > limit {
+		// Never split a UTF-8 sequence, unless the value is not valid UTF-8
+		// and there is no sequence to keep whole.
+		i := limit
+		for i > 0 && s[i]&0xC0 == 0x80 {
+			i--
+		}
+		if i == 0 {
+			i = limit
+		}
+		if _, iw.err = iw.w.WriteString(s[:i] + "\r\n "); iw.err != nil {
+			return
+		}
+		s = s[i:]
+		// The space that starts a continuation line counts.
+		limit = maxLine - 1
+	}
+	_, iw.err = iw.w.WriteString(s + "\r\n")
+}
@@ -0,0 +1,186 @@
+package airing
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func testList() []mal.UserAnime {
+	return []mal.UserAnime{
+		{
+			Anime: mal.Anime{ID: 2, Title: "Late Show", StartDate: "2024-04-04", EndDate: "2024-06-27",
+				Broadcast: mal.Broadcast{DayOfTheWeek: "thursday", StartTime: "25:30"}},
+			Status: mal.AnimeListStatus{Status: mal.AnimeStatusPlanToWatch},
+		},
+		{
+			Anime: mal.Anime{ID: 1, Title: "Cowboy Bebop", StartDate: "1998-04-03", NumEpisodes: 26, AverageEpisodeDuration: 1440,
+				Broadcast: mal.Broadcast{DayOfTheWeek: "saturday", StartTime: "01:00"}},
+			Status: mal.AnimeListStatus{Status: mal.AnimeStatusWatching, NumEpisodesWatched: 3},
+		},
+		{
+			Anime: mal.Anime{ID: 3, Title: "Sousou no Frieren, Part 2; \"Journey's End\"", StartDate: "2023-10-01", AverageEpisodeDuration: 5430,
+				Broadcast: mal.Broadcast{DayOfTheWeek: "Sunday", StartTime: "17:30"}},
+			Status: mal.AnimeListStatus{Status: mal.AnimeStatusWatching, NumEpisodesWatched: 10},
+		},
+		{Anime: mal.Anime{ID: 4, StartDate: "2024-01-01", Broadcast: mal.Broadcast{DayOfTheWeek: "other", StartTime: "12:00"}}},
+		{Anime: mal.Anime{ID: 5, StartDate: "2024", Broadcast: mal.Broadcast{DayOfTheWeek: "monday", StartTime: "12:00"}}},
+		{Anime: mal.Anime{ID: 6, StartDate: "2024-01-01", Broadcast: mal.Broadcast{DayOfTheWeek: "monday"}}},
+	}
+}
+
+func TestFromAnimeList(t *testing.T) {
+	c := FromAnimeList("Airing", testList())
+	want := []Event{
+		{AnimeID: 1, Title: "Cowboy Bebop", Start: time.Date(1998, 4, 4, 1, 0, 0, 0, jst), Duration: 24 * time.Minute, Count: 26,
+			Status: mal.AnimeStatusWatching, NumEpisodesWatched: 3},
+		{AnimeID: 3, Title: "Sousou no Frieren, Part 2; \"Journey's End\"", Start: time.Date(2023, 10, 1, 17, 30, 0, 0, jst),
+			Duration: 90*time.Minute + 30*time.Second, Status: mal.AnimeStatusWatching, NumEpisodesWatched: 10},
+		{AnimeID: 2, Title: "Late Show", Start: time.Date(2024, 4, 5, 1, 30, 0, 0, jst), Duration: 24 * time.Minute,
+			Until: time.Date(2024, 6, 28, 1, 30, 0, 0, jst), Status: mal.AnimeStatusPlanToWatch},
+	}
+	if c.Name != "Airing" || !reflect.DeepEqual(c.Events, want) {
+		t.Errorf("FromAnimeList\nhave: %+v\nwant: %+v", c.Events, want)
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	c := FromAnimeList("Airing, watching", testList())
+	c.Stamp = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	if err := c.WriteICS(&buf); err != nil {
+		t.Fatalf("WriteICS returned error: %v", err)
+	}
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//go-myanimelist//airing//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Airing\, watching`,
+		"BEGIN:VTIMEZONE",
+		"TZID:Asia/Tokyo",
+		"BEGIN:STANDARD",
+		"DTSTART:19700101T000000",
+		"TZOFFSETFROM:+0900",
+		"TZOFFSETTO:+0900",
+		"TZNAME:JST",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:anime-1@myanimelist.net",
+		"DTSTAMP:20240501T120000Z",
+		"DTSTART;TZID=Asia/Tokyo:19980404T010000",
+		"DURATION:PT24M",
+		"RRULE:FREQ=WEEKLY;COUNT=26",
+		"SUMMARY:Cowboy Bebop",
+		"DESCRIPTION:Watching. 3 of 26 episodes watched.",
+		"URL:https://myanimelist.net/anime/1",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:anime-3@myanimelist.net",
+		"DTSTAMP:20240501T120000Z",
+		"DTSTART;TZID=Asia/Tokyo:20231001T173000",
+		"DURATION:PT1H30M30S",
+		"RRULE:FREQ=WEEKLY",
+		`SUMMARY:Sousou no Frieren\, Part 2\; "Journey's End"`,
+		"DESCRIPTION:Watching. 10 episodes watched.",
+		"URL:https://myanimelist.net/anime/3",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:anime-2@myanimelist.net",
+		"DTSTAMP:20240501T120000Z",
+		"DTSTART;TZID=Asia/Tokyo:20240405T013000",
+		"DURATION:PT24M",
+		"RRULE:FREQ=WEEKLY;UNTIL=20240627T163000Z",
+		"SUMMARY:Late Show",
+		"DESCRIPTION:Plan to watch. 0 episodes watched.",
+		"URL:https://myanimelist.net/anime/2",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if got := buf.String(); got != want {
+		t.Errorf("WriteICS wrote:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFolding(t *testing.T) {
+	value := strings.Repeat("フリーレン", 20)
+	var buf bytes.Buffer
+	iw := &icsWriter{w: bufio.NewWriter(&buf)}
+	iw.line("SUMMARY", value)
+	iw.w.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("line of %d octets was not folded", buf.Len())
+	}
+	var unfolded strings.Builder
+	for i, l := range lines {
+		if len(l) > maxLine {
+			t.Errorf("line %d has %d octets, want at most %d", i, len(l), maxLine)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(l, " ") {
+				t.Fatalf("continuation line %d does not start with a space: %q", i, l)
+			}
+			l = l[1:]
+		}
+		if !utf8.ValidString(l) {
+			t.Errorf("line %d splits a UTF-8 sequence", i)
+		}
+		unfolded.WriteString(l)
+	}
+	if got := unfolded.String(); got != "SUMMARY:"+value {
+		t.Errorf("unfolded line = %q, want %q", got, "SUMMARY:"+value)
+	}
+}
+
+func TestFoldingInvalidUTF8(t *testing.T) {
+	c := &Calendar{Name: strings.Repeat("\x80", 100), Stamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var buf bytes.Buffer
+	if err := c.WriteICS(&buf); err != nil {
+		t.Fatalf("WriteICS returned error: %v", err)
+	}
+	var unfolded string
+	for _, l := range strings.Split(buf.String(), "\r\n") {
+		if len(l) > maxLine {
+			t.Errorf("line has %d octets, want at most %d", len(l), maxLine)
+		}
+		if strings.HasPrefix(l, "X-WR-CALNAME") {
+			unfolded = l
+		} else if strings.HasPrefix(l, " ") && unfolded != "" {
+			unfolded += l[1:]
+		} else if unfolded != "" {
+			break
+		}
+	}
+	if want := "X-WR-CALNAME:" + c.Name; unfolded != want {
+		t.Errorf("unfolded line = %q, want %q", unfolded, want)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "PT0S"},
+		{45 * time.Second, "PT45S"},
+		{24 * time.Minute, "PT24M"},
+		{2 * time.Hour, "PT2H"},
+		{time.Hour + 5*time.Second, "PT1H5S"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package airing
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// Handler serves the airing calendar of a user. The user and the statuses of
+// the list entries are given by the query parameters of the request:
+//
+//	user    name of the user, or @me for the user of the client
+//	status  comma separated statuses such as "watching,plan_to_watch"
+//
+// For example, /airing.ics?user=foo&status=watching.
+type Handler struct {
+	User *mal.UserService
+	// Name returns the name of the calendar of a user. By default, it is
+	// "Airing anime of" followed by the name of the user.
+	Name func(username string) string
+	// Statuses are the statuses of the list entries when the request does
+	// not have any. They default to DefaultStatuses.
+	Statuses []mal.AnimeStatus
+}
+
+// ServeHTTP serves the calendar of the user of the request. It responds with
+// 400 Bad Request for invalid parameters, 404 Not Found if the user does not
+// exist and 502 Bad Gateway if fetching the list fails.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	username := q.Get("user")
+	if username == "" {
+		http.Error(w, "user is required", http.StatusBadRequest)
+		return
+	}
+	statuses, err := parseStatuses(q.Get("status"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(statuses) == 0 {
+		statuses = h.Statuses
+	}
+
+	list, err := Fetch(r.Context(), h.User, username, statuses...)
+	if err != nil {
+		code := http.StatusBadGateway
+		var errResp *mal.ErrorResponse
+		if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound {
+			code = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	name := "Airing anime of " + username
+	if h.Name != nil {
+		name = h.Name(username)
+	}
+	var buf bytes.Buffer
+	if err := FromAnimeList(name, list).WriteICS(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func parseStatuses(s string) ([]mal.AnimeStatus, error) {
+	var statuses []mal.AnimeStatus
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		st := mal.AnimeStatus(v)
+		if _, ok := statusNames[st]; !ok {
+			return nil, errors.New("invalid status " + v)
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,111 @@
+package airing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/maltest"
+)
+
+func newTestServer(t *testing.T) *maltest.Server {
+	t.Helper()
+	f := &maltest.Fixtures{Me: "foo"}
+	u := maltest.User{User: mal.User{Name: "foo"}}
+	for _, ua := range testList() {
+		f.Anime = append(f.Anime, ua.Anime)
+		if ua.Status.Status == "" {
+			ua.Status.Status = mal.AnimeStatusCompleted
+		}
+		u.AnimeList = append(u.AnimeList, mal.UserAnime{Anime: mal.Anime{ID: ua.Anime.ID}, Status: ua.Status})
+	}
+	f.Users = append(f.Users, u)
+	srv := maltest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHandler(t *testing.T) {
+	srv := newTestServer(t)
+	h := &Handler{User: srv.Client().User}
+
+	tests := []struct {
+		target string
+		name   string
+		uids   []string
+	}{
+		{"/airing.ics?user=foo", "Airing anime of foo", []string{"anime-1@", "anime-3@", "anime-2@"}},
+		{"/airing.ics?user=@me&status=plan_to_watch", "Airing anime of @me", []string{"anime-2@"}},
+		{"/airing.ics?user=foo&status=completed,watching", "Airing anime of foo", []string{"anime-1@", "anime-3@"}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d: %s", tt.target, rec.Code, http.StatusOK, rec.Body)
+		}
+		if got, want := rec.Header().Get("Content-Type"), "text/calendar; charset=utf-8"; got != want {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.target, got, want)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "X-WR-CALNAME:"+tt.name+"\r\n") {
+			t.Errorf("%s: calendar is not named %q:\n%s", tt.target, tt.name, body)
+		}
+		var uids []string
+		for _, l := range strings.Split(body, "\r\n") {
+			if strings.HasPrefix(l, "UID:") {
+				uids = append(uids, strings.TrimSuffix(strings.TrimPrefix(l, "UID:"), "myanimelist.net"))
+			}
+		}
+		if strings.Join(uids, " ") != strings.Join(tt.uids, " ") {
+			t.Errorf("%s: calendar has events %v, want %v", tt.target, uids, tt.uids)
+		}
+	}
+}
+
+func TestHandlerName(t *testing.T) {
+	srv := newTestServer(t)
+	h := &Handler{
+		User:     srv.Client().User,
+		Name:     func(username string) string { return username + "'s shows" },
+		Statuses: []mal.AnimeStatus{mal.AnimeStatusPlanToWatch},
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/airing.ics?user=foo", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "X-WR-CALNAME:foo's shows\r\n") || strings.Count(body, "BEGIN:VEVENT") != 1 {
+		t.Errorf("calendar with default statuses of the handler:\n%s", body)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	srv := newTestServer(t)
+	h := &Handler{User: srv.Client().User}
+
+	tests := []struct {
+		method string
+		target string
+		code   int
+	}{
+		{http.MethodPost, "/airing.ics?user=foo", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/airing.ics", http.StatusBadRequest},
+		{http.MethodGet, "/airing.ics?user=foo&status=watched", http.StatusBadRequest},
+		{http.MethodGet, "/airing.ics?user=bar", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, rec.Code, tt.code)
+		}
+	}
+
+	srv.Close()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/airing.ics?user=foo", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("closed server: status = %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,246 @@
+package mal
+
+import (
+	"context"
+	"sort"
+	"strconv"
+)
+
+// AnimeAnalytics are analytics of an anime list that go beyond the
+// AnimeStatistics of MyAnimeList, such as the time left to watch the backlog
+// and breakdowns by genre, studio, season, media type and year. They are
+// computed from the user's anime list, see UserService.ComputeAnimeAnalytics.
+//
+// Times are in hours and are derived from the number of episodes and the
+// average episode duration of each anime.
+type AnimeAnalytics struct {
+	NumItems int `json:"num_items"`
+	// NumEpisodesWatched includes the episodes of completed rewatches.
+	NumEpisodesWatched int     `json:"num_episodes_watched"`
+	HoursWatched       float64 `json:"hours_watched"`
+	// NumEpisodesRemaining and HoursRemaining are the episodes and the time
+	// left to watch of the anime that are planned to watch (HoursPlanned),
+	// and in progress, that is watching or on hold (HoursInProgress).
+	NumEpisodesRemaining int     `json:"num_episodes_remaining"`
+	HoursRemaining       float64 `json:"hours_remaining"`
+	HoursPlanned         float64 `json:"hours_planned"`
+	HoursInProgress      float64 `json:"hours_in_progress"`
+	// NumItemsUnknownLength is the number of planned and in progress anime
+	// whose number of episodes or episode duration is not known yet, which
+	// are not included in the remaining time.
+	NumItemsUnknownLength int `json:"num_items_unknown_length"`
+	// CompletionRate is the fraction of the anime that were started, that
+	// is not planned to watch, that are completed.
+	CompletionRate float64 `json:"completion_rate"`
+	MeanScore      float64 `json:"mean_score"`
+	// ScoreDistribution is the number of anime with each score, where index
+	// 0 is score 1 and index 9 is score 10. Unscored anime are not counted.
+	ScoreDistribution [10]int `json:"score_distribution"`
+	// The breakdowns by genre, studio and media type are sorted by the
+	// number of anime, most first. The breakdowns by season, such as
+	// "2023 fall", and year are sorted chronologically.
+	Genres     []AnimeGroup `json:"genres"`
+	Studios    []AnimeGroup `json:"studios"`
+	MediaTypes []AnimeGroup `json:"media_types"`
+	Seasons    []AnimeGroup `json:"seasons"`
+	Years      []AnimeGroup `json:"years"`
+}
+
+// AnimeGroup are the analytics of the anime of a list that share a genre,
+// studio, media type, season or year.
+type AnimeGroup struct {
+	Name               string  `json:"name"`
+	NumItems           int     `json:"num_items"`
+	NumItemsCompleted  int     `json:"num_items_completed"`
+	NumEpisodesWatched int     `json:"num_episodes_watched"`
+	HoursWatched       float64 `json:"hours_watched"`
+	HoursRemaining     float64 `json:"hours_remaining"`
+	MeanScore          float64 `json:"mean_score"`
+}
+
+type animeGroup struct {
+	AnimeGroup
+	scores scoreMean
+}
+
+// groupSet accumulates the groups of a breakdown by name.
+type groupSet map[string]*animeGroup
+
+func (gs groupSet) add(name string, a UserAnime, episodes int, watched, remaining float64) {
+	if name == "" {
+		return
+	}
+	g, ok := gs[name]
+	if !ok {
+		g = &animeGroup{AnimeGroup: AnimeGroup{Name: name}}
+		gs[name] = g
+	}
+	g.NumItems++
+	if a.Status.Status == AnimeStatusCompleted {
+		g.NumItemsCompleted++
+	}
+	g.NumEpisodesWatched += episodes
+	g.HoursWatched += watched
+	g.HoursRemaining += remaining
+	g.scores.add(a.Status.Score)
+}
+
+// sorted returns the groups sorted by the number of anime, most first, and
+// then by name, or only by name if byName is true.
+func (gs groupSet) sorted(byName bool) []AnimeGroup {
+	groups := make([]AnimeGroup, 0, len(gs))
+	for _, g := range gs {
+		g.HoursWatched = round2(g.HoursWatched)
+		g.HoursRemaining = round2(g.HoursRemaining)
+		g.MeanScore = g.scores.mean()
+		groups = append(groups, g.AnimeGroup)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if !byName && groups[i].NumItems != groups[j].NumItems {
+			return groups[i].NumItems > groups[j].NumItems
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// seasonOrder orders the seasons of a year.
+var seasonOrder = map[string]string{"winter": "1", "spring": "2", "summer": "3", "fall": "4"}
+
+// NewAnimeAnalytics computes analytics from the entries of an anime list. The
+// anime of the list need the fields that UserService.ComputeAnimeAnalytics
+// requests. Entries without a score are not included in the mean scores.
+func NewAnimeAnalytics(list []UserAnime) AnimeAnalytics {
+	aa := newAnimeAnalytics()
+	aa.add(list)
+	return aa.result()
+}
+
+// animeAnalytics accumulates the analytics of an anime list page by page.
+type animeAnalytics struct {
+	an                          AnimeAnalytics
+	scores                      scoreMean
+	genres, studios, mediaTypes groupSet
+	seasons, years              groupSet
+	// seasonKeys maps the names of the seasons to keys that sort
+	// chronologically.
+	seasonKeys         map[string]string
+	started, completed int
+}
+
+func newAnimeAnalytics() *animeAnalytics {
+	return &animeAnalytics{
+		genres:     groupSet{},
+		studios:    groupSet{},
+		mediaTypes: groupSet{},
+		seasons:    groupSet{},
+		years:      groupSet{},
+		seasonKeys: make(map[string]string),
+	}
+}
+
+func (aa *animeAnalytics) add(list []UserAnime) {
+	an := &aa.an
+	for _, a := range list {
+		hoursPerEpisode := float64(a.Anime.AverageEpisodeDuration) / (60 * 60)
+		episodes := a.Status.NumEpisodesWatched + a.Status.NumTimesRewatched*a.Anime.NumEpisodes
+		watched := float64(episodes) * hoursPerEpisode
+
+		var remaining float64
+		switch st := a.Status.Status; st {
+		case AnimeStatusPlanToWatch, AnimeStatusWatching, AnimeStatusOnHold:
+			if a.Anime.NumEpisodes <= 0 || a.Anime.AverageEpisodeDuration <= 0 {
+				an.NumItemsUnknownLength++
+				break
+			}
+			left := a.Anime.NumEpisodes
+			if st != AnimeStatusPlanToWatch {
+				left -= a.Status.NumEpisodesWatched
+			}
+			if left < 0 {
+				left = 0
+			}
+			remaining = float64(left) * hoursPerEpisode
+			an.NumEpisodesRemaining += left
+			if st == AnimeStatusPlanToWatch {
+				an.HoursPlanned += remaining
+			} else {
+				an.HoursInProgress += remaining
+			}
+		}
+		if a.Status.Status != AnimeStatusPlanToWatch {
+			aa.started++
+		}
+		if a.Status.Status == AnimeStatusCompleted {
+			aa.completed++
+		}
+
+		an.NumItems++
+		an.NumEpisodesWatched += episodes
+		an.HoursWatched += watched
+		if s := a.Status.Score; s >= 1 && s <= 10 {
+			an.ScoreDistribution[s-1]++
+		}
+		aa.scores.add(a.Status.Score)
+
+		for _, g := range a.Anime.Genres {
+			aa.genres.add(g.Name, a, episodes, watched, remaining)
+		}
+		for _, s := range a.Anime.Studios {
+			aa.studios.add(s.Name, a, episodes, watched, remaining)
+		}
+		aa.mediaTypes.add(a.Anime.MediaType, a, episodes, watched, remaining)
+		year := a.Anime.StartSeason.Year
+		if ss := a.Anime.StartSeason; ss.Year > 0 && ss.Season != "" {
+			name := strconv.Itoa(ss.Year) + " " + ss.Season
+			aa.seasonKeys[name] = strconv.Itoa(ss.Year) + seasonOrder[ss.Season] + ss.Season
+			aa.seasons.add(name, a, episodes, watched, remaining)
+		}
+		if year == 0 && len(a.Anime.StartDate) >= 4 {
+			year, _ = strconv.Atoi(a.Anime.StartDate[:4])
+		}
+		if year > 0 {
+			aa.years.add(strconv.Itoa(year), a, episodes, watched, remaining)
+		}
+	}
+}
+
+// result returns the analytics of the anime added so far. It rounds the
+// groups in place, so it is called once after all the pages are added.
+func (aa *animeAnalytics) result() AnimeAnalytics {
+	an := aa.an
+	an.HoursWatched = round2(an.HoursWatched)
+	an.HoursPlanned = round2(an.HoursPlanned)
+	an.HoursInProgress = round2(an.HoursInProgress)
+	an.HoursRemaining = round2(an.HoursPlanned + an.HoursInProgress)
+	if aa.started > 0 {
+		an.CompletionRate = round2(float64(aa.completed) / float64(aa.started))
+	}
+	an.MeanScore = aa.scores.mean()
+	an.Genres = aa.genres.sorted(false)
+	an.Studios = aa.studios.sorted(false)
+	an.MediaTypes = aa.mediaTypes.sorted(false)
+	an.Seasons = aa.seasons.sorted(true)
+	sort.SliceStable(an.Seasons, func(i, j int) bool {
+		return aa.seasonKeys[an.Seasons[i].Name] < aa.seasonKeys[an.Seasons[j].Name]
+	})
+	an.Years = aa.years.sorted(true)
+	return an
+}
+
+// ComputeAnimeAnalytics walks the whole anime list of the user indicated by
+// username (or use @me) and computes their anime analytics, adding each page
+// as it is received. The fields that the analytics need are requested
+// automatically.
+func (s *UserService) ComputeAnimeAnalytics(ctx context.Context, username string) (*AnimeAnalytics, error) {
+	aa := newAnimeAnalytics()
+	err := s.WalkAnimeList(ctx, username, func(page []UserAnime) error {
+		aa.add(page)
+		return nil
+	}, Fields{"list_status{num_times_rewatched}", "num_episodes", "average_episode_duration", "genres", "studios", "media_type", "start_season", "start_date"}, Limit(1000))
+	if err != nil {
+		return nil, err
+	}
+	an := aa.result()
+	return &an, nil
+}
@@ -0,0 +1,147 @@
+package mal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNewAnimeAnalytics(t *testing.T) {
+	const minutes = 60
+	list := []UserAnime{
+		{
+			Anime: Anime{NumEpisodes: 24, AverageEpisodeDuration: 24 * minutes, MediaType: "tv",
+				Genres: []Genre{{Name: "Action"}, {Name: "Sci-Fi"}}, Studios: []Studio{{Name: "Sunrise"}},
+				StartSeason: StartSeason{Year: 1998, Season: "spring"}},
+			Status: AnimeListStatus{Status: AnimeStatusCompleted, NumEpisodesWatched: 24, NumTimesRewatched: 1, Score: 9},
+		},
+		{
+			Anime: Anime{NumEpisodes: 12, AverageEpisodeDuration: 24 * minutes, MediaType: "tv",
+				Genres: []Genre{{Name: "Action"}}, Studios: []Studio{{Name: "Madhouse"}},
+				StartSeason: StartSeason{Year: 2023, Season: "fall"}},
+			Status: AnimeListStatus{Status: AnimeStatusWatching, NumEpisodesWatched: 6, Score: 6},
+		},
+		{
+			Anime: Anime{NumEpisodes: 1, AverageEpisodeDuration: 120 * minutes, MediaType: "movie",
+				Genres: []Genre{{Name: "Drama"}}, Studios: []Studio{{Name: "Sunrise"}}, StartDate: "2001-09-01"},
+			Status: AnimeListStatus{Status: AnimeStatusPlanToWatch},
+		},
+		{
+			Anime: Anime{NumEpisodes: 12, AverageEpisodeDuration: 24 * minutes, MediaType: "tv",
+				Genres: []Genre{{Name: "Action"}}, StartSeason: StartSeason{Year: 2023, Season: "winter"}},
+			Status: AnimeListStatus{Status: AnimeStatusDropped, NumEpisodesWatched: 2, Score: 4},
+		},
+		{
+			Anime:  Anime{AverageEpisodeDuration: 24 * minutes, MediaType: "tv", StartSeason: StartSeason{Year: 2024, Season: "spring"}},
+			Status: AnimeListStatus{Status: AnimeStatusOnHold, NumEpisodesWatched: 1},
+		},
+	}
+	got := NewAnimeAnalytics(list)
+	want := AnimeAnalytics{
+		NumItems:              5,
+		NumEpisodesWatched:    57,
+		HoursWatched:          22.8,
+		NumEpisodesRemaining:  7,
+		HoursRemaining:        4.4,
+		HoursPlanned:          2,
+		HoursInProgress:       2.4,
+		NumItemsUnknownLength: 1,
+		CompletionRate:        0.25,
+		MeanScore:             6.33,
+		ScoreDistribution:     [10]int{3: 1, 5: 1, 8: 1},
+		Genres: []AnimeGroup{
+			{Name: "Action", NumItems: 3, NumItemsCompleted: 1, NumEpisodesWatched: 56, HoursWatched: 22.4, HoursRemaining: 2.4, MeanScore: 6.33},
+			{Name: "Drama", NumItems: 1, HoursRemaining: 2},
+			{Name: "Sci-Fi", NumItems: 1, NumItemsCompleted: 1, NumEpisodesWatched: 48, HoursWatched: 19.2, MeanScore: 9},
+		},
+		Studios: []AnimeGroup{
+			{Name: "Sunrise", NumItems: 2, NumItemsCompleted: 1, NumEpisodesWatched: 48, HoursWatched: 19.2, HoursRemaining: 2, MeanScore: 9},
+			{Name: "Madhouse", NumItems: 1, NumEpisodesWatched: 6, HoursWatched: 2.4, HoursRemaining: 2.4, MeanScore: 6},
+		},
+		MediaTypes: []AnimeGroup{
+			{Name: "tv", NumItems: 4, NumItemsCompleted: 1, NumEpisodesWatched: 57, HoursWatched: 22.8, HoursRemaining: 2.4, MeanScore: 6.33},
+			{Name: "movie", NumItems: 1, HoursRemaining: 2},
+		},
+		Seasons: []AnimeGroup{
+			{Name: "1998 spring", NumItems: 1, NumItemsCompleted: 1, NumEpisodesWatched: 48, HoursWatched: 19.2, MeanScore: 9},
+			{Name: "2023 winter", NumItems: 1, NumEpisodesWatched: 2, HoursWatched: 0.8, MeanScore: 4},
+			{Name: "2023 fall", NumItems: 1, NumEpisodesWatched: 6, HoursWatched: 2.4, HoursRemaining: 2.4, MeanScore: 6},
+			{Name: "2024 spring", NumItems: 1, NumEpisodesWatched: 1, HoursWatched: 0.4},
+		},
+		Years: []AnimeGroup{
+			{Name: "1998", NumItems: 1, NumItemsCompleted: 1, NumEpisodesWatched: 48, HoursWatched: 19.2, MeanScore: 9},
+			{Name: "2001", NumItems: 1, HoursRemaining: 2},
+			{Name: "2023", NumItems: 2, NumEpisodesWatched: 8, HoursWatched: 3.2, HoursRemaining: 2.4, MeanScore: 5},
+			{Name: "2024", NumItems: 1, NumEpisodesWatched: 1, HoursWatched: 0.4},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewAnimeAnalytics\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestUserServiceComputeAnimeAnalytics(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/animelist", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		offset := r.URL.Query().Get("offset")
+		testURLValues(t, r, urlValues{
+			"fields": "list_status{num_times_rewatched},num_episodes,average_episode_duration,genres,studios,media_type,start_season,start_date",
+			"limit":  "1000",
+			"offset": offset,
+		})
+		switch offset {
+		case "0":
+			fmt.Fprint(w, `{"data": [{"node": {"id": 1, "num_episodes": 2, "average_episode_duration": 1800, "media_type": "tv"}, "list_status": {"status": "watching", "num_episodes_watched": 1, "score": 8}}], "paging": {"next": "?offset=1"}}`)
+		case "1":
+			fmt.Fprint(w, `{"data": [{"node": {"id": 2, "num_episodes": 1, "average_episode_duration": 3600, "media_type": "tv"}, "list_status": {"status": "completed", "num_episodes_watched": 1, "score": 6}}]}`)
+		default:
+			t.Errorf("unexpected offset %q", offset)
+		}
+	})
+
+	ctx := context.Background()
+	got, err := client.User.ComputeAnimeAnalytics(ctx, "foo")
+	if err != nil {
+		t.Fatalf("User.ComputeAnimeAnalytics returned error: %v", err)
+	}
+	want := &AnimeAnalytics{
+		NumItems:             2,
+		NumEpisodesWatched:   2,
+		HoursWatched:         1.5,
+		NumEpisodesRemaining: 1,
+		HoursRemaining:       0.5,
+		HoursInProgress:      0.5,
+		CompletionRate:       0.5,
+		MeanScore:            7,
+		ScoreDistribution:    [10]int{5: 1, 7: 1},
+		Genres:               []AnimeGroup{},
+		Studios:              []AnimeGroup{},
+		MediaTypes:           []AnimeGroup{{Name: "tv", NumItems: 2, NumItemsCompleted: 1, NumEpisodesWatched: 2, HoursWatched: 1.5, HoursRemaining: 0.5, MeanScore: 7}},
+		Seasons:              []AnimeGroup{},
+		Years:                []AnimeGroup{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("User.ComputeAnimeAnalytics\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestUserServiceComputeAnimeAnalyticsError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/animelist", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"mal is down","error":"internal"}`, http.StatusInternalServerError)
+	})
+
+	ctx := context.Background()
+	_, err := client.User.ComputeAnimeAnalytics(ctx, "foo")
+	if err == nil {
+		t.Fatal("User.ComputeAnimeAnalytics expected internal error, got no error.")
+	}
+	testErrorResponse(t, err, ErrorResponse{Message: "mal is down", Err: "internal"})
+}
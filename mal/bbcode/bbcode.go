@@ -0,0 +1,304 @@
+// Package bbcode parses the BBCode used by MyAnimeList forum posts and
+// signatures and renders it as sanitized HTML, Markdown or plain text.
+//
+// The bodies and signatures of the posts returned by
+// mal.ForumService.TopicDetails are raw BBCode:
+//
+//	doc := bbcode.Parse(post.Body)
+//	html := bbcode.HTML(doc, nil)
+//
+// Parsing never fails. Unknown tags and tags that cannot be matched are kept
+// as text, unclosed tags are closed at the end of the input and the HTML
+// renderer escapes all text and only emits links and images with safe URLs.
+package bbcode
+
+import (
+	"strings"
+)
+
+// NodeType is the type of a Node.
+type NodeType int
+
+const (
+	// DocumentNode is the root of a parsed document.
+	DocumentNode NodeType = iota
+	// TextNode is a run of text.
+	TextNode
+	// ElementNode is a BBCode tag along with its children.
+	ElementNode
+)
+
+// Node is a node of a parsed BBCode document.
+type Node struct {
+	Type NodeType
+	// Tag is the lower case name of an ElementNode such as "b", "quote" or
+	// "*" for list items.
+	Tag string
+	// Attr is the raw argument of an ElementNode, for example "username
+	// message=123" for [quote=username message=123].
+	Attr string
+	// Text is the text of a TextNode.
+	Text     string
+	Children []*Node
+}
+
+// maxDepth is the maximum nesting of elements. Tags nested deeper are kept as
+// text.
+const maxDepth = 64
+
+// maxAttrLen is the maximum length of a tag argument.
+const maxAttrLen = 512
+
+// knownTags are the tags supported by MyAnimeList.
+var knownTags = map[string]bool{
+	"b": true, "i": true, "u": true, "s": true,
+	"sub": true, "sup": true,
+	"center": true, "right": true, "left": true, "justify": true,
+	"color": true, "size": true,
+	"url": true, "img": true, "yt": true,
+	"quote": true, "spoiler": true, "code": true,
+	"list": true, "*": true,
+}
+
+// rawTags contain unparsed text up to their closing tag.
+var rawTags = map[string]bool{
+	"code": true, "img": true, "yt": true,
+}
+
+type tag struct {
+	name    string
+	attr    string
+	closing bool
+	length  int
+}
+
+// parseTag parses the tag at the start of s, which starts with '['.
+func parseTag(s string) (tag, bool) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 || end > maxAttrLen+16 {
+		return tag{}, false
+	}
+	body := s[1:end]
+	if strings.ContainsAny(body, "[\n") {
+		return tag{}, false
+	}
+	t := tag{length: end + 1}
+	if strings.HasPrefix(body, "/") {
+		t.closing = true
+		body = body[1:]
+	}
+	name := body
+	if i := strings.IndexAny(body, "= "); i >= 0 {
+		if t.closing {
+			return tag{}, false
+		}
+		name, t.attr = body[:i], strings.TrimSpace(body[i+1:])
+		if body[i] == ' ' {
+			// [img align=left] and similar keep their whole argument.
+			t.attr = strings.TrimSpace(body[i:])
+		}
+	}
+	t.name = asciiLower(name)
+	if !knownTags[t.name] || len(t.attr) > maxAttrLen {
+		return tag{}, false
+	}
+	return t, true
+}
+
+// Parse parses the BBCode in s into a document.
+func Parse(s string) *Node {
+	p := &parser{root: &Node{Type: DocumentNode}, unclosed: make(map[string]bool)}
+	p.stack = []*Node{p.root}
+	// Text is only added to the tree when a tag is used so that runs of
+	// brackets and unusable tags end up in a single text node.
+	pos, i := 0, 0
+	for {
+		j := strings.IndexByte(s[i:], '[')
+		if j < 0 {
+			break
+		}
+		i += j
+		t, ok := parseTag(s[i:])
+		if !ok {
+			i++
+			continue
+		}
+		if !t.closing && rawTags[t.name] {
+			rest := s[i+t.length:]
+			end := p.indexClose(rest, t.name)
+			if end < 0 || len(p.stack) > maxDepth {
+				i += t.length
+				continue
+			}
+			p.text(s[pos:i])
+			n := &Node{Type: ElementNode, Tag: t.name, Attr: t.attr}
+			if end > 0 {
+				n.Children = []*Node{{Type: TextNode, Text: rest[:end]}}
+			}
+			p.append(n)
+			i += t.length + end + len(t.name) + 3
+			pos = i
+			continue
+		}
+		at := p.target(t)
+		if at < 0 {
+			i += t.length
+			continue
+		}
+		p.text(s[pos:i])
+		p.apply(t, at)
+		i += t.length
+		pos = i
+	}
+	p.text(s[pos:])
+	return p.root
+}
+
+// indexFold is like strings.Index but ASCII case insensitive. It does not
+// copy s, so that searching the rest of a long post for each raw tag stays
+// cheap. The first byte of substr must not be a letter, like the '[' of tags.
+func indexFold(s, substr string) int {
+	if substr == "" {
+		return 0
+	}
+	for i := 0; len(s)-i >= len(substr); i++ {
+		j := strings.IndexByte(s[i:], substr[0])
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if len(s)-i < len(substr) {
+			return -1
+		}
+		if asciiEqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// asciiEqualFold reports whether s and t, which have the same length, are
+// equal when ASCII letters are compared case insensitively.
+func asciiEqualFold(s, t string) bool {
+	for i := 0; i < len(s); i++ {
+		a, b := s[i], t[i]
+		if 'A' <= a && a <= 'Z' {
+			a += 'a' - 'A'
+		}
+		if 'A' <= b && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		if a != b {
+			return false
+		}
+	}
+	return true
+}
+
+// asciiLower is like strings.ToLower for ASCII letters only. Unlike
+// strings.ToLower, it keeps the length of s the same so that indexes found in
+// its result can be used in s.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+type parser struct {
+	root     *Node
+	stack    []*Node
+	unclosed map[string]bool
+}
+
+func (p *parser) top() *Node { return p.stack[len(p.stack)-1] }
+
+func (p *parser) text(s string) {
+	if s == "" {
+		return
+	}
+	top := p.top()
+	if n := len(top.Children); n > 0 && top.Children[n-1].Type == TextNode {
+		top.Children[n-1].Text += s
+		return
+	}
+	top.Children = append(top.Children, &Node{Type: TextNode, Text: s})
+}
+
+func (p *parser) append(n *Node) {
+	top := p.top()
+	top.Children = append(top.Children, n)
+}
+
+// find returns the index in the stack of the innermost open element with the
+// given tag or -1.
+func (p *parser) find(name string) int {
+	for i := len(p.stack) - 1; i > 0; i-- {
+		if p.stack[i].Tag == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexClose returns the index of the closing tag of the raw element name in
+// s or -1. Once a closing tag is not found, it is not searched for again since
+// s is always a suffix of the previously searched text.
+func (p *parser) indexClose(s, name string) int {
+	if p.unclosed[name] {
+		return -1
+	}
+	i := indexFold(s, "[/"+name+"]")
+	if i < 0 {
+		p.unclosed[name] = true
+	}
+	return i
+}
+
+// target returns the stack index that tag t applies to or -1 if t cannot be
+// used and should be kept as text. For closing tags, it is the index of the
+// element being closed. For opening tags, it is the index of the element that
+// becomes the parent.
+func (p *parser) target(t tag) int {
+	switch {
+	case t.closing:
+		return p.find(t.name)
+	case len(p.stack) > maxDepth:
+		return -1
+	case t.name == "*":
+		// A list item closes the previous item of the same list.
+		return p.find("list")
+	}
+	return len(p.stack) - 1
+}
+
+// apply applies the tag t at the stack index returned by target.
+func (p *parser) apply(t tag, at int) {
+	if t.closing {
+		p.stack = p.stack[:at]
+		return
+	}
+	p.stack = p.stack[:at+1]
+	n := &Node{Type: ElementNode, Tag: t.name, Attr: t.attr}
+	p.append(n)
+	p.stack = append(p.stack, n)
+}
+
+// TextContent returns the concatenated text of n and its descendants.
+func (n *Node) TextContent() string {
+	var b strings.Builder
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Type == TextNode {
+			b.WriteString(n.Text)
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
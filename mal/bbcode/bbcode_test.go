@@ -0,0 +1,103 @@
+package bbcode
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dump returns a compact representation of the tree for comparisons.
+func dump(n *Node) string {
+	var b strings.Builder
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		switch n.Type {
+		case TextNode:
+			fmt.Fprintf(&b, "%q", n.Text)
+			return
+		case ElementNode:
+			b.WriteString(n.Tag)
+			if n.Attr != "" {
+				fmt.Fprintf(&b, "=%q", n.Attr)
+			}
+		}
+		b.WriteString("(")
+		for i, c := range n.Children {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			walk(c)
+		}
+		b.WriteString(")")
+	}
+	walk(n)
+	return b.String()
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", `()`},
+		{"plain text", `("plain text")`},
+		{"[b]bold[/b] text", `(b("bold") " text")`},
+		{"[B]upper[/b]", `(b("upper"))`},
+		{"[b][i]nested[/i][/b]", `(b(i("nested")))`},
+		{"[b]unclosed", `(b("unclosed"))`},
+		{"stray[/b] close", `("stray[/b] close")`},
+		{"[b][i]overlap[/b][/i]", `(b(i("overlap")) "[/i]")`},
+		{"[unknown]tag[/unknown]", `("[unknown]tag[/unknown]")`},
+		{"[[b]x[/b]]", `("[" b("x") "]")`},
+		{"[url=https://myanimelist.net]MAL[/url]", `(url="https://myanimelist.net"("MAL"))`},
+		{"[quote=foo message=123]hi[/quote]", `(quote="foo message=123"("hi"))`},
+		{"[img align=right]https://a/b.png[/img]", `(img="align=right"("https://a/b.png"))`},
+		{"[code][b]raw[/b][/CODE]", `(code("[b]raw[/b]"))`},
+		{"[code]never closed", `("[code]never closed")`},
+		{"[list][*]one[*]two[/list]", `(list(*("one") *("two")))`},
+		{"[list][*][b]one[*]two[/list]", `(list(*(b("one")) *("two")))`},
+		{"[*]no list", `("[*]no list")`},
+		{"[spoiler]hidden[/spoiler]", `(spoiler("hidden"))`},
+		{"[b\nx]y", `("[b\nx]y")`},
+	}
+	for _, tt := range tests {
+		if got := dump(Parse(tt.in)); got != tt.want {
+			t.Errorf("Parse(%q)\nhave: %s\nwant: %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMaxDepth(t *testing.T) {
+	in := strings.Repeat("[b]", maxDepth+10) + "deep"
+	n := Parse(in)
+	depth := 0
+	for len(n.Children) > 0 && n.Children[0].Type == ElementNode {
+		n = n.Children[0]
+		depth++
+	}
+	if depth > maxDepth {
+		t.Errorf("Parse nested %d elements, want at most %d", depth, maxDepth)
+	}
+	if got, want := Parse(in).TextContent(), strings.Repeat("[b]", 10)+"deep"; got != want {
+		t.Errorf("TextContent() = %q, want %q", got, want)
+	}
+}
+
+func TestIndexFold(t *testing.T) {
+	tests := []struct {
+		s, substr string
+		want      int
+	}{
+		{"abc[/CODE]x", "[/code]", 3},
+		{"[/cod[/Code]", "[/code]", 5},
+		{"[/cod", "[/code]", -1},
+		{"no tags", "[/code]", -1},
+		{"[/cKde]", "[/code]", -1},
+		{"", "[/b]", -1},
+	}
+	for _, tt := range tests {
+		if got := indexFold(tt.s, tt.substr); got != tt.want {
+			t.Errorf("indexFold(%q, %q) = %d, want %d", tt.s, tt.substr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+//go:build go1.18
+
+package bbcode
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var fuzzSeeds = []string{
+	"[b]bold[/b]",
+	"[quote=foo message=1][spoiler]x[/spoiler][/quote]",
+	"[url=https://myanimelist.net]x[/url][img]https://a/b.png[/img]",
+	"[url=javascript:alert(1)]x[/url]",
+	`[url=/\evil.example]x[/url]`,
+	"[list][*]a[*][list=1][*]b[/list][/list]",
+	"[code][/code][code]",
+	"[color=#fff][size=200]x[/color]",
+	"[[[b]]][/[/b]",
+	"[yt]abc[/yt]<script>",
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		doc := Parse(s)
+		// No text is lost or invented outside of raw elements, which drop
+		// their tags.
+		if !strings.Contains(s, "[") && doc.TextContent() != s {
+			t.Errorf("TextContent() = %q, want %q", doc.TextContent(), s)
+		}
+		_ = Markdown(doc, nil)
+		_ = Text(doc, nil)
+	})
+}
+
+var (
+	htmlTag       = regexp.MustCompile(`<(/?)([a-z]+)((?: [a-z]+="[^"<>]*")*)>`)
+	htmlAttr      = regexp.MustCompile(` ([a-z]+)="([^"]*)"`)
+	allowedTags   = map[string]bool{"b": true, "i": true, "u": true, "s": true, "sub": true, "sup": true, "div": true, "span": true, "a": true, "img": true, "blockquote": true, "cite": true, "details": true, "summary": true, "pre": true, "code": true, "ul": true, "ol": true, "li": true, "br": true}
+	allowedAttrs  = map[string]bool{"style": true, "href": true, "rel": true, "src": true, "alt": true}
+	allowedStyles = regexp.MustCompile(`^(text-align:(center|right|left|justify)|color:(#[0-9A-Fa-f]{3,6}|[A-Za-z]+)|font-size:[0-9]+%)$`)
+)
+
+func FuzzHTML(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		out := HTML(Parse(s), nil)
+		// Every '<' must start an allowed tag since all text is escaped.
+		rest := out
+		for {
+			i := strings.IndexByte(rest, '<')
+			if i < 0 {
+				break
+			}
+			rest = rest[i:]
+			loc := htmlTag.FindStringSubmatchIndex(rest)
+			if loc == nil || loc[0] != 0 {
+				t.Fatalf("HTML(%q) = %q contains an unexpected '<' at %q", s, out, rest)
+			}
+			m := htmlTag.FindStringSubmatch(rest)
+			if !allowedTags[m[2]] {
+				t.Fatalf("HTML(%q) = %q contains disallowed tag %q", s, out, m[2])
+			}
+			for _, a := range htmlAttr.FindAllStringSubmatch(m[3], -1) {
+				name, value := a[1], a[2]
+				if !allowedAttrs[name] {
+					t.Fatalf("HTML(%q) = %q contains disallowed attribute %q", s, out, name)
+				}
+				switch name {
+				case "style":
+					if !allowedStyles.MatchString(value) {
+						t.Fatalf("HTML(%q) = %q contains disallowed style %q", s, out, value)
+					}
+				case "href", "src":
+					lower := strings.ToLower(value)
+					if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") &&
+						!strings.HasPrefix(lower, "mailto:") && !strings.HasPrefix(lower, "/") {
+						t.Fatalf("HTML(%q) = %q contains unsafe URL %q", s, out, value)
+					}
+					if strings.HasPrefix(value, "//") || strings.HasPrefix(value, `/\`) {
+						t.Fatalf("HTML(%q) = %q contains protocol-relative URL %q", s, out, value)
+					}
+				}
+			}
+			rest = rest[loc[1]:]
+		}
+		if strings.Contains(out, "\x00") {
+			t.Fatalf("HTML(%q) = %q contains NUL", s, out)
+		}
+	})
+}
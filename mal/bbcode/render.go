@@ -0,0 +1,398 @@
+package bbcode
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Options customize how a document is rendered. A nil *Options renders with
+// the defaults.
+type Options struct {
+	// RewriteURL, if set, is called with the URL of every link and image and
+	// returns the URL to use instead. Returning an empty string drops the link
+	// or image, keeping only its text. Every renderer still refuses URLs that
+	// are not http, https, mailto or paths after rewriting.
+	RewriteURL func(rawURL string) string
+
+	// Spoiler, if set, renders spoilers instead of the default rendering of
+	// each format. It receives the spoiler title, which is empty when the
+	// spoiler has none, and the content already rendered in the target format.
+	// For HTML the title is escaped and the returned HTML is used as is. For
+	// example, a function returning "||" + content + "||" renders Markdown
+	// spoilers the way Discord does.
+	Spoiler func(title, content string) string
+}
+
+func (o *Options) rewrite(u string) string {
+	if o == nil || o.RewriteURL == nil {
+		return u
+	}
+	return o.RewriteURL(u)
+}
+
+func (o *Options) spoiler(title, content string, def func(title, content string) string) string {
+	if o == nil || o.Spoiler == nil {
+		return def(title, content)
+	}
+	return o.Spoiler(title, content)
+}
+
+// linkURL returns the URL of a [url] or [img] element which is either its
+// argument or its text.
+func linkURL(n *Node) string {
+	if n.Tag == "url" && n.Attr != "" {
+		return strings.TrimSpace(n.Attr)
+	}
+	return strings.TrimSpace(n.TextContent())
+}
+
+// safeURL returns u in a form that is safe to use in an HTML attribute or a
+// Markdown link. Only absolute http, https and mailto URLs and paths are
+// allowed. Paths that start with two slashes or backslashes, such as // or /\,
+// are refused as browsers treat them as links to another host.
+func safeURL(u string) (string, bool) {
+	if u == "" || hostRelative(u) {
+		return "", false
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		if parsed.Host == "" {
+			return "", false
+		}
+	case "mailto":
+	case "":
+		if parsed.Host != "" || !strings.HasPrefix(parsed.Path, "/") {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	if u = parsed.String(); hostRelative(u) {
+		return "", false
+	}
+	return u, true
+}
+
+// hostRelative reports whether u starts like a protocol-relative URL, which
+// browsers also recognize with backslashes.
+func hostRelative(u string) bool {
+	return len(u) >= 2 && (u[0] == '/' || u[0] == '\\') && (u[1] == '/' || u[1] == '\\')
+}
+
+var (
+	youtubeID  = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+	colorValue = regexp.MustCompile(`^(#[0-9A-Fa-f]{3}|#[0-9A-Fa-f]{6}|[A-Za-z]{1,20})$`)
+)
+
+func youtubeURL(n *Node) (string, bool) {
+	id := strings.TrimSpace(n.TextContent())
+	if !youtubeID.MatchString(id) {
+		return "", false
+	}
+	return "https://www.youtube.com/watch?v=" + id, true
+}
+
+// quoteAuthor returns the username of [quote=username message=123].
+func quoteAuthor(attr string) string {
+	if i := strings.Index(attr, " message="); i >= 0 {
+		attr = attr[:i]
+	}
+	return strings.Trim(attr, `"' `)
+}
+
+// escape escapes text for HTML, also replacing NUL characters which browsers
+// handle inconsistently.
+func escape(s string) string {
+	return strings.Replace(html.EscapeString(s), "\x00", "\uFFFD", -1)
+}
+
+// HTML renders the document as HTML. All text is escaped, only a fixed set of
+// elements and attributes is produced and links and images are only kept when
+// their URLs are http, https, mailto or paths.
+func HTML(doc *Node, opts *Options) string {
+	var b strings.Builder
+	r := &htmlRenderer{b: &b, opts: opts}
+	r.children(doc)
+	return b.String()
+}
+
+type htmlRenderer struct {
+	b    *strings.Builder
+	opts *Options
+}
+
+func (r *htmlRenderer) children(n *Node) {
+	for _, c := range n.Children {
+		r.node(c)
+	}
+}
+
+func (r *htmlRenderer) wrap(open, close string, n *Node) {
+	r.b.WriteString(open)
+	r.children(n)
+	r.b.WriteString(close)
+}
+
+func (r *htmlRenderer) node(n *Node) {
+	if n.Type == TextNode {
+		r.b.WriteString(strings.Replace(escape(n.Text), "\n", "<br>\n", -1))
+		return
+	}
+	switch n.Tag {
+	case "b", "i", "u", "s", "sub", "sup":
+		r.wrap("<"+n.Tag+">", "</"+n.Tag+">", n)
+	case "center", "right", "left", "justify":
+		r.wrap(`<div style="text-align:`+n.Tag+`">`, "</div>", n)
+	case "color":
+		if !colorValue.MatchString(n.Attr) {
+			r.children(n)
+			return
+		}
+		r.wrap(`<span style="color:`+n.Attr+`">`, "</span>", n)
+	case "size":
+		size, err := strconv.Atoi(n.Attr)
+		if err != nil || size <= 0 || size > 400 {
+			r.children(n)
+			return
+		}
+		r.wrap(`<span style="font-size:`+strconv.Itoa(size)+`%">`, "</span>", n)
+	case "url":
+		u, ok := safeURL(r.opts.rewrite(linkURL(n)))
+		if !ok {
+			r.children(n)
+			return
+		}
+		r.wrap(`<a href="`+html.EscapeString(u)+`" rel="nofollow noopener noreferrer">`, "</a>", n)
+	case "img":
+		u, ok := safeURL(r.opts.rewrite(linkURL(n)))
+		if !ok {
+			return
+		}
+		r.b.WriteString(`<img src="` + html.EscapeString(u) + `" alt="">`)
+	case "yt":
+		u, ok := youtubeURL(n)
+		if !ok {
+			return
+		}
+		if u, ok = safeURL(r.opts.rewrite(u)); !ok {
+			return
+		}
+		u = html.EscapeString(u)
+		r.b.WriteString(`<a href="` + u + `" rel="nofollow noopener noreferrer">` + u + `</a>`)
+	case "quote":
+		r.b.WriteString("<blockquote>")
+		if author := quoteAuthor(n.Attr); author != "" {
+			r.b.WriteString("<cite>" + escape(author) + " said:</cite>")
+		}
+		r.wrap("", "</blockquote>", n)
+	case "spoiler":
+		var content strings.Builder
+		(&htmlRenderer{b: &content, opts: r.opts}).children(n)
+		r.b.WriteString(r.opts.spoiler(escape(strings.Trim(n.Attr, `"'`)), content.String(), func(title, content string) string {
+			if title == "" {
+				title = "Spoiler"
+			}
+			return "<details><summary>" + title + "</summary>" + content + "</details>"
+		}))
+	case "code":
+		r.b.WriteString("<pre><code>" + escape(n.TextContent()) + "</code></pre>")
+	case "list":
+		tag := "ul"
+		if n.Attr == "1" {
+			tag = "ol"
+		}
+		r.b.WriteString("<" + tag + ">")
+		for _, c := range n.Children {
+			if c.Type == ElementNode && c.Tag == "*" {
+				r.wrap("<li>", "</li>", c)
+			} else if strings.TrimSpace(c.TextContent()) != "" || c.Type == ElementNode {
+				r.wrap("<li>", "</li>", &Node{Children: []*Node{c}})
+			}
+		}
+		r.b.WriteString("</" + tag + ">")
+	default:
+		r.children(n)
+	}
+}
+
+// Markdown renders the document as Markdown. Formatting that Markdown cannot
+// express, such as colors and alignment, is dropped and spoilers are rendered
+// as a paragraph labeled with their title, or "Spoiler" when they have none,
+// followed by their content unless Options.Spoiler is set.
+func Markdown(doc *Node, opts *Options) string {
+	r := &textRenderer{opts: opts, markdown: true}
+	return tidy(r.render(doc))
+}
+
+// Text renders the document as plain text. Links are followed by their URL
+// in parentheses when it differs from their text and spoilers are rendered
+// with their content unless Options.Spoiler is set. Like HTML, Markdown and
+// Text only keep the URLs of links and images that are http, https, mailto or
+// paths.
+func Text(doc *Node, opts *Options) string {
+	r := &textRenderer{opts: opts}
+	return tidy(r.render(doc))
+}
+
+var blankLines = regexp.MustCompile(`\n[ \t]*\n(\s*\n)+`)
+
+// tidy removes the extra blank lines left between blocks.
+func tidy(s string) string {
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`(`, `\(`, `)`, `\)`, `#`, `\#`, `<`, `\<`, `>`, `\>`, `~`, `\~`, `|`, `\|`,
+)
+
+// textRenderer renders Markdown and plain text which only differ in how
+// formatting is written.
+type textRenderer struct {
+	opts     *Options
+	markdown bool
+}
+
+func (r *textRenderer) render(n *Node) string {
+	var b strings.Builder
+	for _, c := range n.Children {
+		b.WriteString(r.node(c))
+	}
+	return b.String()
+}
+
+func (r *textRenderer) emphasis(marker string, n *Node) string {
+	inner := r.render(n)
+	if !r.markdown || strings.TrimSpace(inner) == "" {
+		return inner
+	}
+	return marker + inner + marker
+}
+
+// block renders s as a separate paragraph.
+func block(s string) string {
+	return "\n\n" + strings.TrimSpace(s) + "\n\n"
+}
+
+func prefixLines(s, prefix string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(prefix+lines[i], " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *textRenderer) node(n *Node) string {
+	if n.Type == TextNode {
+		if r.markdown {
+			return markdownEscaper.Replace(n.Text)
+		}
+		return n.Text
+	}
+	switch n.Tag {
+	case "b":
+		return r.emphasis("**", n)
+	case "i":
+		return r.emphasis("*", n)
+	case "s":
+		return r.emphasis("~~", n)
+	case "url":
+		inner := r.render(n)
+		u, ok := safeURL(r.opts.rewrite(linkURL(n)))
+		if !ok {
+			return inner
+		}
+		if r.markdown {
+			return "[" + inner + "](" + markdownURL(u) + ")"
+		}
+		if strings.TrimSpace(n.TextContent()) == u {
+			return u
+		}
+		return inner + " (" + u + ")"
+	case "img":
+		u, ok := safeURL(r.opts.rewrite(linkURL(n)))
+		if !ok {
+			return ""
+		}
+		if r.markdown {
+			return "![](" + markdownURL(u) + ")"
+		}
+		return u
+	case "yt":
+		u, ok := youtubeURL(n)
+		if !ok {
+			return ""
+		}
+		if u, ok = safeURL(r.opts.rewrite(u)); !ok {
+			return ""
+		}
+		if r.markdown {
+			return "<" + markdownURL(u) + ">"
+		}
+		return u
+	case "quote":
+		var header string
+		if author := quoteAuthor(n.Attr); author != "" {
+			if r.markdown {
+				author = "**" + markdownEscaper.Replace(author) + "**"
+			}
+			header = author + " said:\n"
+		}
+		return block(prefixLines(header+r.render(n), "> "))
+	case "spoiler":
+		return r.opts.spoiler(strings.Trim(n.Attr, `"'`), r.render(n), func(title, content string) string {
+			if r.markdown {
+				label := "Spoiler"
+				if title != "" {
+					label = markdownEscaper.Replace(title)
+				}
+				return block("**" + label + ":**\n\n" + strings.TrimSpace(content))
+			}
+			return content
+		})
+	case "code":
+		if r.markdown {
+			return block("```\n" + strings.Replace(n.TextContent(), "```", "` ` `", -1) + "\n```")
+		}
+		return block(n.TextContent())
+	case "list":
+		var items []string
+		num := 0
+		for _, c := range n.Children {
+			if c.Type == TextNode && strings.TrimSpace(c.Text) == "" {
+				continue
+			}
+			num++
+			marker := "- "
+			if n.Attr == "1" {
+				marker = strconv.Itoa(num) + ". "
+			}
+			var item string
+			if c.Type == ElementNode && c.Tag == "*" {
+				item = r.render(c)
+			} else {
+				item = r.node(c)
+			}
+			item = prefixLines(item, strings.Repeat(" ", len(marker)))
+			items = append(items, marker+strings.TrimLeft(item, " "))
+		}
+		return block(strings.Join(items, "\n"))
+	case "center", "right", "left", "justify":
+		return block(r.render(n))
+	default:
+		return r.render(n)
+	}
+}
+
+// markdownURL escapes the characters that would end a Markdown link
+// destination.
+func markdownURL(u string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(u)
+}
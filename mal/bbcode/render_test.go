@@ -0,0 +1,119 @@
+package bbcode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"[b]bold[/b]\nnext", "<b>bold</b><br>\nnext"},
+		{"[color=red]red[/color]", `<span style="color:red">red</span>`},
+		{`[color=red;background:url(x)]x[/color]`, "x"},
+		{"[size=150]big[/size]", `<span style="font-size:150%">big</span>`},
+		{"[size=150%]big[/size]", "big"},
+		{"[center]c[/center]", `<div style="text-align:center">c</div>`},
+		{"[url=https://myanimelist.net/?a=1&b=2]MAL[/url]", `<a href="https://myanimelist.net/?a=1&amp;b=2" rel="nofollow noopener noreferrer">MAL</a>`},
+		{"[url]https://myanimelist.net[/url]", `<a href="https://myanimelist.net" rel="nofollow noopener noreferrer">https://myanimelist.net</a>`},
+		{"[url=javascript:alert(1)]click[/url]", "click"},
+		{"[url=JaVaScRiPt:alert(1)]click[/url]", "click"},
+		{`[url=https://a" onclick="x]q[/url]`, "q"},
+		{"[url=/anime/1]a[/url]", `<a href="/anime/1" rel="nofollow noopener noreferrer">a</a>`},
+		{"[url=//evil.example]x[/url]", "x"},
+		{`[url=/\evil.example]x[/url]`, "x"},
+		{`[url=\\evil.example]x[/url]`, "x"},
+		{`[img]/\evil.example/a.png[/img]`, ""},
+		{`[url=https://a/" onclick="x]q[/url]`, `<a href="https://a/%22%20onclick=%22x" rel="nofollow noopener noreferrer">q</a>`},
+		{"[img]https://cdn.myanimelist.net/a.png[/img]", `<img src="https://cdn.myanimelist.net/a.png" alt="">`},
+		{"[img]data:image/png;base64,AAAA[/img]", ""},
+		{"[yt]dQw4w9WgXcQ[/yt]", `<a href="https://www.youtube.com/watch?v=dQw4w9WgXcQ" rel="nofollow noopener noreferrer">https://www.youtube.com/watch?v=dQw4w9WgXcQ</a>`},
+		{"[quote=foo message=1]hi[/quote]", "<blockquote><cite>foo said:</cite>hi</blockquote>"},
+		{"[spoiler=<b>]s[/spoiler]", "<details><summary>&lt;b&gt;</summary>s</details>"},
+		{"[code]<b>[b]x[/b]</b>[/code]", "<pre><code>&lt;b&gt;[b]x[/b]&lt;/b&gt;</code></pre>"},
+		{"[list=1][*]a[*]b[/list]", "<ol><li>a</li><li>b</li></ol>"},
+		{"[list]\n[*]a\n[/list]", "<ul><li>a<br>\n</li></ul>"},
+	}
+	for _, tt := range tests {
+		if got := HTML(Parse(tt.in), nil); got != tt.want {
+			t.Errorf("HTML(%q)\nhave: %q\nwant: %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLOptions(t *testing.T) {
+	opts := &Options{
+		RewriteURL: func(u string) string {
+			if strings.Contains(u, "evil") {
+				return ""
+			}
+			return "https://proxy.example/?u=" + u
+		},
+		Spoiler: func(title, content string) string {
+			return `<span class="spoiler" title="` + title + `">` + content + "</span>"
+		},
+	}
+	in := "[url=https://evil.example]x[/url] [img]https://a/b.png[/img] [spoiler=\"t\"][b]s[/b][/spoiler] [url=javascript:x]y[/url]"
+	want := `x <img src="https://proxy.example/?u=https://a/b.png" alt=""> <span class="spoiler" title="t"><b>s</b></span> ` +
+		`<a href="https://proxy.example/?u=javascript:x" rel="nofollow noopener noreferrer">y</a>`
+	if got := HTML(Parse(in), opts); got != want {
+		t.Errorf("HTML with options\nhave: %q\nwant: %q", got, want)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"[b]bold[/b] and [i]italic[/i] and [s]gone[/s]", "**bold** and *italic* and ~~gone~~"},
+		{"2*3 = [6]", `2\*3 = \[6\]`},
+		{"[url=https://myanimelist.net/a_(b)]MAL[/url]", "[MAL](https://myanimelist.net/a_%28b%29)"},
+		{"[img]https://a/b.png[/img]", "![](https://a/b.png)"},
+		{"[url=javascript:alert(1)]click[/url]", "click"},
+		{`[url=/\evil.example]x[/url]`, "x"},
+		{"[img]data:image/png;base64,AAAA[/img]", ""},
+		{"before[quote=foo]line 1\nline 2[/quote]after", "before\n\n> **foo** said:\n> line 1\n> line 2\n\nafter"},
+		{"[list][*]one[*]two[/list]", "- one\n- two"},
+		{"[list=1][*]one\nmore[*]two[/list]", "1. one\n   more\n2. two"},
+		{"[spoiler]secret[/spoiler]", "**Spoiler:**\n\nsecret"},
+		{"before[spoiler=\"Ending *\"]secret[/spoiler]after", "before\n\n**Ending \\*:**\n\nsecret\n\nafter"},
+		{"[code]a*b[/code]", "```\na*b\n```"},
+		{"[color=red]plain[/color]", "plain"},
+	}
+	for _, tt := range tests {
+		if got := Markdown(Parse(tt.in), nil); got != tt.want {
+			t.Errorf("Markdown(%q)\nhave: %q\nwant: %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"[b]bold[/b] *stays*", "bold *stays*"},
+		{"[url=https://myanimelist.net]MAL[/url]", "MAL (https://myanimelist.net)"},
+		{"[url]https://myanimelist.net[/url]", "https://myanimelist.net"},
+		{"[url=javascript:alert(1)]click[/url]", "click"},
+		{"[url]javascript:alert(1)[/url]", "javascript:alert(1)"},
+		{"[img]//evil.example/a.png[/img]", ""},
+		{"[quote=foo]hi[/quote]", "> foo said:\n> hi"},
+		{"[list][*]one[*]two[/list]", "- one\n- two"},
+		{"[spoiler]secret[/spoiler]", "secret"},
+	}
+	for _, tt := range tests {
+		if got := Text(Parse(tt.in), nil); got != tt.want {
+			t.Errorf("Text(%q)\nhave: %q\nwant: %q", tt.in, got, tt.want)
+		}
+	}
+
+	opts := &Options{Spoiler: func(title, content string) string { return "[spoiler hidden]" }}
+	if got, want := Text(Parse("see [spoiler]secret[/spoiler]"), opts), "see [spoiler hidden]"; got != want {
+		t.Errorf("Text with Spoiler option = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,251 @@
+package mal
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/nstratos/go-myanimelist/mal/internal/pool"
+)
+
+// CompareOptions control how the lists of two users are compared by
+// UserService.Compare.
+type CompareOptions struct {
+	// AnimeOnly and MangaOnly restrict the comparison to the anime or the
+	// manga lists. By default both are compared. Setting both is an error.
+	AnimeOnly bool
+	MangaOnly bool
+	// LovedScore is the lowest score of the titles that a user loved. It
+	// defaults to 9.
+	LovedScore int
+	// Concurrency is the maximum number of lists that are paged at the same
+	// time. It defaults to 2, which pages the lists of the two users
+	// concurrently. Each list is paged one request at a time, so at most
+	// Concurrency requests are in flight. The requests are not otherwise
+	// rate limited.
+	Concurrency int
+}
+
+const (
+	defaultLovedScore         = 9
+	defaultCompareConcurrency = 2
+)
+
+// Comparison compares the lists of two users, A and B.
+type Comparison struct {
+	UserA string `json:"user_a"`
+	UserB string `json:"user_b"`
+	// Anime and Manga are nil if their lists were not compared.
+	Anime *ListComparison `json:"anime,omitempty"`
+	Manga *ListComparison `json:"manga,omitempty"`
+}
+
+// ListComparison compares an anime or manga list of two users.
+type ListComparison struct {
+	NumItemsA int `json:"num_items_a"`
+	NumItemsB int `json:"num_items_b"`
+	// Shared are the titles on both lists, sorted by ID.
+	Shared []ComparedEntry `json:"shared"`
+	// Overlap is the number of shared titles divided by the number of titles
+	// on either list, from 0 to 1.
+	Overlap float64 `json:"overlap"`
+	// Correlation is the Pearson correlation of the scores of the shared
+	// titles that both users scored, from -1 to 1. It is 0 when there are
+	// fewer than two such titles or the scores of a user do not vary.
+	Correlation float64 `json:"correlation"`
+	// NumScoredShared is the number of shared titles that both users
+	// scored.
+	NumScoredShared int `json:"num_scored_shared"`
+	// LovedByAOnly are the titles that A loved and B has not seen, that is
+	// they are not on the list of B or planned. LovedByBOnly are the same
+	// for B. They are sorted by score, highest first, and then by ID.
+	LovedByAOnly []ComparedEntry `json:"loved_by_a_only"`
+	LovedByBOnly []ComparedEntry `json:"loved_by_b_only"`
+	// StatusDisagreements are the shared titles that one user completed and
+	// the other dropped, sorted by ID.
+	StatusDisagreements []ComparedEntry `json:"status_disagreements"`
+}
+
+// ComparedEntry is a title in the lists of two users. The status of a user
+// that does not have the title is empty.
+type ComparedEntry struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	StatusA string `json:"status_a,omitempty"`
+	StatusB string `json:"status_b,omitempty"`
+	ScoreA  int    `json:"score_a,omitempty"`
+	ScoreB  int    `json:"score_b,omitempty"`
+}
+
+// listEntry is an anime or manga list entry reduced to what comparisons use.
+type listEntry struct {
+	id     int
+	title  string
+	status string
+	score  int
+}
+
+// Compare fetches the anime and manga lists of the users indicated by userA
+// and userB (or use @me) and compares them. A nil opts uses the defaults.
+// If fetching any of the lists fails, the remaining requests are canceled
+// and the first error is returned.
+func (s *UserService) Compare(ctx context.Context, userA, userB string, opts *CompareOptions) (*Comparison, error) {
+	if opts == nil {
+		opts = &CompareOptions{}
+	}
+	if opts.AnimeOnly && opts.MangaOnly {
+		return nil, errors.New("AnimeOnly and MangaOnly are both set")
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultCompareConcurrency
+	}
+	loved := opts.LovedScore
+	if loved <= 0 {
+		loved = defaultLovedScore
+	}
+	compareAnime := !opts.MangaOnly
+	compareManga := !opts.AnimeOnly
+
+	// lists holds the anime lists of A and B followed by their manga lists.
+	var lists [4][]listEntry
+	var fetches []func(ctx context.Context) error
+	for i, user := range []string{userA, userB} {
+		i, user := i, user
+		if compareAnime {
+			fetches = append(fetches, func(ctx context.Context) error {
+				return s.WalkAnimeList(ctx, user, func(page []UserAnime) error {
+					for _, a := range page {
+						lists[i] = append(lists[i], listEntry{a.Anime.ID, a.Anime.Title, string(a.Status.Status), a.Status.Score})
+					}
+					return nil
+				}, Fields{"list_status"}, Limit(1000))
+			})
+		}
+		if compareManga {
+			fetches = append(fetches, func(ctx context.Context) error {
+				return s.WalkMangaList(ctx, user, func(page []UserManga) error {
+					for _, m := range page {
+						lists[2+i] = append(lists[2+i], listEntry{m.Manga.ID, m.Manga.Title, string(m.Status.Status), m.Status.Score})
+					}
+					return nil
+				}, Fields{"list_status"}, Limit(1000))
+			})
+		}
+	}
+	err := pool.Run(ctx, concurrency, len(fetches), func(ctx context.Context, i int) error {
+		return fetches[i](ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Comparison{UserA: userA, UserB: userB}
+	if compareAnime {
+		c.Anime = compareLists(lists[0], lists[1], loved)
+	}
+	if compareManga {
+		c.Manga = compareLists(lists[2], lists[3], loved)
+	}
+	return c, nil
+}
+
+func compareLists(a, b []listEntry, loved int) *ListComparison {
+	lc := &ListComparison{NumItemsA: len(a), NumItemsB: len(b)}
+	byID := make(map[int]listEntry, len(b))
+	for _, e := range b {
+		byID[e.id] = e
+	}
+	inA := make(map[int]bool, len(a))
+	var xs, ys []float64
+	for _, ea := range a {
+		inA[ea.id] = true
+		eb, ok := byID[ea.id]
+		if !ok {
+			if ea.score >= loved {
+				lc.LovedByAOnly = append(lc.LovedByAOnly, ComparedEntry{ID: ea.id, Title: ea.title, StatusA: ea.status, ScoreA: ea.score})
+			}
+			continue
+		}
+		ce := ComparedEntry{ID: ea.id, Title: ea.title, StatusA: ea.status, StatusB: eb.status, ScoreA: ea.score, ScoreB: eb.score}
+		lc.Shared = append(lc.Shared, ce)
+		if ea.score > 0 && eb.score > 0 {
+			xs = append(xs, float64(ea.score))
+			ys = append(ys, float64(eb.score))
+		}
+		switch {
+		case ea.score >= loved && planned(eb.status):
+			lc.LovedByAOnly = append(lc.LovedByAOnly, ce)
+		case eb.score >= loved && planned(ea.status):
+			lc.LovedByBOnly = append(lc.LovedByBOnly, ce)
+		}
+		if ea.status == "completed" && eb.status == "dropped" || ea.status == "dropped" && eb.status == "completed" {
+			lc.StatusDisagreements = append(lc.StatusDisagreements, ce)
+		}
+	}
+	for _, eb := range b {
+		if !inA[eb.id] && eb.score >= loved {
+			lc.LovedByBOnly = append(lc.LovedByBOnly, ComparedEntry{ID: eb.id, Title: eb.title, StatusB: eb.status, ScoreB: eb.score})
+		}
+	}
+
+	if union := len(a) + len(b) - len(lc.Shared); union > 0 {
+		lc.Overlap = round2(float64(len(lc.Shared)) / float64(union))
+	}
+	lc.NumScoredShared = len(xs)
+	lc.Correlation = round2(pearson(xs, ys))
+
+	sortByID(lc.Shared)
+	sortByID(lc.StatusDisagreements)
+	sort.Slice(lc.LovedByAOnly, func(i, j int) bool {
+		x, y := lc.LovedByAOnly[i], lc.LovedByAOnly[j]
+		if x.ScoreA != y.ScoreA {
+			return x.ScoreA > y.ScoreA
+		}
+		return x.ID < y.ID
+	})
+	sort.Slice(lc.LovedByBOnly, func(i, j int) bool {
+		x, y := lc.LovedByBOnly[i], lc.LovedByBOnly[j]
+		if x.ScoreB != y.ScoreB {
+			return x.ScoreB > y.ScoreB
+		}
+		return x.ID < y.ID
+	})
+	return lc
+}
+
+func sortByID(entries []ComparedEntry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+}
+
+// planned reports whether a list status means that the title was not seen.
+func planned(status string) bool {
+	return status == string(AnimeStatusPlanToWatch) || status == string(MangaStatusPlanToRead)
+}
+
+// pearson returns the Pearson correlation coefficient of xs and ys, or 0 if
+// it is undefined.
+func pearson(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if len(xs) < 2 {
+		return 0
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx, my = mx/n, my/n
+	var cov, vx, vy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
@@ -0,0 +1,142 @@
+package mal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestUserServiceCompare(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	lists := map[string]string{
+		"/users/foo/animelist": `{"data": [
+			{"node": {"id": 1, "title": "Cowboy Bebop"}, "list_status": {"status": "completed", "score": 10}},
+			{"node": {"id": 6, "title": "Trigun"}, "list_status": {"status": "completed", "score": 8}},
+			{"node": {"id": 20, "title": "Naruto"}, "list_status": {"status": "dropped", "score": 4}},
+			{"node": {"id": 30, "title": "Planned"}, "list_status": {"status": "plan_to_watch"}},
+			{"node": {"id": 40, "title": "Samurai Champloo"}, "list_status": {"status": "completed", "score": 9}}
+		]}`,
+		"/users/bar/animelist": `{"data": [
+			{"node": {"id": 1, "title": "Cowboy Bebop"}, "list_status": {"status": "completed", "score": 9}},
+			{"node": {"id": 6, "title": "Trigun"}, "list_status": {"status": "watching", "score": 7}},
+			{"node": {"id": 20, "title": "Naruto"}, "list_status": {"status": "completed", "score": 6}},
+			{"node": {"id": 30, "title": "Planned"}, "list_status": {"status": "completed", "score": 10}},
+			{"node": {"id": 40, "title": "Samurai Champloo"}, "list_status": {"status": "plan_to_watch"}},
+			{"node": {"id": 50, "title": "Monster"}, "list_status": {"status": "completed", "score": 9}}
+		]}`,
+		"/users/foo/mangalist": `{"data": [{"node": {"id": 2, "title": "Berserk"}, "list_status": {"status": "reading", "score": 10}}]}`,
+		"/users/bar/mangalist": `{"data": []}`,
+	}
+	var mu sync.Mutex
+	requested := make(map[string]bool)
+	for path, body := range lists {
+		path, body := path, body
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, http.MethodGet)
+			testURLValues(t, r, urlValues{"fields": "list_status", "limit": "1000", "offset": "0"})
+			mu.Lock()
+			requested[path] = true
+			mu.Unlock()
+			fmt.Fprint(w, body)
+		})
+	}
+
+	ctx := context.Background()
+	got, err := client.User.Compare(ctx, "foo", "bar", nil)
+	if err != nil {
+		t.Fatalf("User.Compare returned error: %v", err)
+	}
+	if len(requested) != 4 {
+		t.Errorf("User.Compare requested %v, want the anime and manga lists of both users", requested)
+	}
+	bebop := ComparedEntry{ID: 1, Title: "Cowboy Bebop", StatusA: "completed", StatusB: "completed", ScoreA: 10, ScoreB: 9}
+	trigun := ComparedEntry{ID: 6, Title: "Trigun", StatusA: "completed", StatusB: "watching", ScoreA: 8, ScoreB: 7}
+	naruto := ComparedEntry{ID: 20, Title: "Naruto", StatusA: "dropped", StatusB: "completed", ScoreA: 4, ScoreB: 6}
+	planned := ComparedEntry{ID: 30, Title: "Planned", StatusA: "plan_to_watch", StatusB: "completed", ScoreB: 10}
+	champloo := ComparedEntry{ID: 40, Title: "Samurai Champloo", StatusA: "completed", StatusB: "plan_to_watch", ScoreA: 9}
+	want := &Comparison{
+		UserA: "foo",
+		UserB: "bar",
+		Anime: &ListComparison{
+			NumItemsA:           5,
+			NumItemsB:           6,
+			Shared:              []ComparedEntry{bebop, trigun, naruto, planned, champloo},
+			Overlap:             0.83,
+			Correlation:         0.93,
+			NumScoredShared:     3,
+			LovedByAOnly:        []ComparedEntry{champloo},
+			LovedByBOnly:        []ComparedEntry{planned, {ID: 50, Title: "Monster", StatusB: "completed", ScoreB: 9}},
+			StatusDisagreements: []ComparedEntry{naruto},
+		},
+		Manga: &ListComparison{
+			NumItemsA:    1,
+			LovedByAOnly: []ComparedEntry{{ID: 2, Title: "Berserk", StatusA: "reading", ScoreA: 10}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("User.Compare\nhave anime: %+v\nwant anime: %+v\nhave manga: %+v\nwant manga: %+v", got.Anime, want.Anime, got.Manga, want.Manga)
+	}
+
+	got, err = client.User.Compare(ctx, "foo", "bar", &CompareOptions{MangaOnly: true, LovedScore: 11, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("User.Compare manga only returned error: %v", err)
+	}
+	if got.Anime != nil || got.Manga == nil || len(got.Manga.LovedByAOnly) != 0 {
+		t.Errorf("User.Compare manga only = %+v, want only a manga comparison without loved titles", got)
+	}
+}
+
+func TestUserServiceCompareError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/animelist", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": []}`)
+	})
+	mux.HandleFunc("/users/bar/animelist", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"user is private","error":"forbidden"}`, http.StatusForbidden)
+	})
+
+	_, err := client.User.Compare(context.Background(), "foo", "bar", &CompareOptions{AnimeOnly: true})
+	if err == nil {
+		t.Fatal("User.Compare expected forbidden error, got no error.")
+	}
+	testErrorResponse(t, err, ErrorResponse{Message: "user is private", Err: "forbidden"})
+}
+
+func TestUserServiceCompareAnimeAndMangaOnly(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("User.Compare with AnimeOnly and MangaOnly sent a request to %s", r.URL.Path)
+	})
+
+	_, err := client.User.Compare(context.Background(), "foo", "bar", &CompareOptions{AnimeOnly: true, MangaOnly: true})
+	if err == nil {
+		t.Error("User.Compare with AnimeOnly and MangaOnly returned no error")
+	}
+}
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		xs, ys []float64
+		want   float64
+	}{
+		{[]float64{1, 2, 3}, []float64{2, 4, 6}, 1},
+		{[]float64{1, 2, 3}, []float64{3, 2, 1}, -1},
+		{[]float64{1, 2, 3, 4}, []float64{2, 1, 4, 3}, 0.6},
+		{[]float64{5}, []float64{5}, 0},
+		{[]float64{7, 7, 7}, []float64{1, 2, 3}, 0},
+	}
+	for _, tt := range tests {
+		if got := pearson(tt.xs, tt.ys); round2(got) != tt.want {
+			t.Errorf("pearson(%v, %v) = %v, want %v", tt.xs, tt.ys, got, tt.want)
+		}
+	}
+}
@@ -294,6 +294,22 @@ Official docs:
 
 - https://myanimelist.net/apiconfig/references/api/v2#operation/manga_manga_id_my_list_status_put
 
+# Progress
+
+To record watching an episode or reading a chapter without working out the
+status changes yourself:
+
+	s, _, err := c.Anime.WatchNextEpisode(ctx, 967)
+	// ...
+
+	s, _, err := c.Manga.SetChaptersRead(ctx, 401, 5)
+	// ...
+
+These methods move plan to watch (or read) entries to watching (or reading)
+with a start date, complete the entry with a finish date when the last episode
+or chapter is reached and keep count of rewatches and rereads. Progress beyond
+the known total is refused with ErrProgressBeyondTotal.
+
 # Delete
 
 To delete anime or manga from a user's list, simply provide their IDs:
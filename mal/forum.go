@@ -199,3 +199,80 @@ func optionFromTopicsOption(o TopicsOption) optionFunc {
 		o.topicsApply(v)
 	})
 }
+
+// WalkTopicOption are options specific to the ForumService.WalkTopic method.
+type WalkTopicOption interface {
+	walkTopicApply(w *topicWalk)
+}
+
+type topicWalk struct {
+	start int
+	limit int
+}
+
+// StartAtPost is an option that starts walking a topic from the post with the
+// given number. Posts are numbered from 1.
+type StartAtPost int
+
+func (n StartAtPost) walkTopicApply(w *topicWalk) { w.start = int(n) }
+func (l Limit) walkTopicApply(w *topicWalk)       { w.limit = int(l) }
+
+const defaultWalkTopicLimit = 100
+
+// WalkTopic walks all the pages of the forum topic specified by topicID and
+// calls fn with each post in order, exactly once. Walking stops at the first
+// error returned by the API or by fn. Use the StartAtPost option to skip the
+// posts before a certain post number and the Limit option to control how many
+// posts are requested with each page.
+//
+// Each page after the first is requested starting from the last post already
+// seen, so posts that are added or removed while walking do not cause gaps and
+// posts that appear again are skipped.
+//
+// The title and poll of the topic, which the API repeats on every page, are
+// returned once in TopicDetails which has no posts.
+func (s *ForumService) WalkTopic(ctx context.Context, topicID int, fn func(p Post) error, options ...WalkTopicOption) (TopicDetails, error) {
+	w := &topicWalk{start: 1, limit: defaultWalkTopicLimit}
+	for _, o := range options {
+		o.walkTopicApply(w)
+	}
+	if w.start < 1 {
+		w.start = 1
+	}
+
+	var info TopicDetails
+	seen := make(map[int]bool)
+	offset := w.start - 1
+	for first := true; ; first = false {
+		d, resp, err := s.TopicDetails(ctx, topicID, Limit(w.limit), Offset(offset))
+		if err != nil {
+			return info, err
+		}
+		if first {
+			info.Title, info.Poll = d.Title, d.Poll
+		}
+		last := 0
+		for _, p := range d.Posts {
+			if p.Number > last {
+				last = p.Number
+			}
+			if seen[p.ID] || (p.Number != 0 && p.Number < w.start) {
+				continue
+			}
+			seen[p.ID] = true
+			if err := fn(p); err != nil {
+				return info, err
+			}
+		}
+		if resp.NextOffset == 0 || len(d.Posts) == 0 {
+			return info, nil
+		}
+		// Overlap with the last post seen so that a removed post cannot
+		// shift an unseen post to the previous page.
+		next := last - 1
+		if next <= offset {
+			next = offset + len(d.Posts)
+		}
+		offset = next
+	}
+}
@@ -2,9 +2,12 @@ package mal
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -202,3 +205,149 @@ func TestForumServiceTopicsError(t *testing.T) {
 	testResponseStatusCode(t, resp, http.StatusInternalServerError, "Forum.Topics")
 	testErrorResponse(t, err, ErrorResponse{Message: "mal is down", Err: "internal"})
 }
+
+// topicServer serves the posts of a topic in pages like the API does. The
+// onPage hook is called after each page is served.
+func topicServer(t *testing.T, mux *http.ServeMux, posts *[]Post, onPage func(offset int)) {
+	mux.HandleFunc("/forum/topic/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		all := *posts
+		end := offset + limit
+		if end > len(all) {
+			end = len(all)
+		}
+		d := topicDetail{Data: TopicDetails{
+			Title: "Poll topic",
+			Poll:  &Poll{ID: 1, Question: "Best?"},
+			Posts: all[offset:end],
+		}}
+		if end < len(all) {
+			d.Paging.Next = fmt.Sprintf("?offset=%d", end)
+		}
+		if err := json.NewEncoder(w).Encode(d); err != nil {
+			t.Fatal(err)
+		}
+		if onPage != nil {
+			onPage(offset)
+		}
+	})
+}
+
+func numberedPosts(n int) []Post {
+	posts := make([]Post, n)
+	for i := range posts {
+		posts[i] = Post{ID: 100 + i + 1, Number: i + 1}
+	}
+	return posts
+}
+
+func TestForumServiceWalkTopic(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	posts := numberedPosts(5)
+	pages := 0
+	topicServer(t, mux, &posts, func(offset int) {
+		pages++
+		if pages == 1 {
+			// A reply is added while walking.
+			posts = append(posts, Post{ID: 106, Number: 6})
+		}
+	})
+
+	var got []int
+	ctx := context.Background()
+	info, err := client.Forum.WalkTopic(ctx, 1, func(p Post) error {
+		got = append(got, p.Number)
+		return nil
+	}, Limit(2))
+	if err != nil {
+		t.Fatalf("Forum.WalkTopic returned error: %v", err)
+	}
+	if want := []int{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Forum.WalkTopic walked posts %v, want %v", got, want)
+	}
+	want := TopicDetails{Title: "Poll topic", Poll: &Poll{ID: 1, Question: "Best?"}}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("Forum.WalkTopic returned\nhave: %+v\nwant: %+v", info, want)
+	}
+}
+
+func TestForumServiceWalkTopicRemovedPost(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	posts := numberedPosts(6)
+	topicServer(t, mux, &posts, func(offset int) {
+		if offset == 0 {
+			// The first post is removed and the rest are renumbered.
+			posts = numberedPosts(6)[1:]
+			for i := range posts {
+				posts[i].Number = i + 1
+			}
+		}
+	})
+
+	var got []int
+	ctx := context.Background()
+	_, err := client.Forum.WalkTopic(ctx, 1, func(p Post) error {
+		got = append(got, p.ID)
+		return nil
+	}, Limit(3))
+	if err != nil {
+		t.Fatalf("Forum.WalkTopic returned error: %v", err)
+	}
+	if want := []int{101, 102, 103, 104, 105, 106}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Forum.WalkTopic walked post IDs %v, want %v", got, want)
+	}
+}
+
+func TestForumServiceWalkTopicStartAtPost(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	posts := numberedPosts(7)
+	topicServer(t, mux, &posts, nil)
+
+	var got []int
+	ctx := context.Background()
+	_, err := client.Forum.WalkTopic(ctx, 1, func(p Post) error {
+		got = append(got, p.Number)
+		return nil
+	}, StartAtPost(4), Limit(2))
+	if err != nil {
+		t.Fatalf("Forum.WalkTopic returned error: %v", err)
+	}
+	if want := []int{4, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Forum.WalkTopic walked posts %v, want %v", got, want)
+	}
+}
+
+func TestForumServiceWalkTopicError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	posts := numberedPosts(3)
+	topicServer(t, mux, &posts, nil)
+
+	stop := errors.New("stop")
+	calls := 0
+	ctx := context.Background()
+	_, err := client.Forum.WalkTopic(ctx, 1, func(p Post) error {
+		calls++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("Forum.WalkTopic returned err = %v, want %v", err, stop)
+	}
+	if calls != 1 {
+		t.Errorf("Forum.WalkTopic called fn %d times after error, want 1", calls)
+	}
+
+	_, err = client.Forum.WalkTopic(ctx, 2, func(p Post) error { return nil })
+	if err == nil {
+		t.Error("Forum.WalkTopic for missing topic expected error, got no error.")
+	}
+}
@@ -0,0 +1,179 @@
+package mal
+
+import (
+	"strings"
+)
+
+// BoardFilter returns the BoardID option that filters topics of the board.
+func (b ForumBoard) BoardFilter() BoardID { return BoardID(b.ID) }
+
+// SubboardFilter returns the SubboardID option that filters topics of the
+// subboard.
+func (s ForumSubboard) SubboardFilter() SubboardID { return SubboardID(s.ID) }
+
+// BoardPath is the location of a board or a subboard in the forum.
+type BoardPath struct {
+	Category string
+	Board    ForumBoard
+	// Subboard is nil for the path of a board.
+	Subboard *ForumSubboard
+}
+
+// String returns the titles of the path separated by " / ", for example
+// "Anime / Anime Discussion".
+func (p BoardPath) String() string {
+	s := p.Category + " / " + p.Board.Title
+	if p.Subboard != nil {
+		s += " / " + p.Subboard.Title
+	}
+	return s
+}
+
+// TopicsFilter returns the option that filters topics of the board or the
+// subboard of the path.
+func (p BoardPath) TopicsFilter() TopicsOption {
+	if p.Subboard != nil {
+		return p.Subboard.SubboardFilter()
+	}
+	return p.Board.BoardFilter()
+}
+
+// Paths returns the path of every board and subboard of the forum. Each
+// board is followed by its subboards.
+func (f *Forum) Paths() []BoardPath {
+	var paths []BoardPath
+	for _, c := range f.Categories {
+		for _, b := range c.Boards {
+			paths = append(paths, BoardPath{Category: c.Title, Board: b})
+			for i := range b.Subboards {
+				paths = append(paths, BoardPath{Category: c.Title, Board: b, Subboard: &b.Subboards[i]})
+			}
+		}
+	}
+	return paths
+}
+
+// BoardIndex finds the boards and subboards of a forum by ID, title and
+// path. It is built once by Forum.Index so that lookups do not walk the
+// forum.
+type BoardIndex struct {
+	paths []BoardPath
+	// The maps hold indexes of paths.
+	boards         map[int]int
+	subboards      map[int]int
+	boardTitles    map[string]int
+	subboardTitles map[string]int
+	pathTitles     map[string]int
+}
+
+// Index returns an index of the boards and subboards of the forum. It should
+// be built again if the forum changes.
+func (f *Forum) Index() *BoardIndex {
+	idx := &BoardIndex{
+		paths:          f.Paths(),
+		boards:         make(map[int]int),
+		subboards:      make(map[int]int),
+		boardTitles:    make(map[string]int),
+		subboardTitles: make(map[string]int),
+		pathTitles:     make(map[string]int),
+	}
+	// The first board or subboard with an ID or title wins.
+	add := func(m map[string]int, title string, i int) {
+		k := titleKey(title)
+		if _, ok := m[k]; !ok {
+			m[k] = i
+		}
+	}
+	for i, p := range idx.paths {
+		add(idx.pathTitles, p.String(), i)
+		if p.Subboard == nil {
+			if _, ok := idx.boards[p.Board.ID]; !ok {
+				idx.boards[p.Board.ID] = i
+			}
+			add(idx.boardTitles, p.Board.Title, i)
+			continue
+		}
+		if _, ok := idx.subboards[p.Subboard.ID]; !ok {
+			idx.subboards[p.Subboard.ID] = i
+		}
+		add(idx.subboardTitles, p.Subboard.Title, i)
+	}
+	return idx
+}
+
+// Paths returns the path of every board and subboard of the index in the
+// order of Forum.Paths.
+func (idx *BoardIndex) Paths() []BoardPath {
+	return append([]BoardPath(nil), idx.paths...)
+}
+
+func (idx *BoardIndex) path(m map[int]int, id int) (BoardPath, bool) {
+	i, ok := m[id]
+	if !ok {
+		return BoardPath{}, false
+	}
+	return idx.paths[i], true
+}
+
+func (idx *BoardIndex) titled(m map[string]int, title string) (BoardPath, bool) {
+	i, ok := m[titleKey(title)]
+	if !ok {
+		return BoardPath{}, false
+	}
+	return idx.paths[i], true
+}
+
+// Board returns the board with the given ID.
+func (idx *BoardIndex) Board(id int) (ForumBoard, bool) {
+	p, ok := idx.path(idx.boards, id)
+	return p.Board, ok
+}
+
+// BoardByTitle returns the board with the given title. Titles are compared
+// case insensitively.
+func (idx *BoardIndex) BoardByTitle(title string) (ForumBoard, bool) {
+	p, ok := idx.titled(idx.boardTitles, title)
+	return p.Board, ok
+}
+
+// Subboard returns the subboard with the given ID.
+func (idx *BoardIndex) Subboard(id int) (ForumSubboard, bool) {
+	if p, ok := idx.path(idx.subboards, id); ok {
+		return *p.Subboard, true
+	}
+	return ForumSubboard{}, false
+}
+
+// SubboardByTitle returns the subboard with the given title. Titles are
+// compared case insensitively.
+func (idx *BoardIndex) SubboardByTitle(title string) (ForumSubboard, bool) {
+	if p, ok := idx.titled(idx.subboardTitles, title); ok {
+		return *p.Subboard, true
+	}
+	return ForumSubboard{}, false
+}
+
+// SubboardParent returns the board that contains the subboard with the given
+// ID.
+func (idx *BoardIndex) SubboardParent(id int) (ForumBoard, bool) {
+	p, ok := idx.path(idx.subboards, id)
+	return p.Board, ok
+}
+
+// Lookup returns the path of the board or subboard with the given title or
+// path, as returned by BoardPath.String. Titles are compared case
+// insensitively and boards are preferred over subboards with the same title.
+func (idx *BoardIndex) Lookup(title string) (BoardPath, bool) {
+	for _, m := range []map[string]int{idx.pathTitles, idx.boardTitles, idx.subboardTitles} {
+		if p, ok := idx.titled(m, title); ok {
+			return p, true
+		}
+	}
+	return BoardPath{}, false
+}
+
+// titleKey returns the key of a title that titles which are equal case
+// insensitively share.
+func titleKey(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
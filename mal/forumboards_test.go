@@ -0,0 +1,93 @@
+package mal
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testForum = &Forum{
+	Categories: []ForumCategory{
+		{
+			Title: "MyAnimeList",
+			Boards: []ForumBoard{
+				{ID: 17, Title: "MAL Guidelines", Subboards: []ForumSubboard{{ID: 2, Title: "Anime DB"}, {ID: 3, Title: "Manga DB"}}},
+				{ID: 5, Title: "Updates & Announcements"},
+			},
+		},
+		{
+			Title: "Anime",
+			Boards: []ForumBoard{
+				{ID: 1, Title: "Anime Discussion", Subboards: []ForumSubboard{{ID: 9, Title: "Anime DB"}}},
+			},
+		},
+	},
+}
+
+func TestForumPaths(t *testing.T) {
+	var got []string
+	for _, p := range testForum.Paths() {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"MyAnimeList / MAL Guidelines",
+		"MyAnimeList / MAL Guidelines / Anime DB",
+		"MyAnimeList / MAL Guidelines / Manga DB",
+		"MyAnimeList / Updates & Announcements",
+		"Anime / Anime Discussion",
+		"Anime / Anime Discussion / Anime DB",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Forum.Paths returned\nhave: %q\nwant: %q", got, want)
+	}
+}
+
+func TestBoardIndex(t *testing.T) {
+	idx := testForum.Index()
+	if b, ok := idx.Board(5); !ok || b.Title != "Updates & Announcements" {
+		t.Errorf("BoardIndex.Board(5) = %+v, %v", b, ok)
+	}
+	if _, ok := idx.Board(2); ok {
+		t.Error("BoardIndex.Board(2) found the subboard with ID 2")
+	}
+	if b, ok := idx.BoardByTitle(" anime discussion "); !ok || b.ID != 1 {
+		t.Errorf("BoardIndex.BoardByTitle = %+v, %v", b, ok)
+	}
+	if s, ok := idx.Subboard(3); !ok || s.Title != "Manga DB" {
+		t.Errorf("BoardIndex.Subboard(3) = %+v, %v", s, ok)
+	}
+	if s, ok := idx.SubboardByTitle("MANGA db"); !ok || s.ID != 3 {
+		t.Errorf("BoardIndex.SubboardByTitle = %+v, %v", s, ok)
+	}
+	if b, ok := idx.SubboardParent(9); !ok || b.ID != 1 {
+		t.Errorf("BoardIndex.SubboardParent(9) = %+v, %v", b, ok)
+	}
+	if _, ok := idx.SubboardParent(17); ok {
+		t.Error("BoardIndex.SubboardParent(17) found the board with ID 17")
+	}
+}
+
+func TestBoardIndexLookup(t *testing.T) {
+	idx := testForum.Index()
+	tests := []struct {
+		title string
+		want  TopicsOption
+	}{
+		{"updates & announcements", BoardID(5)},
+		{"Anime DB", SubboardID(2)},
+		{"anime / anime discussion / anime db", SubboardID(9)},
+		{"Anime / Anime Discussion", BoardID(1)},
+	}
+	for _, tt := range tests {
+		p, ok := idx.Lookup(tt.title)
+		if !ok {
+			t.Errorf("BoardIndex.Lookup(%q) found nothing", tt.title)
+			continue
+		}
+		if got := p.TopicsFilter(); got != tt.want {
+			t.Errorf("BoardIndex.Lookup(%q).TopicsFilter() = %#v, want %#v", tt.title, got, tt.want)
+		}
+	}
+	if p, ok := idx.Lookup("Off-Topic"); ok {
+		t.Errorf("BoardIndex.Lookup(%q) = %v, want nothing", "Off-Topic", p)
+	}
+}
@@ -0,0 +1,326 @@
+// Package forumfeed converts MyAnimeList forum topics and posts into Atom and
+// RSS 2.0 feeds.
+//
+// Feeds are built from the results of the forum methods of mal.ForumService:
+//
+//	topics, _, err := c.Forum.Topics(ctx, mal.Query("spring"))
+//	// ...
+//	err = forumfeed.FromTopics("Spring threads", topics).WriteAtom(w)
+//
+// Handler serves the feed of a forum search over HTTP.
+package forumfeed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/bbcode"
+)
+
+const siteURL = "https://myanimelist.net"
+
+// TopicURL returns the URL of the forum topic specified by topicID.
+func TopicURL(topicID int) string {
+	return siteURL + "/forum/?topicid=" + strconv.Itoa(topicID)
+}
+
+// PostURL returns the URL of the forum post specified by postID.
+func PostURL(postID int) string {
+	return siteURL + "/forum/message/" + strconv.Itoa(postID) + "?goto=topic"
+}
+
+// ProfileURL returns the URL of the profile of the user with the given name.
+func ProfileURL(name string) string {
+	return siteURL + "/profile/" + url.PathEscape(name)
+}
+
+// Feed is a feed that can be written as Atom or RSS 2.0.
+type Feed struct {
+	Title string
+	// ID identifies the feed. It must be a URI that never changes. It
+	// defaults to Link.
+	ID string
+	// Link is the URL of the web page of the feed.
+	Link string
+	// Self, if set, is the URL the feed is served from.
+	Self string
+	// Updated is the last time the feed changed. It defaults to the most
+	// recent update of its entries.
+	Updated time.Time
+	Entries []Entry
+}
+
+// Entry is an entry of a Feed.
+type Entry struct {
+	// ID identifies the entry. It must be a URI that never changes. It
+	// defaults to Link.
+	ID        string
+	Title     string
+	Link      string
+	Published time.Time
+	Updated   time.Time
+	Author    string
+	AuthorURI string
+	// Content is the HTML content of the entry.
+	Content string
+}
+
+// FromTopics returns a feed with an entry for each topic. Topics are updated
+// when their last post is created so readers that track updates show topics
+// with new posts again.
+func FromTopics(title string, topics []mal.Topic) *Feed {
+	f := &Feed{Title: title, Link: siteURL + "/forum/"}
+	for _, t := range topics {
+		updated := t.LastPostCreatedAt
+		if updated.IsZero() {
+			updated = t.CreatedAt
+		}
+		f.Entries = append(f.Entries, Entry{
+			Title:     t.Title,
+			Link:      TopicURL(t.ID),
+			Published: t.CreatedAt,
+			Updated:   updated,
+			Author:    t.CreatedBy.Name,
+			AuthorURI: profileURL(t.CreatedBy.Name),
+			Content:   topicContent(t),
+		})
+	}
+	return f
+}
+
+func profileURL(name string) string {
+	if name == "" {
+		return ""
+	}
+	return ProfileURL(name)
+}
+
+func topicContent(t mal.Topic) string {
+	s := fmt.Sprintf("<p>%d posts", t.NumberOfPosts)
+	if t.NumberOfPosts == 1 {
+		s = "<p>1 post"
+	}
+	if name := t.LastPostCreatedBy.Name; name != "" {
+		s += `, last by <a href="` + html.EscapeString(ProfileURL(name)) + `">` + html.EscapeString(name) + "</a>"
+	}
+	if t.IsLocked {
+		s += " (locked)"
+	}
+	return s + ".</p>"
+}
+
+// FromTopicDetails returns a feed with an entry for each post of the topic
+// specified by topicID, newest first. The HTML content of the entries is
+// rendered from the BBCode of the post bodies using opts.
+func FromTopicDetails(topicID int, d mal.TopicDetails, opts *bbcode.Options) *Feed {
+	f := &Feed{Title: d.Title, Link: TopicURL(topicID)}
+	for i := len(d.Posts) - 1; i >= 0; i-- {
+		p := d.Posts[i]
+		f.Entries = append(f.Entries, Entry{
+			Title:     fmt.Sprintf("%s #%d", d.Title, p.Number),
+			Link:      PostURL(p.ID),
+			Published: p.CreatedAt,
+			Updated:   p.CreatedAt,
+			Author:    p.CreatedBy.Name,
+			AuthorURI: profileURL(p.CreatedBy.Name),
+			Content:   bbcode.HTML(bbcode.Parse(p.Body), opts),
+		})
+	}
+	return f
+}
+
+// timeNow is used for the update time of feeds without entries.
+var timeNow = time.Now
+
+// updated returns the time the feed was last updated.
+func (f *Feed) updated() time.Time {
+	u := f.Updated
+	if u.IsZero() {
+		for _, e := range f.Entries {
+			if e.updated().After(u) {
+				u = e.updated()
+			}
+		}
+	}
+	if u.IsZero() {
+		u = timeNow()
+	}
+	return u.UTC()
+}
+
+func (e Entry) updated() time.Time {
+	if e.Updated.IsZero() {
+		return e.Published
+	}
+	return e.Updated
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published,omitempty"`
+	Updated   string      `xml:"updated"`
+	Author    *atomPerson `xml:"author"`
+	Content   *atomText   `xml:"content"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// WriteAtom writes the feed to w as an Atom document.
+func (f *Feed) WriteAtom(w io.Writer) error {
+	a := atomFeed{
+		Title:   f.Title,
+		ID:      orDefault(f.ID, f.Link),
+		Updated: atomTime(f.updated()),
+		Links:   []atomLink{{Href: f.Link, Rel: "alternate", Type: "text/html"}},
+	}
+	if f.Self != "" {
+		a.Links = append(a.Links, atomLink{Href: f.Self, Rel: "self", Type: "application/atom+xml"})
+	}
+	for _, e := range f.Entries {
+		ae := atomEntry{
+			Title:     e.Title,
+			ID:        orDefault(e.ID, e.Link),
+			Link:      atomLink{Href: e.Link, Rel: "alternate", Type: "text/html"},
+			Published: atomTime(e.Published),
+			Updated:   atomTime(e.updated()),
+		}
+		if e.Author != "" {
+			ae.Author = &atomPerson{Name: e.Author, URI: e.AuthorURI}
+		}
+		if e.Content != "" {
+			ae.Content = &atomText{Type: "html", Body: e.Content}
+		}
+		a.Entries = append(a.Entries, ae)
+	}
+	return writeXML(w, a)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Self          *atomLink `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Creator     string  `xml:"dc:creator,omitempty"`
+	Description string  `xml:"description,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func rssTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+// WriteRSS writes the feed to w as an RSS 2.0 document.
+func (f *Feed) WriteRSS(w io.Writer) error {
+	r := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Title,
+			LastBuildDate: rssTime(f.updated()),
+		},
+	}
+	if f.Self != "" {
+		r.Channel.Self = &atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"}
+	}
+	for _, e := range f.Entries {
+		id := orDefault(e.ID, e.Link)
+		pub := e.Published
+		if pub.IsZero() {
+			pub = e.Updated
+		}
+		r.Channel.Items = append(r.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        rssGUID{IsPermaLink: id == e.Link, Value: id},
+			PubDate:     rssTime(pub),
+			Creator:     e.Author,
+			Description: e.Content,
+		})
+	}
+	return writeXML(w, r)
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
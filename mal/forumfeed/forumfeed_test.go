@@ -0,0 +1,133 @@
+package forumfeed
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+var (
+	created  = time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	lastPost = time.Date(2021, 3, 2, 12, 30, 0, 0, time.FixedZone("JST", 9*60*60))
+)
+
+func testTopics() []mal.Topic {
+	return []mal.Topic{{
+		ID:                481,
+		Title:             "Spring <2021> & beyond",
+		CreatedAt:         created,
+		CreatedBy:         mal.CreatedBy{Name: "foo"},
+		NumberOfPosts:     2,
+		LastPostCreatedAt: lastPost,
+		LastPostCreatedBy: mal.CreatedBy{Name: "bar baz"},
+	}}
+}
+
+func TestWriteAtom(t *testing.T) {
+	f := FromTopics("Spring", testTopics())
+	f.Self = "https://example.com/feed"
+	var buf bytes.Buffer
+	if err := f.WriteAtom(&buf); err != nil {
+		t.Fatalf("WriteAtom returned error: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Spring</title>
+  <id>https://myanimelist.net/forum/</id>
+  <updated>2021-03-02T03:30:00Z</updated>
+  <link href="https://myanimelist.net/forum/" rel="alternate" type="text/html"></link>
+  <link href="https://example.com/feed" rel="self" type="application/atom+xml"></link>
+  <entry>
+    <title>Spring &lt;2021&gt; &amp; beyond</title>
+    <id>https://myanimelist.net/forum/?topicid=481</id>
+    <link href="https://myanimelist.net/forum/?topicid=481" rel="alternate" type="text/html"></link>
+    <published>2021-03-01T10:00:00Z</published>
+    <updated>2021-03-02T03:30:00Z</updated>
+    <author>
+      <name>foo</name>
+      <uri>https://myanimelist.net/profile/foo</uri>
+    </author>
+    <content type="html">&lt;p&gt;2 posts, last by &lt;a href=&#34;https://myanimelist.net/profile/bar%20baz&#34;&gt;bar baz&lt;/a&gt;.&lt;/p&gt;</content>
+  </entry>
+</feed>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteAtom\nhave: %s\nwant: %s", got, want)
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	f := FromTopics("Spring", testTopics())
+	var buf bytes.Buffer
+	if err := f.WriteRSS(&buf); err != nil {
+		t.Fatalf("WriteRSS returned error: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Spring</title>
+    <link>https://myanimelist.net/forum/</link>
+    <description>Spring</description>
+    <lastBuildDate>Tue, 02 Mar 2021 03:30:00 +0000</lastBuildDate>
+    <item>
+      <title>Spring &lt;2021&gt; &amp; beyond</title>
+      <link>https://myanimelist.net/forum/?topicid=481</link>
+      <guid isPermaLink="true">https://myanimelist.net/forum/?topicid=481</guid>
+      <pubDate>Mon, 01 Mar 2021 10:00:00 +0000</pubDate>
+      <dc:creator>foo</dc:creator>
+      <description>&lt;p&gt;2 posts, last by &lt;a href=&#34;https://myanimelist.net/profile/bar%20baz&#34;&gt;bar baz&lt;/a&gt;.&lt;/p&gt;</description>
+    </item>
+  </channel>
+</rss>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteRSS\nhave: %s\nwant: %s", got, want)
+	}
+}
+
+func TestFromTopicDetails(t *testing.T) {
+	d := mal.TopicDetails{
+		Title: "Thread",
+		Posts: []mal.Post{
+			{ID: 10, Number: 1, CreatedAt: created, CreatedBy: mal.CreatedBy{Name: "foo"}, Body: "[b]first[/b]"},
+			{ID: 11, Number: 2, CreatedAt: lastPost, CreatedBy: mal.CreatedBy{Name: "bar"}, Body: "[url=javascript:x]second[/url]<script>"},
+		},
+	}
+	f := FromTopicDetails(481, d, nil)
+	if got, want := f.Link, "https://myanimelist.net/forum/?topicid=481"; got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+	if len(f.Entries) != 2 {
+		t.Fatalf("FromTopicDetails returned %d entries, want 2", len(f.Entries))
+	}
+	e := f.Entries[0]
+	if got, want := e.Title, "Thread #2"; got != want {
+		t.Errorf("newest entry Title = %q, want %q", got, want)
+	}
+	if got, want := e.Link, "https://myanimelist.net/forum/message/11?goto=topic"; got != want {
+		t.Errorf("newest entry Link = %q, want %q", got, want)
+	}
+	if got, want := e.Content, "second&lt;script&gt;"; got != want {
+		t.Errorf("newest entry Content = %q, want %q", got, want)
+	}
+	if got, want := f.Entries[1].Content, "<b>first</b>"; got != want {
+		t.Errorf("oldest entry Content = %q, want %q", got, want)
+	}
+}
+
+func TestEmptyFeedUpdated(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	var buf bytes.Buffer
+	if err := FromTopics("Empty", nil).WriteAtom(&buf); err != nil {
+		t.Fatalf("WriteAtom returned error: %v", err)
+	}
+	if want := "<updated>2022-01-01T00:00:00Z</updated>"; !strings.Contains(buf.String(), want) {
+		t.Errorf("WriteAtom of empty feed = %s, want it to contain %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,157 @@
+package forumfeed
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// maxLimit is the largest page size allowed when searching topics.
+const maxLimit = 100
+
+// Handler serves the feed of a forum search. The search is described by the
+// query parameters of the request:
+//
+//	q                search term
+//	board_id         board ID
+//	subboard_id      subboard ID
+//	user_name        name of a user that posted in the topics
+//	topic_user_name  name of the user that created the topics
+//	limit            number of topics, at most 100
+//	format           "atom" (the default) or "rss"
+//
+// For example, /feed?q=spring&board_id=1&format=rss.
+type Handler struct {
+	Forum *mal.ForumService
+	// Title returns the title of the feed of a search. By default, the title
+	// lists the parameters of the search.
+	Title func(r *http.Request) string
+	// Options are added to the options of every search.
+	Options []mal.TopicsOption
+}
+
+// ServeHTTP serves the feed of the search described by the request. It
+// responds with 400 Bad Request for invalid parameters and 502 Bad Gateway if
+// the search fails.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "atom"
+	}
+	if format != "atom" && format != "rss" {
+		http.Error(w, `format must be "atom" or "rss"`, http.StatusBadRequest)
+		return
+	}
+	options, err := searchOptions(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	options = append(append([]mal.TopicsOption(nil), h.Options...), options...)
+
+	topics, _, err := h.Forum.Topics(r.Context(), options...)
+	if err != nil {
+		http.Error(w, "searching forum: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	title := defaultTitle(q)
+	if h.Title != nil {
+		title = h.Title(r)
+	}
+	f := FromTopics(title, topics)
+	f.Self = requestURL(r)
+
+	var buf bytes.Buffer
+	contentType := "application/atom+xml; charset=utf-8"
+	if format == "rss" {
+		contentType = "application/rss+xml; charset=utf-8"
+		err = f.WriteRSS(&buf)
+	} else {
+		err = f.WriteAtom(&buf)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Last-Modified", f.updated().Format(http.TimeFormat))
+	w.Write(buf.Bytes())
+}
+
+func searchOptions(q url.Values) ([]mal.TopicsOption, error) {
+	atoi := func(key string) (int, error) {
+		n, err := strconv.Atoi(q.Get(key))
+		if err != nil || n <= 0 {
+			return 0, errors.New(key + " must be a positive integer")
+		}
+		return n, nil
+	}
+	var oo []mal.TopicsOption
+	if v := q.Get("q"); v != "" {
+		oo = append(oo, mal.Query(v))
+	}
+	if q.Get("board_id") != "" {
+		id, err := atoi("board_id")
+		if err != nil {
+			return nil, err
+		}
+		oo = append(oo, mal.BoardID(id))
+	}
+	if q.Get("subboard_id") != "" {
+		id, err := atoi("subboard_id")
+		if err != nil {
+			return nil, err
+		}
+		oo = append(oo, mal.SubboardID(id))
+	}
+	if v := q.Get("user_name"); v != "" {
+		oo = append(oo, mal.UserName(v))
+	}
+	if v := q.Get("topic_user_name"); v != "" {
+		oo = append(oo, mal.TopicUserName(v))
+	}
+	if q.Get("limit") != "" {
+		n, err := atoi("limit")
+		if err != nil {
+			return nil, err
+		}
+		if n > maxLimit {
+			n = maxLimit
+		}
+		oo = append(oo, mal.Limit(n))
+	}
+	return oo, nil
+}
+
+func defaultTitle(q url.Values) string {
+	var parts []string
+	for _, key := range []string{"q", "board_id", "subboard_id", "user_name", "topic_user_name"} {
+		if v := q.Get(key); v != "" {
+			parts = append(parts, key+"="+v)
+		}
+	}
+	if len(parts) == 0 {
+		return "MyAnimeList forum"
+	}
+	return "MyAnimeList forum: " + strings.Join(parts, ", ")
+}
+
+// requestURL returns the absolute URL of the request.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
@@ -0,0 +1,97 @@
+package forumfeed
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// setup sets up a test HTTP server along with a mal.Client that is configured
+// to talk to that test server.
+func setup() (client *mal.Client, mux *http.ServeMux, teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client = mal.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, mux, server.Close
+}
+
+func TestHandler(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/forum/topics", func(w http.ResponseWriter, r *http.Request) {
+		want := url.Values{"q": {"spring"}, "board_id": {"1"}, "limit": {"100"}, "user_name": {"foo"}}
+		if got := r.URL.Query(); got.Encode() != want.Encode() {
+			t.Errorf("query = %v, want %v", got, want)
+		}
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": testTopics()}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	h := &Handler{Forum: client.Forum, Options: []mal.TopicsOption{mal.UserName("foo")}}
+	tests := []struct {
+		format      string
+		contentType string
+		contains    string
+	}{
+		{"", "application/atom+xml; charset=utf-8", `<link href="http://example.com/feed?q=spring&amp;board_id=1&amp;limit=500" rel="self" type="application/atom+xml"></link>`},
+		{"atom", "application/atom+xml; charset=utf-8", "<title>MyAnimeList forum: q=spring, board_id=1</title>"},
+		{"rss", "application/rss+xml; charset=utf-8", "<rss version=\"2.0\""},
+	}
+	for _, tt := range tests {
+		target := "/feed?q=spring&board_id=1&limit=500"
+		if tt.format != "" {
+			target += "&format=" + tt.format
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("format %q: status = %d, want %d: %s", tt.format, rec.Code, http.StatusOK, rec.Body)
+		}
+		if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("format %q: Content-Type = %q, want %q", tt.format, got, tt.contentType)
+		}
+		if got, want := rec.Header().Get("Last-Modified"), "Tue, 02 Mar 2021 03:30:00 GMT"; got != want {
+			t.Errorf("format %q: Last-Modified = %q, want %q", tt.format, got, want)
+		}
+		if !strings.Contains(rec.Body.String(), tt.contains) {
+			t.Errorf("format %q: body does not contain %s:\n%s", tt.format, tt.contains, rec.Body)
+		}
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/forum/topics", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"bad_request","message":"invalid q"}`, http.StatusBadRequest)
+	})
+
+	h := &Handler{Forum: client.Forum}
+	tests := []struct {
+		method string
+		target string
+		want   int
+	}{
+		{http.MethodGet, "/feed?format=json", http.StatusBadRequest},
+		{http.MethodGet, "/feed?board_id=x", http.StatusBadRequest},
+		{http.MethodGet, "/feed?limit=0", http.StatusBadRequest},
+		{http.MethodPost, "/feed", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/feed?q=x", http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+// Package forumwatch polls the MyAnimeList forum and reports new topics and
+// new posts.
+//
+// A Watcher is given forum searches to watch for new topics, such as all the
+// topics of a board, and topics to watch for new posts:
+//
+//	w := forumwatch.New(c.Forum, forumwatch.NewFileStore("forumwatch.json"))
+//	w.WatchTopics("updates", mal.BoardID(5))
+//	w.WatchTopic(481)
+//	err := w.Run(ctx, func(e forumwatch.Event) error {
+//		// Notify about e.
+//		return nil
+//	})
+//
+// The watcher keeps high-water marks of what it has already reported in a
+// Store so that nothing is reported twice, even across restarts. A search
+// with more activity between two polls than a single poll reads is reported
+// with a SearchTruncated event.
+package forumwatch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// EventType is the type of an Event.
+type EventType int
+
+const (
+	// NewTopic reports a topic that was created in a watched search.
+	NewTopic EventType = iota
+	// NewPost reports a post that was added to a watched topic.
+	NewPost
+	// SearchTruncated reports that more topics of a watched search had
+	// activity since the last poll than are read in a single poll. New
+	// topics among the ones that were not read are not reported.
+	SearchTruncated
+)
+
+func (t EventType) String() string {
+	switch t {
+	case NewTopic:
+		return "new topic"
+	case NewPost:
+		return "new post"
+	case SearchTruncated:
+		return "search truncated"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event is a new topic or post, or a search that was truncated.
+type Event struct {
+	Type EventType
+	// Search is the name of the search of NewTopic and SearchTruncated
+	// events.
+	Search string
+	// Topic is the new topic of a NewTopic event.
+	Topic mal.Topic
+	// TopicID is the ID of the topic of both NewTopic and NewPost events.
+	TopicID int
+	// Post is the new post of a NewPost event.
+	Post mal.Post
+}
+
+const (
+	defaultInterval   = 5 * time.Minute
+	defaultMaxBackoff = time.Hour
+	// topicsLimit is the largest page size allowed when searching topics.
+	topicsLimit = 100
+	// maxSearchPages is the number of pages of a search that are read in a
+	// single poll. A poll that stops at the limit reports SearchTruncated.
+	maxSearchPages = 5
+	// postSlack is how many posts before the last post seen are read again
+	// when polling a topic, in case removed posts have renumbered the rest.
+	postSlack = 20
+)
+
+// Watcher polls the forum for new topics and posts. Configure the watcher
+// before calling Poll, Run or Events. A Watcher is not safe for concurrent
+// use.
+type Watcher struct {
+	// Interval is the time between polls. It defaults to 5 minutes.
+	Interval time.Duration
+	// MaxBackoff is the longest time to wait before polling again after
+	// failed polls. The wait doubles after each consecutive failure starting
+	// from Interval. It defaults to one hour.
+	MaxBackoff time.Duration
+	// Backfill reports the existing topics and posts the first time a search
+	// or topic is polled. By default they are only recorded so that only the
+	// topics and posts that appear later are reported.
+	Backfill bool
+	// OnError, if set, is called with the error of each failed poll. Failed
+	// polls are retried.
+	OnError func(err error)
+
+	forum    *mal.ForumService
+	store    Store
+	searches []search
+	topics   []int
+	state    *State
+}
+
+type search struct {
+	name    string
+	options []mal.TopicsOption
+}
+
+// New returns a Watcher that uses forum to poll the forum and store to keep
+// its high-water marks.
+func New(forum *mal.ForumService, store Store) *Watcher {
+	return &Watcher{forum: forum, store: store}
+}
+
+// WatchTopics watches for new topics the forum search that uses the given
+// options, for example mal.BoardID, mal.SubboardID or mal.Query. The name
+// identifies the search in the store and in the events. It should not be
+// changed between restarts or the topics of the search will be reported as
+// new.
+func (w *Watcher) WatchTopics(name string, options ...mal.TopicsOption) {
+	w.searches = append(w.searches, search{name: name, options: options})
+}
+
+// WatchTopic watches the topic specified by topicID for new posts.
+func (w *Watcher) WatchTopic(topicID int) {
+	w.topics = append(w.topics, topicID)
+}
+
+// Poll polls all the watched searches and topics once and returns the new
+// topics and posts in the order they were created. The high-water marks are
+// saved before Poll returns so the same events are never returned again.
+func (w *Watcher) Poll(ctx context.Context) ([]Event, error) {
+	events, next, err := w.poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return events, w.commit(next)
+}
+
+// Run polls the forum every Interval and calls fn with each new topic and
+// post until ctx is done or fn returns an error. The high-water marks are
+// saved after each poll, including the events already handled when fn
+// fails, so that an event that fn handled successfully is not reported again.
+// Failed polls are reported to OnError and retried with exponential backoff.
+func (w *Watcher) Run(ctx context.Context, fn func(e Event) error) error {
+	failures := 0
+	for {
+		events, next, err := w.poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failures++
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+		} else {
+			failures = 0
+			for i, e := range events {
+				if err := fn(e); err != nil {
+					if serr := w.commitEvents(events[:i]); serr != nil {
+						return fmt.Errorf("%v (saving state: %v)", err, serr)
+					}
+					return err
+				}
+			}
+			if err := w.commit(next); err != nil {
+				return err
+			}
+		}
+		t := time.NewTimer(w.wait(failures))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Events runs the watcher like Run and sends the new topics and posts on the
+// returned channel, which is closed when ctx is done. Errors that stop the
+// watcher, such as failing to save its state, are reported to OnError before
+// the channel is closed.
+func (w *Watcher) Events(ctx context.Context) <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		err := w.Run(ctx, func(e Event) error {
+			select {
+			case ch <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil && w.OnError != nil {
+			w.OnError(err)
+		}
+	}()
+	return ch
+}
+
+// wait returns how long to wait before the next poll.
+func (w *Watcher) wait(failures int) time.Duration {
+	d := w.Interval
+	if d <= 0 {
+		d = defaultInterval
+	}
+	max := w.MaxBackoff
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+	for i := 0; i < failures && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+func (w *Watcher) load() error {
+	if w.state != nil {
+		return nil
+	}
+	s, err := w.store.Load()
+	if err != nil {
+		return fmt.Errorf("loading state: %v", err)
+	}
+	if s == nil {
+		s = new(State)
+	}
+	w.state = s
+	return nil
+}
+
+// poll returns the new events along with the state after all of them are
+// handled. The state of the watcher is not changed.
+func (w *Watcher) poll(ctx context.Context) ([]Event, *State, error) {
+	if err := w.load(); err != nil {
+		return nil, nil, err
+	}
+	next := w.state.clone()
+	var events []Event
+	for _, s := range w.searches {
+		ee, err := w.pollSearch(ctx, s, next)
+		if err != nil {
+			return nil, nil, fmt.Errorf("polling search %q: %w", s.name, err)
+		}
+		events = append(events, ee...)
+	}
+	for _, id := range w.topics {
+		ee, err := w.pollTopic(ctx, id, next)
+		if err != nil {
+			return nil, nil, fmt.Errorf("polling topic %d: %w", id, err)
+		}
+		events = append(events, ee...)
+	}
+	return events, next, nil
+}
+
+// pollSearch reads the topics of a search, which are sorted by their last
+// post, until it reaches the topics that have had no activity since the last
+// poll. If it stops at maxSearchPages before reaching them, it reports
+// SearchTruncated after the new topics, as the marks move past the topics
+// that were not read.
+func (w *Watcher) pollSearch(ctx context.Context, s search, next *State) ([]Event, error) {
+	mark, known := next.Searches[s.name]
+	var found []mal.Topic
+	newest := mark
+	offset := 0
+	truncated := false
+	for page := 0; page < maxSearchPages; page++ {
+		oo := append([]mal.TopicsOption{mal.Limit(topicsLimit)}, s.options...)
+		oo = append(oo, mal.Offset(offset))
+		topics, resp, err := w.forum.Topics(ctx, oo...)
+		if err != nil {
+			return nil, err
+		}
+		done := false
+		for _, t := range topics {
+			if known && !t.LastPostCreatedAt.After(mark.LastPostCreatedAt) {
+				done = true
+				break
+			}
+			if t.ID > mark.LastTopicID {
+				found = append(found, t)
+			}
+			if t.ID > newest.LastTopicID {
+				newest.LastTopicID = t.ID
+			}
+			if t.LastPostCreatedAt.After(newest.LastPostCreatedAt) {
+				newest.LastPostCreatedAt = t.LastPostCreatedAt
+			}
+		}
+		// The first poll only records the marks of the most recent page
+		// unless backfilling.
+		if done || resp.NextOffset == 0 || (!known && !w.Backfill) {
+			break
+		}
+		if page == maxSearchPages-1 {
+			truncated = true
+			break
+		}
+		offset = resp.NextOffset
+	}
+	next.setSearch(s.name, newest)
+	if !known && !w.Backfill {
+		return nil, nil
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+	events := make([]Event, len(found), len(found)+1)
+	for i, t := range found {
+		events[i] = Event{Type: NewTopic, Search: s.name, Topic: t, TopicID: t.ID}
+	}
+	if truncated {
+		events = append(events, Event{Type: SearchTruncated, Search: s.name})
+	}
+	return events, nil
+}
+
+// pollTopic reads the posts of a topic starting a little before the last post
+// seen.
+func (w *Watcher) pollTopic(ctx context.Context, topicID int, next *State) ([]Event, error) {
+	mark, known := next.Topics[topicID]
+	start := mark.LastPostNumber - postSlack
+	if start < 1 {
+		start = 1
+	}
+	var events []Event
+	newest := mark
+	_, err := w.forum.WalkTopic(ctx, topicID, func(p mal.Post) error {
+		if p.ID <= mark.LastPostID {
+			return nil
+		}
+		if known || w.Backfill {
+			events = append(events, Event{Type: NewPost, TopicID: topicID, Post: p})
+		}
+		newest = newest.max(p)
+		return nil
+	}, mal.StartAtPost(start))
+	if err != nil {
+		return nil, err
+	}
+	next.setTopic(topicID, newest)
+	return events, nil
+}
+
+// commit saves next as the current state.
+func (w *Watcher) commit(next *State) error {
+	if err := w.store.Save(next); err != nil {
+		return fmt.Errorf("saving state: %v", err)
+	}
+	w.state = next
+	return nil
+}
+
+// commitEvents saves the current state updated with the marks of the events
+// that were handled.
+func (w *Watcher) commitEvents(events []Event) error {
+	next := w.state.clone()
+	for _, e := range events {
+		switch e.Type {
+		case NewTopic:
+			m := next.Searches[e.Search]
+			if e.Topic.ID > m.LastTopicID {
+				m.LastTopicID = e.Topic.ID
+			}
+			next.setSearch(e.Search, m)
+		case NewPost:
+			next.setTopic(e.TopicID, next.Topics[e.TopicID].max(e.Post))
+		}
+	}
+	return w.commit(next)
+}
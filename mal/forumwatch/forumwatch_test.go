@@ -0,0 +1,353 @@
+package forumwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// setup sets up a test HTTP server along with a mal.Client that is configured
+// to talk to that test server.
+func setup() (client *mal.Client, mux *http.ServeMux, teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client = mal.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, mux, server.Close
+}
+
+// fakeForum serves a board of topics, most recently active first, and the
+// posts of topic 1.
+type fakeForum struct {
+	mu     sync.Mutex
+	topics []mal.Topic
+	posts  []mal.Post
+	fail   bool
+}
+
+var epoch = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (f *fakeForum) addTopic(id int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := mal.Topic{ID: id, Title: fmt.Sprintf("Topic %d", id), LastPostCreatedAt: epoch.Add(time.Duration(id) * time.Hour)}
+	f.topics = append([]mal.Topic{t}, f.topics...)
+}
+
+func (f *fakeForum) addPost(id int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.posts = append(f.posts, mal.Post{ID: id, Number: len(f.posts) + 1, CreatedAt: epoch.Add(time.Duration(id) * time.Minute)})
+}
+
+func (f *fakeForum) register(t *testing.T, mux *http.ServeMux) {
+	page := func(r *http.Request, n int) (int, int) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if offset > n {
+			offset = n
+		}
+		end := offset + limit
+		if end > n {
+			end = n
+		}
+		return offset, end
+	}
+	next := func(end, n int) mal.Paging {
+		if end < n {
+			return mal.Paging{Next: fmt.Sprintf("?offset=%d", end)}
+		}
+		return mal.Paging{}
+	}
+	mux.HandleFunc("/forum/topics", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.fail {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+		if got, want := r.URL.Query().Get("board_id"), "5"; got != want {
+			t.Errorf("board_id = %q, want %q", got, want)
+		}
+		from, to := page(r, len(f.topics))
+		writeJSON(t, w, map[string]interface{}{"data": f.topics[from:to], "paging": next(to, len(f.topics))})
+	})
+	mux.HandleFunc("/forum/topic/1", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		from, to := page(r, len(f.posts))
+		writeJSON(t, w, map[string]interface{}{
+			"data":   mal.TopicDetails{Title: "Thread", Posts: f.posts[from:to]},
+			"paging": next(to, len(f.posts)),
+		})
+	})
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ids returns the topic IDs of NewTopic events and the post IDs of NewPost
+// events.
+func ids(events []Event) []int {
+	var out []int
+	for _, e := range events {
+		switch e.Type {
+		case NewTopic:
+			out = append(out, e.Topic.ID)
+		case NewPost:
+			out = append(out, e.Post.ID)
+		}
+	}
+	return out
+}
+
+func newWatcher(client *mal.Client, store Store) *Watcher {
+	w := New(client.Forum, store)
+	w.WatchTopics("board", mal.BoardID(5))
+	w.WatchTopic(1)
+	return w
+}
+
+func TestWatcherPoll(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	f := &fakeForum{}
+	f.register(t, mux)
+	for i := 1; i <= 3; i++ {
+		f.addTopic(i)
+		f.addPost(100 + i)
+	}
+
+	store := &MemoryStore{}
+	w := newWatcher(client, store)
+	ctx := context.Background()
+
+	// The first poll only records what already exists.
+	events, err := w.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("first Poll returned %v, want no events", ids(events))
+	}
+
+	f.addTopic(4)
+	f.addTopic(5)
+	f.addPost(104)
+	events, err = w.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	want := []Event{
+		{Type: NewTopic, Search: "board", Topic: f.topics[1], TopicID: 4},
+		{Type: NewTopic, Search: "board", Topic: f.topics[0], TopicID: 5},
+		{Type: NewPost, TopicID: 1, Post: f.posts[3]},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Poll returned\nhave: %+v\nwant: %+v", events, want)
+	}
+
+	// A watcher that restarts with the same store does not report them again.
+	w = newWatcher(client, store)
+	f.addPost(105)
+	events, err = w.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := ids(events), []int{105}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll after restart returned %v, want %v", got, want)
+	}
+}
+
+func TestWatcherPollBackfill(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	f := &fakeForum{}
+	f.register(t, mux)
+	f.addTopic(1)
+	f.addTopic(2)
+	f.addPost(101)
+
+	w := newWatcher(client, &MemoryStore{})
+	w.Backfill = true
+	events, err := w.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := ids(events), []int{1, 2, 101}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll with Backfill returned %v, want %v", got, want)
+	}
+}
+
+func TestWatcherPollTruncated(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	f := &fakeForum{}
+	f.register(t, mux)
+	f.addTopic(1)
+
+	w := New(client.Forum, &MemoryStore{})
+	w.WatchTopics("board", mal.BoardID(5))
+	ctx := context.Background()
+	if _, err := w.Poll(ctx); err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+
+	// One more topic is active than a poll reads, so topic 2 is not read.
+	n := maxSearchPages*topicsLimit + 2
+	for i := 2; i <= n; i++ {
+		f.addTopic(i)
+	}
+	events, err := w.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := len(events), maxSearchPages*topicsLimit+1; got != want {
+		t.Fatalf("Poll returned %d events, want %d", got, want)
+	}
+	if got, want := events[0].TopicID, 3; got != want {
+		t.Errorf("Poll reported topic %d first, want %d", got, want)
+	}
+	if e, want := events[len(events)-1], (Event{Type: SearchTruncated, Search: "board"}); !reflect.DeepEqual(e, want) {
+		t.Errorf("Poll returned last event %+v, want %+v", e, want)
+	}
+
+	// A search that is read to the topics seen before is not truncated.
+	f.addTopic(n + 1)
+	events, err = w.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if got, want := ids(events), []int{n + 1}; len(events) != 1 || !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll returned %+v, want topic %d only", events, n+1)
+	}
+}
+
+func TestWatcherPollError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	f := &fakeForum{fail: true}
+	f.register(t, mux)
+
+	store := &MemoryStore{}
+	w := newWatcher(client, store)
+	if _, err := w.Poll(context.Background()); err == nil {
+		t.Fatal("Poll expected error, got no error.")
+	}
+	s, _ := store.Load()
+	if !reflect.DeepEqual(s, new(State)) {
+		t.Errorf("Poll saved state %+v after error, want nothing saved", s)
+	}
+}
+
+func TestWatcherRunSavesHandledEvents(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	f := &fakeForum{}
+	f.register(t, mux)
+	f.addPost(101)
+
+	store := &MemoryStore{}
+	ctx := context.Background()
+	if _, err := newWatcher(client, store).Poll(ctx); err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+
+	f.addPost(102)
+	f.addPost(103)
+	errStop := errors.New("stop")
+	var got []int
+	err := newWatcher(client, store).Run(ctx, func(e Event) error {
+		if e.Post.ID == 103 {
+			return errStop
+		}
+		got = append(got, e.Post.ID)
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("Run returned err = %v, want %v", err, errStop)
+	}
+
+	// Only the event that failed is reported again.
+	events, err := newWatcher(client, store).Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	got = append(got, ids(events)...)
+	if want := []int{102, 103}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events handled = %v, want %v", got, want)
+	}
+}
+
+func TestWatcherEvents(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	f := &fakeForum{}
+	f.register(t, mux)
+	f.addTopic(1)
+
+	w := newWatcher(client, &MemoryStore{})
+	w.Interval = time.Millisecond
+	w.OnError = func(err error) { t.Errorf("OnError called with %v", err) }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := w.Poll(ctx); err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	f.addTopic(2)
+	ch := w.Events(ctx)
+	select {
+	case e := <-ch:
+		if e.Type != NewTopic || e.Topic.ID != 2 {
+			t.Errorf("Events sent %+v, want new topic 2", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Events sent nothing")
+	}
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatcherWait(t *testing.T) {
+	w := &Watcher{Interval: time.Minute, MaxBackoff: 10 * time.Minute}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{3, 8 * time.Minute},
+		{4, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := w.wait(tt.failures); got != tt.want {
+			t.Errorf("wait(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+	if got, want := (&Watcher{}).wait(0), defaultInterval; got != want {
+		t.Errorf("default wait(0) = %v, want %v", got, want)
+	}
+}
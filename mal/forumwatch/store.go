@@ -0,0 +1,161 @@
+package forumwatch
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// State holds the high-water marks of a Watcher.
+type State struct {
+	Searches map[string]SearchMark `json:"searches,omitempty"`
+	Topics   map[int]TopicMark     `json:"topics,omitempty"`
+}
+
+// SearchMark is the high-water mark of a watched search.
+type SearchMark struct {
+	// LastTopicID is the ID of the newest topic seen. Topics with a greater ID
+	// are new.
+	LastTopicID int `json:"last_topic_id"`
+	// LastPostCreatedAt is the time of the most recent post of the topics
+	// seen. Searching stops at topics without more recent posts.
+	LastPostCreatedAt time.Time `json:"last_post_created_at"`
+}
+
+// TopicMark is the high-water mark of a watched topic.
+type TopicMark struct {
+	// LastPostID is the ID of the newest post seen. Posts with a greater ID
+	// are new.
+	LastPostID int `json:"last_post_id"`
+	// LastPostNumber is the number of the newest post seen.
+	LastPostNumber int `json:"last_post_number"`
+	// LastPostCreatedAt is the creation time of the newest post seen.
+	LastPostCreatedAt time.Time `json:"last_post_created_at"`
+}
+
+func (m TopicMark) max(p mal.Post) TopicMark {
+	if p.ID > m.LastPostID {
+		m.LastPostID = p.ID
+		m.LastPostNumber = p.Number
+		m.LastPostCreatedAt = p.CreatedAt
+	}
+	return m
+}
+
+func (s *State) clone() *State {
+	c := new(State)
+	for k, v := range s.Searches {
+		c.setSearch(k, v)
+	}
+	for k, v := range s.Topics {
+		c.setTopic(k, v)
+	}
+	return c
+}
+
+func (s *State) setSearch(name string, m SearchMark) {
+	if s.Searches == nil {
+		s.Searches = make(map[string]SearchMark)
+	}
+	s.Searches[name] = m
+}
+
+func (s *State) setTopic(id int, m TopicMark) {
+	if s.Topics == nil {
+		s.Topics = make(map[int]TopicMark)
+	}
+	s.Topics[id] = m
+}
+
+// Store keeps the state of a Watcher between restarts.
+type Store interface {
+	// Load returns the saved state or an empty state if none was saved.
+	Load() (*State, error)
+	// Save saves the state.
+	Save(s *State) error
+}
+
+// FileStore is a Store that keeps the state in a JSON file.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a FileStore that keeps the state in the file at path.
+// The file is created the first time the state is saved.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads the state from the file. It returns an empty state if the file
+// does not exist.
+func (fs *FileStore) Load() (*State, error) {
+	data, err := os.ReadFile(fs.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return new(State), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := new(State)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save writes the state to the file. It writes to a temporary file first and
+// renames it so that the file is never left partially written.
+func (fs *FileStore) Save(s *State) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), fs.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// MemoryStore is a Store that keeps the state in memory. It is useful for
+// watchers that do not need to survive restarts.
+type MemoryStore struct {
+	mu    sync.Mutex
+	state *State
+}
+
+// Load returns a copy of the saved state.
+func (ms *MemoryStore) Load() (*State, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.state == nil {
+		return new(State), nil
+	}
+	return ms.state.clone(), nil
+}
+
+// Save saves a copy of the state.
+func (ms *MemoryStore) Save(s *State) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.state = s.clone()
+	return nil
+}
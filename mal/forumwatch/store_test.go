@@ -0,0 +1,69 @@
+package forumwatch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	fs := NewFileStore(filepath.Join(dir, "state.json"))
+
+	s, err := fs.Load()
+	if err != nil {
+		t.Fatalf("Load of missing file returned error: %v", err)
+	}
+	if !reflect.DeepEqual(s, new(State)) {
+		t.Errorf("Load of missing file returned %+v, want empty state", s)
+	}
+
+	want := &State{
+		Searches: map[string]SearchMark{"board": {LastTopicID: 10, LastPostCreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		Topics:   map[int]TopicMark{1: {LastPostID: 100, LastPostNumber: 3, LastPostCreatedAt: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	if err := fs.Save(want); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	got, err := fs.Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load returned\nhave: %+v\nwant: %+v", got, want)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Save left %d files, want 1", len(entries))
+	}
+}
+
+func TestFileStoreInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(path).Load(); err == nil {
+		t.Error("Load of invalid file expected error, got no error.")
+	}
+}
+
+func TestMemoryStoreCopies(t *testing.T) {
+	ms := &MemoryStore{}
+	s := &State{}
+	s.setTopic(1, TopicMark{LastPostID: 1})
+	if err := ms.Save(s); err != nil {
+		t.Fatal(err)
+	}
+	s.setTopic(1, TopicMark{LastPostID: 2})
+	got, _ := ms.Load()
+	if got.Topics[1].LastPostID != 1 {
+		t.Errorf("MemoryStore kept a reference to the saved state")
+	}
+}
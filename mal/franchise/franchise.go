@@ -0,0 +1,466 @@
+// Package franchise builds the graph of a MyAnimeList franchise by following
+// the relations between anime and manga, such as sequels, prequels, side
+// stories and adaptations.
+//
+// A Crawler starts from an anime or manga and fetches the details of every
+// related entry it reaches:
+//
+//	cr := franchise.New(c)
+//	cr.MaxDepth = 3
+//	g, err := cr.Crawl(ctx, franchise.Anime, 1)
+//	for _, n := range g.WatchOrder() {
+//		fmt.Println(n.Title)
+//	}
+//
+// The graph can be exported with WriteDOT to be drawn by Graphviz or with
+// WriteJSON.
+package franchise
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/internal/pool"
+)
+
+// Kind is the kind of an entry, anime or manga.
+type Kind string
+
+// The kinds of entries.
+const (
+	Anime Kind = "anime"
+	Manga Kind = "manga"
+)
+
+// Relation types that order the entries of a franchise. The API returns
+// other relation types such as side_story, alternative_version and
+// adaptation which are kept in the graph but do not affect the order.
+const (
+	Sequel  = "sequel"
+	Prequel = "prequel"
+)
+
+// Key identifies an entry of the graph. It is formatted as "anime:1" or
+// "manga:2" which is also how it is encoded in JSON.
+type Key struct {
+	Kind Kind
+	ID   int
+}
+
+func (k Key) String() string {
+	return string(k.Kind) + ":" + strconv.Itoa(k.ID)
+}
+
+// MarshalText encodes the key as "kind:id".
+func (k Key) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a key encoded as "kind:id".
+func (k *Key) UnmarshalText(text []byte) error {
+	s := string(text)
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return fmt.Errorf("invalid key %q", s)
+	}
+	id, err := strconv.Atoi(s[i+1:])
+	kind := Kind(s[:i])
+	if err != nil || kind != Anime && kind != Manga {
+		return fmt.Errorf("invalid key %q", s)
+	}
+	*k = Key{Kind: kind, ID: id}
+	return nil
+}
+
+func (k Key) less(o Key) bool {
+	if k.Kind != o.Kind {
+		return k.Kind < o.Kind
+	}
+	return k.ID < o.ID
+}
+
+// Node is an anime or manga of the franchise.
+type Node struct {
+	Key       Key    `json:"key"`
+	Title     string `json:"title"`
+	MediaType string `json:"media_type,omitempty"`
+	// StartDate is the date the entry started airing or publishing in one of
+	// the formats "2006-01-02", "2006-01" or "2006". It is empty when it is
+	// unknown.
+	StartDate string `json:"start_date,omitempty"`
+	// Depth is the number of relations between the entry and the entry where
+	// the crawl started.
+	Depth int `json:"depth"`
+}
+
+// Edge is a relation from an entry to another as returned by the API, for
+// example an edge with the Sequel relation points to the sequel of the From
+// entry.
+type Edge struct {
+	From     Key    `json:"from"`
+	To       Key    `json:"to"`
+	Relation string `json:"relation"`
+}
+
+// Graph is a franchise. Nodes are sorted by depth and then by key and edges
+// are sorted by their keys.
+type Graph struct {
+	Root  Key    `json:"root"`
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Node returns the node of the graph with key k.
+func (g *Graph) Node(k Key) (Node, bool) {
+	for _, n := range g.Nodes {
+		if n.Key == k {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+// WatchOrder returns the anime of the franchise in the order they should be
+// watched. See Order.
+func (g *Graph) WatchOrder() []Node {
+	return g.Order(Anime)
+}
+
+// ReadingOrder returns the manga of the franchise in the order they should
+// be read. See Order.
+func (g *Graph) ReadingOrder() []Node {
+	return g.Order(Manga)
+}
+
+// Order returns the entries of the given kind in chronological order. An
+// entry always comes after its prequels and before its sequels. Otherwise
+// entries are ordered by their start date, with unknown dates last, and then
+// by ID. If the prequel and sequel relations form a cycle, the cycle is
+// broken at the earliest entry.
+func (g *Graph) Order(kind Kind) []Node {
+	var nodes []Node
+	for _, n := range g.Nodes {
+		if n.Key.Kind == kind {
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return earlier(nodes[i], nodes[j]) })
+
+	// before[k] holds the entries that must come before k.
+	before := make(map[Key]map[Key]bool)
+	require := func(first, then Key) {
+		if first == then {
+			return
+		}
+		if before[then] == nil {
+			before[then] = make(map[Key]bool)
+		}
+		before[then][first] = true
+	}
+	for _, e := range g.Edges {
+		if e.From.Kind != kind || e.To.Kind != kind {
+			continue
+		}
+		switch e.Relation {
+		case Sequel:
+			require(e.From, e.To)
+		case Prequel:
+			require(e.To, e.From)
+		}
+	}
+
+	order := make([]Node, 0, len(nodes))
+	placed := make(map[Key]bool)
+	for len(order) < len(nodes) {
+		next := -1
+		for i, n := range nodes {
+			if placed[n.Key] {
+				continue
+			}
+			if next < 0 {
+				// The earliest entry breaks a cycle if no entry is ready.
+				next = i
+			}
+			if ready(before[n.Key], placed) {
+				next = i
+				break
+			}
+		}
+		placed[nodes[next].Key] = true
+		order = append(order, nodes[next])
+	}
+	return order
+}
+
+func ready(before, placed map[Key]bool) bool {
+	for k := range before {
+		if !placed[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// earlier reports whether a started before b. Entries with unknown start
+// dates come last.
+func earlier(a, b Node) bool {
+	switch {
+	case a.StartDate == b.StartDate:
+		return a.Key.less(b.Key)
+	case a.StartDate == "":
+		return false
+	case b.StartDate == "":
+		return true
+	}
+	return a.StartDate < b.StartDate
+}
+
+// WriteJSON writes the graph as JSON including the keys of the anime and
+// manga in watch and reading order.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	keys := func(nodes []Node) []Key {
+		kk := make([]Key, len(nodes))
+		for i, n := range nodes {
+			kk[i] = n.Key
+		}
+		return kk
+	}
+	v := struct {
+		*Graph
+		WatchOrder   []Key `json:"watch_order"`
+		ReadingOrder []Key `json:"reading_order"`
+	}{g, keys(g.WatchOrder()), keys(g.ReadingOrder())}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// WriteDOT writes the graph in the DOT language of Graphviz. Anime are drawn
+// as ellipses and manga as boxes. The edges are labeled with the relation
+// type.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph franchise {\n")
+	for _, n := range g.Nodes {
+		label := n.Title
+		var extra []string
+		if n.MediaType != "" {
+			extra = append(extra, n.MediaType)
+		}
+		if len(n.StartDate) >= 4 {
+			extra = append(extra, n.StartDate[:4])
+		}
+		if len(extra) > 0 {
+			label += " (" + strings.Join(extra, ", ") + ")"
+		}
+		shape := "ellipse"
+		if n.Key.Kind == Manga {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "\t%s [label=%s, shape=%s];\n", dotQuote(n.Key.String()), dotQuote(label), shape)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s];\n", dotQuote(e.From.String()), dotQuote(e.To.String()), dotQuote(e.Relation))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+const defaultConcurrency = 4
+
+// Crawler follows the relations of anime and manga to build franchise
+// graphs. Configure the crawler before calling Crawl.
+type Crawler struct {
+	// MaxDepth is the largest number of relations followed from the entry
+	// where the crawl starts. Zero means no limit.
+	MaxDepth int
+	// MaxNodes stops the crawl after this many entries have been added to
+	// the graph. Zero means no limit.
+	MaxNodes int
+	// Concurrency is the number of details requested at the same time. It
+	// defaults to 4.
+	Concurrency int
+	// Relations are the relation types to follow, for example Sequel and
+	// Prequel. By default all relations are followed.
+	Relations []string
+	// AnimeOnly does not follow relations to manga.
+	AnimeOnly bool
+	// MangaOnly does not follow relations to anime.
+	MangaOnly bool
+
+	anime *mal.AnimeService
+	manga *mal.MangaService
+}
+
+// New returns a Crawler that uses c to fetch the details of the entries.
+func New(c *mal.Client) *Crawler {
+	return &Crawler{anime: c.Anime, manga: c.Manga}
+}
+
+// detailFields are the fields of the anime and manga details that the
+// crawler needs.
+var detailFields = mal.Fields{"title", "media_type", "start_date", "related_anime", "related_manga"}
+
+// details is the part of the details of an anime or manga that the crawler
+// needs.
+type details struct {
+	node    Node
+	related []Edge
+}
+
+// Crawl builds the graph of the franchise of the entry with the given kind
+// and ID. The entries are fetched one level of depth at a time. The first
+// error stops the crawl.
+func (c *Crawler) Crawl(ctx context.Context, kind Kind, id int) (*Graph, error) {
+	root := Key{Kind: kind, ID: id}
+	if !c.follows(kind) {
+		return nil, fmt.Errorf("crawling %v: the crawler does not follow %s", root, kind)
+	}
+	g := &Graph{Root: root}
+	seen := map[Key]bool{root: true}
+	var edges []Edge
+	level := []Key{root}
+	for depth := 0; len(level) > 0; depth++ {
+		found, err := c.fetch(ctx, level)
+		if err != nil {
+			return nil, err
+		}
+		var next []Key
+		for _, d := range found {
+			d.node.Depth = depth
+			g.Nodes = append(g.Nodes, d.node)
+			for _, e := range d.related {
+				if !c.followsEdge(e) {
+					continue
+				}
+				edges = append(edges, e)
+				if seen[e.To] || c.MaxDepth > 0 && depth >= c.MaxDepth {
+					continue
+				}
+				if c.MaxNodes > 0 && len(seen) >= c.MaxNodes {
+					continue
+				}
+				seen[e.To] = true
+				next = append(next, e.To)
+			}
+		}
+		level = next
+	}
+
+	// Keep the edges between the entries of the graph once each.
+	inGraph := make(map[Key]bool, len(g.Nodes))
+	for _, n := range g.Nodes {
+		inGraph[n.Key] = true
+	}
+	kept := make(map[Edge]bool)
+	for _, e := range edges {
+		if inGraph[e.To] && !kept[e] {
+			kept[e] = true
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		a, b := g.Nodes[i], g.Nodes[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		return a.Key.less(b.Key)
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		switch {
+		case a.From != b.From:
+			return a.From.less(b.From)
+		case a.To != b.To:
+			return a.To.less(b.To)
+		}
+		return a.Relation < b.Relation
+	})
+	return g, nil
+}
+
+func (c *Crawler) follows(kind Kind) bool {
+	return !(kind == Anime && c.MangaOnly || kind == Manga && c.AnimeOnly)
+}
+
+func (c *Crawler) followsEdge(e Edge) bool {
+	if !c.follows(e.To.Kind) {
+		return false
+	}
+	if len(c.Relations) == 0 {
+		return true
+	}
+	for _, r := range c.Relations {
+		if r == e.Relation {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch fetches the details of keys concurrently and returns them in the
+// same order.
+func (c *Crawler) fetch(ctx context.Context, keys []Key) ([]details, error) {
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	found := make([]details, len(keys))
+	err := pool.Run(ctx, concurrency, len(keys), func(ctx context.Context, i int) error {
+		d, err := c.details(ctx, keys[i])
+		if err != nil {
+			return err
+		}
+		found[i] = d
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (c *Crawler) details(ctx context.Context, k Key) (details, error) {
+	var (
+		d            details
+		relatedAnime []mal.RelatedAnime
+		relatedManga []mal.RelatedManga
+	)
+	switch k.Kind {
+	case Anime:
+		a, _, err := c.anime.Details(ctx, k.ID, detailFields)
+		if err != nil {
+			return details{}, fmt.Errorf("fetching %v: %w", k, err)
+		}
+		d.node = Node{Key: k, Title: a.Title, MediaType: a.MediaType, StartDate: a.StartDate}
+		relatedAnime, relatedManga = a.RelatedAnime, a.RelatedManga
+	case Manga:
+		m, _, err := c.manga.Details(ctx, k.ID, detailFields)
+		if err != nil {
+			return details{}, fmt.Errorf("fetching %v: %w", k, err)
+		}
+		d.node = Node{Key: k, Title: m.Title, MediaType: m.MediaType, StartDate: m.StartDate}
+		relatedAnime, relatedManga = m.RelatedAnime, m.RelatedManga
+	default:
+		return details{}, fmt.Errorf("fetching %v: unknown kind %q", k, k.Kind)
+	}
+	for _, r := range relatedAnime {
+		d.related = append(d.related, Edge{From: k, To: Key{Kind: Anime, ID: r.Node.ID}, Relation: r.RelationType})
+	}
+	for _, r := range relatedManga {
+		d.related = append(d.related, Edge{From: k, To: Key{Kind: Manga, ID: r.Node.ID}, Relation: r.RelationType})
+	}
+	return d, nil
+}
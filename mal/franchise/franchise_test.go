@@ -0,0 +1,268 @@
+package franchise
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// setup sets up a test HTTP server along with a mal.Client that is configured
+// to talk to that test server.
+func setup() (client *mal.Client, mux *http.ServeMux, teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client = mal.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, mux, server.Close
+}
+
+// franchiseJSON is a franchise where anime 4 is the sequel of anime 2 which is
+// the sequel of anime 1. Anime 3 is a side story and manga 10 the original
+// manga.
+var franchiseJSON = map[string]string{
+	"/anime/1": `{"id":1,"title":"First","media_type":"tv","start_date":"2000-01-05",
+		"related_anime":[{"node":{"id":2},"relation_type":"sequel"},{"node":{"id":3},"relation_type":"side_story"}],
+		"related_manga":[{"node":{"id":10},"relation_type":"adaptation"}]}`,
+	"/anime/2": `{"id":2,"title":"Second","media_type":"tv","start_date":"2001",
+		"related_anime":[{"node":{"id":1},"relation_type":"prequel"},{"node":{"id":4},"relation_type":"sequel"}]}`,
+	"/anime/3": `{"id":3,"title":"Side \"Story\"","media_type":"ova","start_date":"2000-06",
+		"related_anime":[{"node":{"id":1},"relation_type":"parent_story"}]}`,
+	"/anime/4": `{"id":4,"title":"Fourth","media_type":"movie","start_date":"1999-12-01",
+		"related_anime":[{"node":{"id":2},"relation_type":"prequel"}]}`,
+	"/manga/10": `{"id":10,"title":"Original","media_type":"manga","start_date":"1998",
+		"related_anime":[{"node":{"id":1},"relation_type":"adaptation"}]}`,
+}
+
+func serveFranchise(t *testing.T, mux *http.ServeMux, requested map[string]int) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("request method: %v, want GET", r.Method)
+		}
+		if got, want := r.URL.Query().Get("fields"), "title,media_type,start_date,related_anime,related_manga"; got != want {
+			t.Errorf("fields = %q, want %q", got, want)
+		}
+		body, ok := franchiseJSON[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"error":"not_found","message":""}`, http.StatusNotFound)
+			return
+		}
+		if requested != nil {
+			requested[r.URL.Path]++
+		}
+		fmt.Fprint(w, body)
+	})
+}
+
+func key(kind Kind, id int) Key { return Key{Kind: kind, ID: id} }
+
+func TestCrawl(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	requested := make(map[string]int)
+	serveFranchise(t, mux, requested)
+
+	cr := New(client)
+	cr.Concurrency = 1
+	g, err := cr.Crawl(context.Background(), Anime, 1)
+	if err != nil {
+		t.Fatalf("Crawl returned error: %v", err)
+	}
+
+	want := &Graph{
+		Root: key(Anime, 1),
+		Nodes: []Node{
+			{Key: key(Anime, 1), Title: "First", MediaType: "tv", StartDate: "2000-01-05"},
+			{Key: key(Anime, 2), Title: "Second", MediaType: "tv", StartDate: "2001", Depth: 1},
+			{Key: key(Anime, 3), Title: `Side "Story"`, MediaType: "ova", StartDate: "2000-06", Depth: 1},
+			{Key: key(Manga, 10), Title: "Original", MediaType: "manga", StartDate: "1998", Depth: 1},
+			{Key: key(Anime, 4), Title: "Fourth", MediaType: "movie", StartDate: "1999-12-01", Depth: 2},
+		},
+		Edges: []Edge{
+			{key(Anime, 1), key(Anime, 2), "sequel"},
+			{key(Anime, 1), key(Anime, 3), "side_story"},
+			{key(Anime, 1), key(Manga, 10), "adaptation"},
+			{key(Anime, 2), key(Anime, 1), "prequel"},
+			{key(Anime, 2), key(Anime, 4), "sequel"},
+			{key(Anime, 3), key(Anime, 1), "parent_story"},
+			{key(Anime, 4), key(Anime, 2), "prequel"},
+			{key(Manga, 10), key(Anime, 1), "adaptation"},
+		},
+	}
+	if !reflect.DeepEqual(g, want) {
+		t.Errorf("Crawl returned\nhave: %+v\nwant: %+v", g, want)
+	}
+	for path, n := range requested {
+		if n != 1 {
+			t.Errorf("%s was requested %d times, want once", path, n)
+		}
+	}
+
+	var titles []string
+	for _, n := range g.WatchOrder() {
+		titles = append(titles, n.Title)
+	}
+	if want := []string{"First", `Side "Story"`, "Second", "Fourth"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("WatchOrder = %q, want %q", titles, want)
+	}
+	if order := g.ReadingOrder(); len(order) != 1 || order[0].Key != key(Manga, 10) {
+		t.Errorf("ReadingOrder = %+v, want the manga", order)
+	}
+}
+
+func TestCrawlLimits(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	serveFranchise(t, mux, nil)
+
+	tests := []struct {
+		name      string
+		configure func(cr *Crawler)
+		start     Key
+		wantNodes []Key
+		wantEdges int
+	}{
+		{"max depth", func(cr *Crawler) { cr.MaxDepth = 1 }, key(Anime, 1), []Key{key(Anime, 1), key(Anime, 2), key(Anime, 3), key(Manga, 10)}, 6},
+		{"max nodes", func(cr *Crawler) { cr.MaxNodes = 2 }, key(Anime, 1), []Key{key(Anime, 1), key(Anime, 2)}, 2},
+		{"relations", func(cr *Crawler) { cr.Relations = []string{Sequel, Prequel} }, key(Anime, 1), []Key{key(Anime, 1), key(Anime, 2), key(Anime, 4)}, 4},
+		{"anime only", func(cr *Crawler) { cr.AnimeOnly = true }, key(Anime, 4), []Key{key(Anime, 4), key(Anime, 2), key(Anime, 1), key(Anime, 3)}, 6},
+		{"manga start", func(cr *Crawler) { cr.MaxDepth = 1 }, key(Manga, 10), []Key{key(Manga, 10), key(Anime, 1)}, 2},
+	}
+	for _, tt := range tests {
+		cr := New(client)
+		cr.Concurrency = 3
+		tt.configure(cr)
+		g, err := cr.Crawl(context.Background(), tt.start.Kind, tt.start.ID)
+		if err != nil {
+			t.Errorf("%s: Crawl returned error: %v", tt.name, err)
+			continue
+		}
+		var keys []Key
+		for _, n := range g.Nodes {
+			keys = append(keys, n.Key)
+		}
+		if !reflect.DeepEqual(keys, tt.wantNodes) {
+			t.Errorf("%s: Crawl nodes = %v, want %v", tt.name, keys, tt.wantNodes)
+		}
+		if len(g.Edges) != tt.wantEdges {
+			t.Errorf("%s: Crawl returned %d edges, want %d: %v", tt.name, len(g.Edges), tt.wantEdges, g.Edges)
+		}
+	}
+}
+
+func TestCrawlError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	serveFranchise(t, mux, nil)
+
+	_, err := New(client).Crawl(context.Background(), Anime, 99)
+	var errResp *mal.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != http.StatusNotFound {
+		t.Errorf("Crawl of missing anime returned error %v, want a not found error response", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "anime:99") {
+		t.Errorf("Crawl error %v does not mention the entry", err)
+	}
+
+	cr := New(client)
+	cr.MangaOnly = true
+	if _, err := cr.Crawl(context.Background(), Anime, 1); err == nil {
+		t.Error("Crawl of anime with MangaOnly returned no error")
+	}
+}
+
+func TestOrderCycle(t *testing.T) {
+	g := &Graph{
+		Nodes: []Node{
+			{Key: key(Anime, 1), StartDate: "2005"},
+			{Key: key(Anime, 2), StartDate: "2003"},
+			{Key: key(Anime, 3)},
+			{Key: key(Anime, 4), StartDate: "2004"},
+		},
+		Edges: []Edge{
+			{key(Anime, 1), key(Anime, 2), Sequel},
+			{key(Anime, 2), key(Anime, 1), Sequel},
+			{key(Anime, 3), key(Anime, 4), Prequel},
+		},
+	}
+	var got []int
+	for _, n := range g.WatchOrder() {
+		got = append(got, n.Key.ID)
+	}
+	if want := []int{4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WatchOrder = %v, want %v", got, want)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := &Graph{
+		Root: key(Anime, 1),
+		Nodes: []Node{
+			{Key: key(Anime, 1), Title: `Side "Story"`, MediaType: "ova", StartDate: "2000-06"},
+			{Key: key(Manga, 10), Title: "Original"},
+		},
+		Edges: []Edge{{key(Anime, 1), key(Manga, 10), "adaptation"}},
+	}
+	var b bytes.Buffer
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatalf("WriteDOT returned error: %v", err)
+	}
+	want := `digraph franchise {
+	"anime:1" [label="Side \"Story\" (ova, 2000)", shape=ellipse];
+	"manga:10" [label="Original", shape=box];
+	"anime:1" -> "manga:10" [label="adaptation"];
+}
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteDOT wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	g := &Graph{
+		Root: key(Anime, 1),
+		Nodes: []Node{
+			{Key: key(Anime, 1), Title: "First", StartDate: "2000"},
+			{Key: key(Anime, 2), Title: "Second", StartDate: "1999", Depth: 1},
+		},
+		Edges: []Edge{{key(Anime, 1), key(Anime, 2), Sequel}},
+	}
+	var b bytes.Buffer
+	if err := g.WriteJSON(&b); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	var got struct {
+		Graph
+		WatchOrder []Key `json:"watch_order"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJSON output could not be decoded: %v\n%s", err, b.String())
+	}
+	if !reflect.DeepEqual(&got.Graph, g) {
+		t.Errorf("WriteJSON graph decoded to %+v, want %+v", got.Graph, g)
+	}
+	if want := []Key{key(Anime, 1), key(Anime, 2)}; !reflect.DeepEqual(got.WatchOrder, want) {
+		t.Errorf("WriteJSON watch order = %v, want %v", got.WatchOrder, want)
+	}
+	if !strings.Contains(b.String(), `"from": "anime:1"`) {
+		t.Errorf("WriteJSON did not encode keys as text:\n%s", b.String())
+	}
+}
+
+func TestKeyUnmarshalText(t *testing.T) {
+	for _, s := range []string{"anime", "anime:x", "novel:1", ":1"} {
+		var k Key
+		if err := k.UnmarshalText([]byte(s)); err == nil {
+			t.Errorf("UnmarshalText(%q) returned no error", s)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Package idmap maps the IDs of anime on AniList, AniDB and Kitsu to
+// MyAnimeList IDs and back using an offline dataset.
+//
+// The dataset is read from disk so that lookups need no network access. It
+// is a JSON array with an object for each anime that has the IDs of the anime
+// on each site:
+//
+//	[
+//		{"mal_id": 1, "anilist_id": 1, "anidb_id": 23, "kitsu_id": 1},
+//		{"mal_id": 5, "anilist_id": 5, "anidb_id": 5, "kitsu_id": "3"}
+//	]
+//
+// IDs may be numbers or numeric strings and missing or zero IDs are unknown.
+// Other fields are ignored, so datasets such as anime-list-full.json of
+// Fribb/anime-lists can be used as is. Objects without a MyAnimeList ID are
+// skipped.
+//
+//	m, err := idmap.Load("anime-list-full.json")
+//	if err != nil {
+//		return err
+//	}
+//	a, _, err := m.Details(ctx, c, idmap.AniList, 21, mal.Fields{"num_episodes"})
+//
+// A Map satisfies the IDMapper of package scrobble.
+package idmap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// The sites whose IDs are mapped.
+const (
+	MAL     = "mal"
+	AniList = "anilist"
+	AniDB   = "anidb"
+	Kitsu   = "kitsu"
+)
+
+// ErrNotMapped is returned by Details when the ID has no MyAnimeList ID in
+// the dataset.
+var ErrNotMapped = errors.New("idmap: ID is not mapped to a MyAnimeList ID")
+
+// Entry is the IDs of an anime on each site. Zero IDs are unknown.
+type Entry struct {
+	MAL     int
+	AniList int
+	AniDB   int
+	Kitsu   int
+}
+
+func (e Entry) id(site string) int {
+	switch site {
+	case MAL:
+		return e.MAL
+	case AniList:
+		return e.AniList
+	case AniDB:
+		return e.AniDB
+	case Kitsu:
+		return e.Kitsu
+	}
+	return 0
+}
+
+// ambiguous marks the IDs of other sites that map to more than one
+// MyAnimeList ID in the dataset.
+const ambiguous = -1
+
+// Map answers lookups between the IDs of the sites. It is safe for concurrent
+// use since it is not modified after it is created.
+type Map struct {
+	entries map[int]Entry
+	toMAL   map[string]map[int]int
+}
+
+// New returns a Map of entries. Entries without a MyAnimeList ID are skipped.
+// When entries share a MyAnimeList ID, the IDs of all of them map to it and
+// Entry returns them merged with the first known ID of each site kept. An ID
+// of another site that maps to more than one MyAnimeList ID is not mapped
+// since it cannot be told which one it is.
+func New(entries []Entry) *Map {
+	m := &Map{
+		entries: make(map[int]Entry),
+		toMAL: map[string]map[int]int{
+			AniList: make(map[int]int),
+			AniDB:   make(map[int]int),
+			Kitsu:   make(map[int]int),
+		},
+	}
+	for _, e := range entries {
+		if e.MAL <= 0 {
+			continue
+		}
+		// Every entry is indexed, not only the merged one, since the dataset
+		// often has several IDs of a site for one MyAnimeList ID.
+		for site, ids := range m.toMAL {
+			id := e.id(site)
+			if id <= 0 {
+				continue
+			}
+			if malID, ok := ids[id]; ok && malID != e.MAL {
+				ids[id] = ambiguous
+				continue
+			}
+			ids[id] = e.MAL
+		}
+		if old, ok := m.entries[e.MAL]; ok {
+			e = merge(old, e)
+		}
+		m.entries[e.MAL] = e
+	}
+	return m
+}
+
+func merge(old, e Entry) Entry {
+	if old.AniList == 0 {
+		old.AniList = e.AniList
+	}
+	if old.AniDB == 0 {
+		old.AniDB = e.AniDB
+	}
+	if old.Kitsu == 0 {
+		old.Kitsu = e.Kitsu
+	}
+	return old
+}
+
+// entryJSON is an object of the dataset.
+type entryJSON struct {
+	MAL     flexID `json:"mal_id"`
+	AniList flexID `json:"anilist_id"`
+	AniDB   flexID `json:"anidb_id"`
+	Kitsu   flexID `json:"kitsu_id"`
+}
+
+// flexID is an ID that may be a JSON number, a numeric string or null.
+type flexID int
+
+func (id *flexID) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*id = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid ID %s", data)
+	}
+	*id = flexID(n)
+	return nil
+}
+
+// Read reads a dataset from r.
+func Read(r io.Reader) (*Map, error) {
+	var objs []entryJSON
+	if err := json.NewDecoder(r).Decode(&objs); err != nil {
+		return nil, fmt.Errorf("decoding dataset: %w", err)
+	}
+	entries := make([]Entry, len(objs))
+	for i, o := range objs {
+		entries[i] = Entry{
+			MAL:     int(o.MAL),
+			AniList: int(o.AniList),
+			AniDB:   int(o.AniDB),
+			Kitsu:   int(o.Kitsu),
+		}
+	}
+	return New(entries), nil
+}
+
+// Load reads the dataset in the file at path.
+func Load(path string) (*Map, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Len returns the number of anime in the map.
+func (m *Map) Len() int {
+	return len(m.entries)
+}
+
+// Entry returns the IDs of the anime with the given MyAnimeList ID.
+func (m *Map) Entry(malID int) (Entry, bool) {
+	e, ok := m.entries[malID]
+	return e, ok
+}
+
+// MALID returns the MyAnimeList ID of the anime with the given ID on site. For
+// the site MAL it reports whether the ID is in the dataset.
+func (m *Map) MALID(site string, id int) (int, bool) {
+	if site == MAL {
+		_, ok := m.entries[id]
+		return id, ok
+	}
+	malID, ok := m.toMAL[site][id]
+	return malID, ok && malID != ambiguous
+}
+
+// AnimeID is like MALID for IDs as text, such as the IDs of media server
+// events.
+func (m *Map) AnimeID(site, id string) (int, bool) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return m.MALID(site, n)
+}
+
+// ID returns the ID on site of the anime with the given MyAnimeList ID.
+func (m *Map) ID(malID int, site string) (int, bool) {
+	id := m.entries[malID].id(site)
+	return id, id > 0
+}
+
+// Details returns the details of the anime with the given ID on site using
+// AnimeService.Details. It returns an error wrapping ErrNotMapped if the ID
+// has no MyAnimeList ID.
+func (m *Map) Details(ctx context.Context, c *mal.Client, site string, id int, options ...mal.DetailsOption) (*mal.Anime, *mal.Response, error) {
+	malID, ok := m.MALID(site, id)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s ID %d", ErrNotMapped, site, id)
+	}
+	return c.Anime.Details(ctx, malID, options...)
+}
@@ -0,0 +1,123 @@
+package idmap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/maltest"
+)
+
+func loadTestMap(t *testing.T) *Map {
+	t.Helper()
+	m, err := Load("testdata/anime-list.json")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	return m
+}
+
+func TestLoad(t *testing.T) {
+	m := loadTestMap(t)
+	if got, want := m.Len(), 5; got != want {
+		t.Errorf("Len = %d, want %d", got, want)
+	}
+	e, ok := m.Entry(5)
+	if want := (Entry{MAL: 5, AniList: 5, AniDB: 5, Kitsu: 3}); !ok || e != want {
+		t.Errorf("Entry(5) = %+v, %v, want %+v, true", e, ok, want)
+	}
+	// The IDs of entries that share a MyAnimeList ID are merged.
+	e, ok = m.Entry(6)
+	if want := (Entry{MAL: 6, AniList: 6, AniDB: 86}); !ok || e != want {
+		t.Errorf("Entry(6) = %+v, %v, want %+v, true", e, ok, want)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"mal_id": 1}`,
+		`[{"mal_id": "one"}]`,
+		`[{"mal_id": 1, "kitsu_id": true}]`,
+	} {
+		if _, err := Read(strings.NewReader(data)); err == nil {
+			t.Errorf("Read(%s) returned no error", data)
+		}
+	}
+	if _, err := Load("testdata/missing.json"); err == nil {
+		t.Errorf("Load of missing file returned no error")
+	}
+}
+
+func TestMALID(t *testing.T) {
+	m := loadTestMap(t)
+	tests := []struct {
+		site string
+		id   int
+		want int
+		ok   bool
+	}{
+		{AniList, 1, 1, true},
+		{AniDB, 23, 1, true},
+		{Kitsu, 3, 5, true},
+		{AniDB, 86, 6, true},
+		{AniDB, 999, 6, true},
+		{AniList, 200000, 0, false},
+		// AniDB 500 maps to both 100 and 101.
+		{AniDB, 500, 0, false},
+		{MAL, 5, 5, true},
+		{MAL, 7, 7, false},
+		{"tvdb", 76885, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := m.MALID(tt.site, tt.id)
+		if ok != tt.ok || ok && got != tt.want {
+			t.Errorf("MALID(%s, %d) = %d, %v, want %d, %v", tt.site, tt.id, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if got, ok := m.AnimeID(AniDB, "23"); !ok || got != 1 {
+		t.Errorf("AnimeID(anidb, 23) = %d, %v, want 1, true", got, ok)
+	}
+	for _, id := range []string{"", "x", "-1"} {
+		if _, ok := m.AnimeID(AniDB, id); ok {
+			t.Errorf("AnimeID(anidb, %q) reported a mapping", id)
+		}
+	}
+}
+
+func TestID(t *testing.T) {
+	m := loadTestMap(t)
+	if got, ok := m.ID(1, AniDB); !ok || got != 23 {
+		t.Errorf("ID(1, anidb) = %d, %v, want 23, true", got, ok)
+	}
+	if got, ok := m.ID(100, Kitsu); ok {
+		t.Errorf("ID(100, kitsu) = %d, true, want no ID", got)
+	}
+	if got, ok := m.ID(404, AniList); ok {
+		t.Errorf("ID(404, anilist) = %d, true, want no ID", got)
+	}
+}
+
+func TestDetails(t *testing.T) {
+	srv := maltest.NewServer(&maltest.Fixtures{
+		Anime: []mal.Anime{{ID: 1, Title: "Cowboy Bebop", NumEpisodes: 26}},
+	})
+	defer srv.Close()
+	c := srv.Client()
+	m := loadTestMap(t)
+
+	a, _, err := m.Details(context.Background(), c, AniDB, 23, mal.Fields{"num_episodes"})
+	if err != nil {
+		t.Fatalf("Details returned error: %v", err)
+	}
+	if a.ID != 1 || a.Title != "Cowboy Bebop" || a.NumEpisodes != 26 {
+		t.Errorf("Details returned %+v, want Cowboy Bebop with 26 episodes", a)
+	}
+
+	_, _, err = m.Details(context.Background(), c, AniList, 200000)
+	if !errors.Is(err, ErrNotMapped) {
+		t.Errorf("Details of unmapped ID returned error %v, want ErrNotMapped", err)
+	}
+}
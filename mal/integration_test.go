@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
+	"os"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/recorder"
 	"golang.org/x/oauth2"
 )
 
@@ -16,6 +18,8 @@ var (
 	oauth2Token  = flag.String("oauth2-token", "", "MyAnimeList.net oauth2 token to use for integration tests in `JSON` format")
 	clientID     = flag.String("client-id", "", "your registered MyAnimeList.net application client ID")
 	clientSecret = flag.String("client-secret", "", "your registered MyAnimeList.net application client secret; optional if you chose App Type 'other'")
+	record       = flag.Bool("record", false, "record the integration tests to the cassette; requires an oauth2 token and client ID")
+	cassette     = flag.String("cassette", "testdata/cassettes/integration.json", "cassette used to replay the integration tests when no oauth2 token is provided")
 )
 
 func setup(ctx context.Context, t *testing.T) *mal.Client {
@@ -27,9 +31,21 @@ func setup(ctx context.Context, t *testing.T) *mal.Client {
 		"expiry": "2021-06-01T16:12:56.1319122Z"
 	}`
 	if *oauth2Token == "" || *clientID == "" {
+		if *record {
+			t.Fatal("Recording the integration tests requires an oauth2 token and client ID.")
+		}
+		if _, err := os.Stat(*cassette); err == nil {
+			rec, err := recorder.New(*cassette, recorder.Replay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("No oauth2 token or client ID provided, replaying %s.", *cassette)
+			return mal.NewClient(rec.Client())
+		}
 		t.Log("No oauth2 token or client ID provided.")
 		t.Log("The integration tests are meant to be run with a dedicated test account with empty lists.")
 		t.Log("To run the integration tests use: go test --client-id='<your client ID>' --oauth2-token='<your oauth2 token>'")
+		t.Log("To also record them for offline replay add: --record")
 		t.Logf("The oauth2 token is expected to be in JSON format, example: %s", tokenFormat)
 		t.Log(`Note: On some terminals you may need to escape the double quotes: --oauth2-token='{\"token_type\":\"Bearer\",...'`)
 		t.Skip("Skipping integration tests.")
@@ -54,7 +70,21 @@ func setup(ctx context.Context, t *testing.T) *mal.Client {
 		},
 	}
 
-	return mal.NewClient(conf.Client(ctx, token))
+	httpClient := conf.Client(ctx, token)
+	if *record {
+		rec, err := recorder.New(*cassette, recorder.Record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec.Transport = httpClient.Transport
+		t.Cleanup(func() {
+			if err := rec.Stop(); err != nil {
+				t.Error(err)
+			}
+		})
+		httpClient = rec.Client()
+	}
+	return mal.NewClient(httpClient)
 }
 
 func TestIntegration(t *testing.T) {
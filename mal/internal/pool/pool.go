@@ -0,0 +1,68 @@
+// Package pool runs calls concurrently with a bounded number of goroutines.
+package pool
+
+import (
+	"context"
+	"sync"
+)
+
+// Run calls fn with every index from 0 to n-1 using at most concurrency
+// goroutines, or one if concurrency is not positive. The first error returned
+// by fn cancels the context of the calls in flight, stops the calls that have
+// not started and is returned. If ctx is done before every call was started,
+// its error is returned.
+func Run(ctx context.Context, concurrency, n int, fn func(ctx context.Context, i int) error) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		mu       sync.Mutex
+		skipped  bool
+		jobs     = make(chan int)
+	)
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					mu.Lock()
+					skipped = true
+					mu.Unlock()
+					continue
+				}
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	started := 0
+send:
+	for started < n {
+		select {
+		case jobs <- started:
+			started++
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if started < n || skipped {
+		return ctx.Err()
+	}
+	return nil
+}
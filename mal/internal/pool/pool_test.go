@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		running int
+		peak    int
+		called  = make([]bool, 20)
+	)
+	err := Run(context.Background(), 3, len(called), func(ctx context.Context, i int) error {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		called[i] = true
+		mu.Unlock()
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if peak > 3 {
+		t.Errorf("Run ran %d calls at the same time, want at most 3", peak)
+	}
+	for i, ok := range called {
+		if !ok {
+			t.Errorf("Run did not call index %d", i)
+		}
+	}
+}
+
+func TestRunFirstErrorCancels(t *testing.T) {
+	errFirst := errors.New("first")
+	var mu sync.Mutex
+	calls := 0
+	err := Run(context.Background(), 1, 10, func(ctx context.Context, i int) error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if i == 2 {
+			return errFirst
+		}
+		return ctx.Err()
+	})
+	if !errors.Is(err, errFirst) {
+		t.Errorf("Run returned err = %v, want %v", err, errFirst)
+	}
+	if calls != 3 {
+		t.Errorf("Run made %d calls, want the calls to stop after the error", calls)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	err := Run(ctx, 2, 5, func(ctx context.Context, i int) error {
+		calls++
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run returned err = %v, want %v", err, context.Canceled)
+	}
+	if calls != 0 {
+		t.Errorf("Run made %d calls with a canceled context, want 0", calls)
+	}
+}
@@ -0,0 +1,424 @@
+// Package listexport writes MyAnimeList user anime and manga lists as CSV or
+// JSON Lines.
+//
+// The lists are written page by page as they are received from the API so
+// that exporting large lists does not require holding them in memory:
+//
+//	w := listexport.NewAnimeCSVWriter(os.Stdout, listexport.DefaultAnimeColumns())
+//	err := listexport.Anime(ctx, c.User, "@me", w)
+package listexport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// pageSize is the largest page size allowed by the user list endpoints.
+const pageSize = 1000
+
+// AnimeWriter is implemented by the writers that can export anime list pages.
+type AnimeWriter interface {
+	WriteAnime(page []mal.UserAnime) error
+}
+
+// MangaWriter is implemented by the writers that can export manga list pages.
+type MangaWriter interface {
+	WriteManga(page []mal.UserManga) error
+}
+
+// fielder is implemented by writers that know which fields they need the API
+// to return.
+type fielder interface {
+	Fields() mal.Fields
+}
+
+// Anime walks the anime list of the user indicated by username (or use @me)
+// and writes each page to w as soon as it is received. If w needs specific
+// fields, like the CSV writers do, they are requested automatically unless
+// the Fields option is passed. The pages contain the maximum number of
+// entries allowed unless the Limit option is passed.
+func Anime(ctx context.Context, s *mal.UserService, username string, w AnimeWriter, options ...mal.AnimeListOption) error {
+	var hasLimit, hasFields bool
+	for _, o := range options {
+		switch o.(type) {
+		case mal.Limit:
+			hasLimit = true
+		case mal.Fields:
+			hasFields = true
+		}
+	}
+	var oo []mal.AnimeListOption
+	if !hasLimit {
+		oo = append(oo, mal.Limit(pageSize))
+	}
+	if f, ok := w.(fielder); ok && !hasFields {
+		oo = append(oo, f.Fields())
+	}
+	oo = append(oo, options...)
+	return s.WalkAnimeList(ctx, username, w.WriteAnime, oo...)
+}
+
+// Manga walks the manga list of the user indicated by username (or use @me)
+// and writes each page to w as soon as it is received. If w needs specific
+// fields, like the CSV writers do, they are requested automatically unless
+// the Fields option is passed. The pages contain the maximum number of
+// entries allowed unless the Limit option is passed.
+func Manga(ctx context.Context, s *mal.UserService, username string, w MangaWriter, options ...mal.MangaListOption) error {
+	var hasLimit, hasFields bool
+	for _, o := range options {
+		switch o.(type) {
+		case mal.Limit:
+			hasLimit = true
+		case mal.Fields:
+			hasFields = true
+		}
+	}
+	var oo []mal.MangaListOption
+	if !hasLimit {
+		oo = append(oo, mal.Limit(pageSize))
+	}
+	if f, ok := w.(fielder); ok && !hasFields {
+		oo = append(oo, f.Fields())
+	}
+	oo = append(oo, options...)
+	return s.WalkMangaList(ctx, username, w.WriteManga, oo...)
+}
+
+// JSONLinesWriter writes each list entry as a JSON object on its own line.
+type JSONLinesWriter struct {
+	enc *json.Encoder
+}
+
+// NewJSONLinesWriter returns a JSONLinesWriter that writes to w.
+func NewJSONLinesWriter(w io.Writer) *JSONLinesWriter {
+	return &JSONLinesWriter{enc: json.NewEncoder(w)}
+}
+
+// WriteAnime writes the anime of the page, one per line.
+func (w *JSONLinesWriter) WriteAnime(page []mal.UserAnime) error {
+	for _, a := range page {
+		if err := w.enc.Encode(a); err != nil {
+			return fmt.Errorf("encoding anime %d: %v", a.Anime.ID, err)
+		}
+	}
+	return nil
+}
+
+// WriteManga writes the manga of the page, one per line.
+func (w *JSONLinesWriter) WriteManga(page []mal.UserManga) error {
+	for _, m := range page {
+		if err := w.enc.Encode(m); err != nil {
+			return fmt.Errorf("encoding manga %d: %v", m.Manga.ID, err)
+		}
+	}
+	return nil
+}
+
+// AnimeColumn is a column of an anime list CSV export.
+type AnimeColumn struct {
+	// Name is the column header. The predefined columns are named after the
+	// JSON field they contain, prefixed with "list_status." for the fields of
+	// the list status.
+	Name string
+	// Field is the field that needs to be requested from the API for the
+	// column to have a value. It is empty for the fields that are always
+	// returned.
+	Field string
+	// Value returns the column value of an entry.
+	Value func(a mal.UserAnime) string
+}
+
+// MangaColumn is a column of a manga list CSV export.
+type MangaColumn struct {
+	// Name is the column header. The predefined columns are named after the
+	// JSON field they contain, prefixed with "list_status." for the fields of
+	// the list status.
+	Name string
+	// Field is the field that needs to be requested from the API for the
+	// column to have a value. It is empty for the fields that are always
+	// returned.
+	Field string
+	// Value returns the column value of an entry.
+	Value func(m mal.UserManga) string
+}
+
+var (
+	itoa = strconv.Itoa
+	ftoa = func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	btoa = strconv.FormatBool
+)
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func joinGenres(genres []mal.Genre) string {
+	names := make([]string, len(genres))
+	for i := range genres {
+		names[i] = genres[i].Name
+	}
+	return strings.Join(names, ",")
+}
+
+var animeColumns = []AnimeColumn{
+	{"id", "", func(a mal.UserAnime) string { return itoa(a.Anime.ID) }},
+	{"title", "", func(a mal.UserAnime) string { return a.Anime.Title }},
+	{"alternative_titles.en", "alternative_titles", func(a mal.UserAnime) string { return a.Anime.AlternativeTitles.En }},
+	{"alternative_titles.ja", "alternative_titles", func(a mal.UserAnime) string { return a.Anime.AlternativeTitles.Ja }},
+	{"media_type", "media_type", func(a mal.UserAnime) string { return a.Anime.MediaType }},
+	{"status", "status", func(a mal.UserAnime) string { return a.Anime.Status }},
+	{"num_episodes", "num_episodes", func(a mal.UserAnime) string { return itoa(a.Anime.NumEpisodes) }},
+	{"average_episode_duration", "average_episode_duration", func(a mal.UserAnime) string { return itoa(a.Anime.AverageEpisodeDuration) }},
+	{"start_date", "start_date", func(a mal.UserAnime) string { return a.Anime.StartDate }},
+	{"end_date", "end_date", func(a mal.UserAnime) string { return a.Anime.EndDate }},
+	{"start_season.year", "start_season", func(a mal.UserAnime) string { return itoa(a.Anime.StartSeason.Year) }},
+	{"start_season.season", "start_season", func(a mal.UserAnime) string { return a.Anime.StartSeason.Season }},
+	{"source", "source", func(a mal.UserAnime) string { return a.Anime.Source }},
+	{"rating", "rating", func(a mal.UserAnime) string { return a.Anime.Rating }},
+	{"mean", "mean", func(a mal.UserAnime) string { return ftoa(a.Anime.Mean) }},
+	{"rank", "rank", func(a mal.UserAnime) string { return itoa(a.Anime.Rank) }},
+	{"popularity", "popularity", func(a mal.UserAnime) string { return itoa(a.Anime.Popularity) }},
+	{"genres", "genres", func(a mal.UserAnime) string { return joinGenres(a.Anime.Genres) }},
+	{"list_status.status", "", func(a mal.UserAnime) string { return string(a.Status.Status) }},
+	{"list_status.score", "", func(a mal.UserAnime) string { return itoa(a.Status.Score) }},
+	{"list_status.num_episodes_watched", "", func(a mal.UserAnime) string { return itoa(a.Status.NumEpisodesWatched) }},
+	{"list_status.is_rewatching", "", func(a mal.UserAnime) string { return btoa(a.Status.IsRewatching) }},
+	{"list_status.updated_at", "", func(a mal.UserAnime) string { return formatTime(a.Status.UpdatedAt) }},
+	{"list_status.start_date", "list_status{start_date}", func(a mal.UserAnime) string { return a.Status.StartDate }},
+	{"list_status.finish_date", "list_status{finish_date}", func(a mal.UserAnime) string { return a.Status.FinishDate }},
+	{"list_status.priority", "list_status{priority}", func(a mal.UserAnime) string { return itoa(a.Status.Priority) }},
+	{"list_status.num_times_rewatched", "list_status{num_times_rewatched}", func(a mal.UserAnime) string { return itoa(a.Status.NumTimesRewatched) }},
+	{"list_status.rewatch_value", "list_status{rewatch_value}", func(a mal.UserAnime) string { return itoa(a.Status.RewatchValue) }},
+	{"list_status.tags", "list_status{tags}", func(a mal.UserAnime) string { return strings.Join(a.Status.Tags, ",") }},
+	{"list_status.comments", "list_status{comments}", func(a mal.UserAnime) string { return a.Status.Comments }},
+}
+
+var mangaColumns = []MangaColumn{
+	{"id", "", func(m mal.UserManga) string { return itoa(m.Manga.ID) }},
+	{"title", "", func(m mal.UserManga) string { return m.Manga.Title }},
+	{"alternative_titles.en", "alternative_titles", func(m mal.UserManga) string { return m.Manga.AlternativeTitles.En }},
+	{"alternative_titles.ja", "alternative_titles", func(m mal.UserManga) string { return m.Manga.AlternativeTitles.Ja }},
+	{"media_type", "media_type", func(m mal.UserManga) string { return m.Manga.MediaType }},
+	{"status", "status", func(m mal.UserManga) string { return m.Manga.Status }},
+	{"num_volumes", "num_volumes", func(m mal.UserManga) string { return itoa(m.Manga.NumVolumes) }},
+	{"num_chapters", "num_chapters", func(m mal.UserManga) string { return itoa(m.Manga.NumChapters) }},
+	{"start_date", "start_date", func(m mal.UserManga) string { return m.Manga.StartDate }},
+	{"mean", "mean", func(m mal.UserManga) string { return ftoa(m.Manga.Mean) }},
+	{"rank", "rank", func(m mal.UserManga) string { return itoa(m.Manga.Rank) }},
+	{"popularity", "popularity", func(m mal.UserManga) string { return itoa(m.Manga.Popularity) }},
+	{"genres", "genres", func(m mal.UserManga) string { return joinGenres(m.Manga.Genres) }},
+	{"list_status.status", "", func(m mal.UserManga) string { return string(m.Status.Status) }},
+	{"list_status.score", "", func(m mal.UserManga) string { return itoa(m.Status.Score) }},
+	{"list_status.num_volumes_read", "", func(m mal.UserManga) string { return itoa(m.Status.NumVolumesRead) }},
+	{"list_status.num_chapters_read", "", func(m mal.UserManga) string { return itoa(m.Status.NumChaptersRead) }},
+	{"list_status.is_rereading", "", func(m mal.UserManga) string { return btoa(m.Status.IsRereading) }},
+	{"list_status.updated_at", "", func(m mal.UserManga) string { return formatTime(m.Status.UpdatedAt) }},
+	{"list_status.start_date", "list_status{start_date}", func(m mal.UserManga) string { return m.Status.StartDate }},
+	{"list_status.finish_date", "list_status{finish_date}", func(m mal.UserManga) string { return m.Status.FinishDate }},
+	{"list_status.priority", "list_status{priority}", func(m mal.UserManga) string { return itoa(m.Status.Priority) }},
+	{"list_status.num_times_reread", "list_status{num_times_reread}", func(m mal.UserManga) string { return itoa(m.Status.NumTimesReread) }},
+	{"list_status.reread_value", "list_status{reread_value}", func(m mal.UserManga) string { return itoa(m.Status.RereadValue) }},
+	{"list_status.tags", "list_status{tags}", func(m mal.UserManga) string { return strings.Join(m.Status.Tags, ",") }},
+	{"list_status.comments", "list_status{comments}", func(m mal.UserManga) string { return m.Status.Comments }},
+}
+
+// AllAnimeColumns returns every predefined anime column.
+func AllAnimeColumns() []AnimeColumn {
+	return append([]AnimeColumn(nil), animeColumns...)
+}
+
+// AllMangaColumns returns every predefined manga column.
+func AllMangaColumns() []MangaColumn {
+	return append([]MangaColumn(nil), mangaColumns...)
+}
+
+// DefaultAnimeColumns returns the columns used for an anime export when no
+// other columns are chosen.
+func DefaultAnimeColumns() []AnimeColumn {
+	cols, _ := AnimeColumns("id", "title", "media_type", "num_episodes",
+		"list_status.status", "list_status.score", "list_status.num_episodes_watched",
+		"list_status.start_date", "list_status.finish_date", "list_status.tags")
+	return cols
+}
+
+// DefaultMangaColumns returns the columns used for a manga export when no
+// other columns are chosen.
+func DefaultMangaColumns() []MangaColumn {
+	cols, _ := MangaColumns("id", "title", "media_type", "num_volumes", "num_chapters",
+		"list_status.status", "list_status.score", "list_status.num_volumes_read",
+		"list_status.num_chapters_read", "list_status.start_date",
+		"list_status.finish_date", "list_status.tags")
+	return cols
+}
+
+// AnimeColumns returns the predefined anime columns with the given names in
+// the same order. An error is returned if a name is unknown.
+func AnimeColumns(names ...string) ([]AnimeColumn, error) {
+	byName := make(map[string]AnimeColumn, len(animeColumns))
+	for _, c := range animeColumns {
+		byName[c.Name] = c
+	}
+	cols := make([]AnimeColumn, len(names))
+	for i, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown anime column %q", name)
+		}
+		cols[i] = c
+	}
+	return cols, nil
+}
+
+// MangaColumns returns the predefined manga columns with the given names in
+// the same order. An error is returned if a name is unknown.
+func MangaColumns(names ...string) ([]MangaColumn, error) {
+	byName := make(map[string]MangaColumn, len(mangaColumns))
+	for _, c := range mangaColumns {
+		byName[c.Name] = c
+	}
+	cols := make([]MangaColumn, len(names))
+	for i, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown manga column %q", name)
+		}
+		cols[i] = c
+	}
+	return cols, nil
+}
+
+// fields merges the fields needed by columns into a Fields option. Nested list
+// status fields are combined into a single list_status field.
+func fields(columnFields []string) mal.Fields {
+	seen := make(map[string]bool)
+	var top, listStatus []string
+	for _, f := range columnFields {
+		if f == "" || seen[f] {
+			continue
+		}
+		seen[f] = true
+		if strings.HasPrefix(f, "list_status{") {
+			listStatus = append(listStatus, strings.TrimSuffix(strings.TrimPrefix(f, "list_status{"), "}"))
+			continue
+		}
+		top = append(top, f)
+	}
+	sort.Strings(listStatus)
+	status := "list_status"
+	if len(listStatus) != 0 {
+		status += "{" + strings.Join(listStatus, ",") + "}"
+	}
+	return append(mal.Fields{status}, top...)
+}
+
+// AnimeCSVWriter writes anime list entries as CSV records with a header
+// record written before the first page.
+type AnimeCSVWriter struct {
+	w           *csv.Writer
+	columns     []AnimeColumn
+	wroteHeader bool
+}
+
+// NewAnimeCSVWriter returns an AnimeCSVWriter that writes the given columns to
+// w.
+func NewAnimeCSVWriter(w io.Writer, columns []AnimeColumn) *AnimeCSVWriter {
+	return &AnimeCSVWriter{w: csv.NewWriter(w), columns: columns}
+}
+
+// Fields returns the fields that need to be requested from the API for all
+// the columns of the writer to have values.
+func (w *AnimeCSVWriter) Fields() mal.Fields {
+	ff := make([]string, len(w.columns))
+	for i := range w.columns {
+		ff[i] = w.columns[i].Field
+	}
+	return fields(ff)
+}
+
+// WriteAnime writes a record for each anime of the page and flushes them to
+// the underlying writer.
+func (w *AnimeCSVWriter) WriteAnime(page []mal.UserAnime) error {
+	if !w.wroteHeader {
+		header := make([]string, len(w.columns))
+		for i := range w.columns {
+			header[i] = w.columns[i].Name
+		}
+		if err := w.w.Write(header); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+	record := make([]string, len(w.columns))
+	for _, a := range page {
+		for i := range w.columns {
+			record[i] = w.columns[i].Value(a)
+		}
+		if err := w.w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// MangaCSVWriter writes manga list entries as CSV records with a header
+// record written before the first page.
+type MangaCSVWriter struct {
+	w           *csv.Writer
+	columns     []MangaColumn
+	wroteHeader bool
+}
+
+// NewMangaCSVWriter returns a MangaCSVWriter that writes the given columns to
+// w.
+func NewMangaCSVWriter(w io.Writer, columns []MangaColumn) *MangaCSVWriter {
+	return &MangaCSVWriter{w: csv.NewWriter(w), columns: columns}
+}
+
+// Fields returns the fields that need to be requested from the API for all
+// the columns of the writer to have values.
+func (w *MangaCSVWriter) Fields() mal.Fields {
+	ff := make([]string, len(w.columns))
+	for i := range w.columns {
+		ff[i] = w.columns[i].Field
+	}
+	return fields(ff)
+}
+
+// WriteManga writes a record for each manga of the page and flushes them to
+// the underlying writer.
+func (w *MangaCSVWriter) WriteManga(page []mal.UserManga) error {
+	if !w.wroteHeader {
+		header := make([]string, len(w.columns))
+		for i := range w.columns {
+			header[i] = w.columns[i].Name
+		}
+		if err := w.w.Write(header); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+	record := make([]string, len(w.columns))
+	for _, m := range page {
+		for i := range w.columns {
+			record[i] = w.columns[i].Value(m)
+		}
+		if err := w.w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
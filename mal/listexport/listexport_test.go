@@ -0,0 +1,158 @@
+package listexport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// setup sets up a test HTTP server along with a mal.Client that is configured
+// to talk to that test server.
+func setup() (client *mal.Client, mux *http.ServeMux, teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client = mal.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, mux, server.Close
+}
+
+func TestAnimeCSV(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/animelist", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if got, want := q.Get("fields"), "list_status{tags},num_episodes"; got != want {
+			t.Errorf("fields = %q, want %q", got, want)
+		}
+		if got, want := q.Get("limit"), "1000"; got != want {
+			t.Errorf("limit = %q, want %q", got, want)
+		}
+		switch q.Get("offset") {
+		case "0":
+			fmt.Fprint(w, `{
+			  "data": [{"node": {"id": 1, "title": "Foo, the \"Movie\"", "num_episodes": 1}, "list_status": {"status": "completed", "tags": ["a", "b"]}}],
+			  "paging": {"next": "?offset=1"}
+			}`)
+		case "1":
+			fmt.Fprint(w, `{"data": [{"node": {"id": 2, "title": "Bar"}, "list_status": {"status": "watching"}}]}`)
+		}
+	})
+
+	cols, err := AnimeColumns("id", "title", "num_episodes", "list_status.status", "list_status.tags")
+	if err != nil {
+		t.Fatalf("AnimeColumns returned error: %v", err)
+	}
+	var buf bytes.Buffer
+	ctx := context.Background()
+	if err := Anime(ctx, client.User, "foo", NewAnimeCSVWriter(&buf, cols)); err != nil {
+		t.Fatalf("Anime returned error: %v", err)
+	}
+	const want = "id,title,num_episodes,list_status.status,list_status.tags\n" +
+		"1,\"Foo, the \"\"Movie\"\"\",1,completed,\"a,b\"\n" +
+		"2,Bar,0,watching,\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Anime CSV\nhave: %q\nwant: %q", got, want)
+	}
+}
+
+func TestMangaJSONLines(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/mangalist", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if got, want := q.Get("fields"), "num_chapters"; got != want {
+			t.Errorf("fields = %q, want %q", got, want)
+		}
+		if got, want := q.Get("status"), "reading"; got != want {
+			t.Errorf("status = %q, want %q", got, want)
+		}
+		fmt.Fprint(w, `{"data": [{"node": {"id": 1}}, {"node": {"id": 2}}]}`)
+	})
+
+	var buf bytes.Buffer
+	ctx := context.Background()
+	err := Manga(ctx, client.User, "foo", NewJSONLinesWriter(&buf), mal.MangaStatusReading, mal.Fields{"num_chapters"})
+	if err != nil {
+		t.Fatalf("Manga returned error: %v", err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if got, want := len(lines), 2; got != want {
+		t.Fatalf("Manga wrote %d lines, want %d", got, want)
+	}
+	if !bytes.HasPrefix(lines[1], []byte(`{"node":{"id":2,`)) {
+		t.Errorf("second line = %s, want manga with ID 2", lines[1])
+	}
+}
+
+func TestMangaCSVOptions(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/mangalist", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if got, want := q["fields"], []string{"num_chapters"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("fields = %q, want %q", got, want)
+		}
+		if got, want := q["limit"], []string{"10"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("limit = %q, want %q", got, want)
+		}
+		fmt.Fprint(w, `{"data": [{"node": {"id": 1, "title": "Foo", "num_chapters": 3}}]}`)
+	})
+
+	cols, err := MangaColumns("id", "num_chapters")
+	if err != nil {
+		t.Fatalf("MangaColumns returned error: %v", err)
+	}
+	var buf bytes.Buffer
+	err = Manga(context.Background(), client.User, "foo", NewMangaCSVWriter(&buf, cols), mal.Fields{"num_chapters"}, mal.Limit(10))
+	if err != nil {
+		t.Fatalf("Manga returned error: %v", err)
+	}
+	if got, want := buf.String(), "id,num_chapters\n1,3\n"; got != want {
+		t.Errorf("Manga CSV\nhave: %q\nwant: %q", got, want)
+	}
+}
+
+func TestAnimeError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/animelist", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"mal is down","error":"internal"}`, http.StatusInternalServerError)
+	})
+
+	var buf bytes.Buffer
+	ctx := context.Background()
+	if err := Anime(ctx, client.User, "foo", NewJSONLinesWriter(&buf)); err == nil {
+		t.Fatal("Anime expected internal error, got no error.")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Anime wrote %q on error, want nothing", buf.String())
+	}
+}
+
+func TestMangaCSVWriterFields(t *testing.T) {
+	w := NewMangaCSVWriter(nil, DefaultMangaColumns())
+	want := mal.Fields{"list_status{finish_date,start_date,tags}", "media_type", "num_volumes", "num_chapters"}
+	if got := w.Fields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MangaCSVWriter.Fields() = %q, want %q", got, want)
+	}
+}
+
+func TestColumnsUnknown(t *testing.T) {
+	if _, err := AnimeColumns("id", "foo"); err == nil {
+		t.Error("AnimeColumns with unknown column expected error, got no error.")
+	}
+	if _, err := MangaColumns("list_status.num_episodes_watched"); err == nil {
+		t.Error("MangaColumns with unknown column expected error, got no error.")
+	}
+}
@@ -0,0 +1,170 @@
+package listimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// aniListExport is the result of a MediaListCollection query of the AniList
+// API, with or without the data envelope of the response.
+type aniListExport struct {
+	Data struct {
+		MediaListCollection aniListCollection `json:"MediaListCollection"`
+	} `json:"data"`
+	MediaListCollection aniListCollection `json:"MediaListCollection"`
+}
+
+type aniListCollection struct {
+	Lists []struct {
+		Entries []aniListEntry `json:"entries"`
+	} `json:"lists"`
+}
+
+type aniListEntry struct {
+	Status          string      `json:"status"`
+	Score           float64     `json:"score"`
+	Progress        int         `json:"progress"`
+	ProgressVolumes int         `json:"progressVolumes"`
+	Repeat          int         `json:"repeat"`
+	Notes           string      `json:"notes"`
+	StartedAt       aniListDate `json:"startedAt"`
+	CompletedAt     aniListDate `json:"completedAt"`
+	Media           struct {
+		ID    int    `json:"id"`
+		IDMal int    `json:"idMal"`
+		Type  string `json:"type"`
+		Title struct {
+			Romaji  string `json:"romaji"`
+			English string `json:"english"`
+			Native  string `json:"native"`
+		} `json:"title"`
+	} `json:"media"`
+}
+
+// aniListDate is a FuzzyDate of AniList whose parts may be unknown.
+type aniListDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+// String returns the date in the format of MyAnimeList or "" if it is not a
+// full date, since MyAnimeList updates need full dates.
+func (d aniListDate) String() string {
+	if d.Year == 0 || d.Month == 0 || d.Day == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// ReadAniList reads an AniList list export from r. The export is the JSON
+// result of a MediaListCollection query of the AniList API with the scores in
+// the 100 point format:
+//
+//	query ($user: String, $type: MediaType) {
+//		MediaListCollection(userName: $user, type: $type) {
+//			lists {
+//				entries {
+//					status score(format: POINT_100) progress progressVolumes repeat notes
+//					startedAt { year month day } completedAt { year month day }
+//					media { id idMal type title { romaji english native } }
+//				}
+//			}
+//		}
+//	}
+//
+// The entries of the anime and manga lists can be combined in one export
+// since they are told apart by their media type. Entries without a media type
+// are anime.
+func ReadAniList(r io.Reader) (*List, error) {
+	var x aniListExport
+	if err := json.NewDecoder(r).Decode(&x); err != nil {
+		return nil, fmt.Errorf("decoding AniList export: %w", err)
+	}
+	coll := x.MediaListCollection
+	if len(coll.Lists) == 0 {
+		coll = x.Data.MediaListCollection
+	}
+	list := &List{Source: AniList}
+	for _, l := range coll.Lists {
+		for _, e := range l.Entries {
+			title := e.Media.Title.Romaji
+			if title == "" {
+				title = e.Media.Title.English
+			}
+			if title == "" {
+				title = e.Media.Title.Native
+			}
+			repeating := e.Status == "REPEATING"
+			if strings.EqualFold(e.Media.Type, "MANGA") {
+				list.Manga = append(list.Manga, MangaEntry{
+					SourceID: e.Media.ID,
+					MALID:    e.Media.IDMal,
+					Title:    title,
+					Status: mal.MangaListStatus{
+						Status:          aniListMangaStatus(e.Status),
+						IsRereading:     repeating,
+						NumVolumesRead:  e.ProgressVolumes,
+						NumChaptersRead: e.Progress,
+						Score:           score100(e.Score),
+						NumTimesReread:  e.Repeat,
+						Comments:        e.Notes,
+						StartDate:       e.StartedAt.String(),
+						FinishDate:      e.CompletedAt.String(),
+					},
+				})
+				continue
+			}
+			list.Anime = append(list.Anime, AnimeEntry{
+				SourceID: e.Media.ID,
+				MALID:    e.Media.IDMal,
+				Title:    title,
+				Status: mal.AnimeListStatus{
+					Status:             aniListAnimeStatus(e.Status),
+					Score:              score100(e.Score),
+					NumEpisodesWatched: e.Progress,
+					IsRewatching:       repeating,
+					NumTimesRewatched:  e.Repeat,
+					Comments:           e.Notes,
+					StartDate:          e.StartedAt.String(),
+					FinishDate:         e.CompletedAt.String(),
+				},
+			})
+		}
+	}
+	return list, nil
+}
+
+// aniListAnimeStatus converts a MediaListStatus of AniList. Rewatches are
+// completed anime that are being rewatched on MyAnimeList.
+func aniListAnimeStatus(s string) mal.AnimeStatus {
+	switch s {
+	case "CURRENT":
+		return mal.AnimeStatusWatching
+	case "COMPLETED", "REPEATING":
+		return mal.AnimeStatusCompleted
+	case "PAUSED":
+		return mal.AnimeStatusOnHold
+	case "DROPPED":
+		return mal.AnimeStatusDropped
+	}
+	return mal.AnimeStatusPlanToWatch
+}
+
+func aniListMangaStatus(s string) mal.MangaStatus {
+	switch s {
+	case "CURRENT":
+		return mal.MangaStatusReading
+	case "COMPLETED", "REPEATING":
+		return mal.MangaStatusCompleted
+	case "PAUSED":
+		return mal.MangaStatusOnHold
+	case "DROPPED":
+		return mal.MangaStatusDropped
+	}
+	return mal.MangaStatusPlanToRead
+}
@@ -0,0 +1,69 @@
+package listimport
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func TestReadAniList(t *testing.T) {
+	f, err := os.Open("testdata/anilist.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := ReadAniList(f)
+	if err != nil {
+		t.Fatalf("ReadAniList returned error: %v", err)
+	}
+	want := &List{
+		Source: AniList,
+		Anime: []AnimeEntry{
+			{SourceID: 1, MALID: 1, Title: "Cowboy Bebop", Status: mal.AnimeListStatus{
+				Status: mal.AnimeStatusCompleted, Score: 9, NumEpisodesWatched: 26, NumTimesRewatched: 1,
+				Comments: "classic", StartDate: "2019-01-02",
+			}},
+			{SourceID: 6, Title: "Trigun", Status: mal.AnimeListStatus{
+				Status: mal.AnimeStatusCompleted, Score: 1, NumEpisodesWatched: 4, IsRewatching: true,
+			}},
+		},
+		Manga: []MangaEntry{
+			{SourceID: 30013, MALID: 13, Title: "One Piece", Status: mal.MangaListStatus{
+				Status: mal.MangaStatusReading, NumChaptersRead: 120, NumVolumesRead: 12, StartDate: "2020-03-04",
+			}},
+			{SourceID: 30002, Title: "Berserk", Status: mal.MangaListStatus{
+				Status: mal.MangaStatusPlanToRead, Score: 10,
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAniList\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestReadAniListWithoutEnvelope(t *testing.T) {
+	const data = `{"MediaListCollection": {"lists": [{"entries": [
+		{"status": "PAUSED", "score": 55, "progress": 2, "media": {"id": 20, "idMal": 20, "title": {"romaji": "Naruto"}}},
+		{"status": "DROPPED", "score": 40, "media": {"id": 30, "type": "MANGA", "title": {"romaji": "Bleach"}}}
+	]}]}}`
+	got, err := ReadAniList(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadAniList returned error: %v", err)
+	}
+	if len(got.Anime) != 1 || got.Anime[0].Status.Status != mal.AnimeStatusOnHold || got.Anime[0].Status.Score != 6 {
+		t.Errorf("ReadAniList anime = %+v, want Naruto on hold scored 6", got.Anime)
+	}
+	if len(got.Manga) != 1 || got.Manga[0].Status.Status != mal.MangaStatusDropped || got.Manga[0].Status.Score != 4 {
+		t.Errorf("ReadAniList manga = %+v, want Bleach dropped scored 4", got.Manga)
+	}
+}
+
+func TestReadAniListError(t *testing.T) {
+	if _, err := ReadAniList(strings.NewReader(`{"data": [`)); err == nil {
+		t.Error("ReadAniList of invalid JSON returned no error")
+	}
+}
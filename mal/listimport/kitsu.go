@@ -0,0 +1,243 @@
+package listimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// kitsuExport is a JSON:API document of Kitsu library entries.
+type kitsuExport struct {
+	Data     []kitsuResource `json:"data"`
+	Included []kitsuResource `json:"included"`
+}
+
+type kitsuResource struct {
+	ID            string                       `json:"id"`
+	Type          string                       `json:"type"`
+	Attributes    json.RawMessage              `json:"attributes"`
+	Relationships map[string]kitsuRelationship `json:"relationships"`
+}
+
+// kitsuRelationship has the resource identifiers of a relationship, which
+// are an object for to-one and an array for to-many relationships.
+type kitsuRelationship struct {
+	Data json.RawMessage `json:"data"`
+}
+
+type kitsuRef struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+func (r kitsuRelationship) refs() []kitsuRef {
+	var many []kitsuRef
+	if err := json.Unmarshal(r.Data, &many); err == nil {
+		return many
+	}
+	var one *kitsuRef
+	if err := json.Unmarshal(r.Data, &one); err == nil && one != nil {
+		return []kitsuRef{*one}
+	}
+	return nil
+}
+
+type kitsuEntry struct {
+	Status         string   `json:"status"`
+	Progress       int      `json:"progress"`
+	Reconsuming    bool     `json:"reconsuming"`
+	ReconsumeCount int      `json:"reconsumeCount"`
+	RatingTwenty   *float64 `json:"ratingTwenty"`
+	Rating         string   `json:"rating"`
+	Notes          string   `json:"notes"`
+	StartedAt      string   `json:"startedAt"`
+	FinishedAt     string   `json:"finishedAt"`
+}
+
+type kitsuMedia struct {
+	CanonicalTitle string `json:"canonicalTitle"`
+}
+
+type kitsuMapping struct {
+	ExternalSite string `json:"externalSite"`
+	ExternalID   string `json:"externalId"`
+}
+
+// ReadKitsu reads a Kitsu library export from r. The export is a JSON:API
+// document of library entries as returned by the Kitsu API for
+// /library-entries?filter[userId]=<id>&include=anime,manga,anime.mappings,manga.mappings.
+// The included anime and manga give the titles of the entries and their
+// mappings give the MyAnimeList IDs.
+//
+// The ratings of Kitsu are converted from ratingTwenty, which goes from 2 to
+// 20, or from the older rating of 0.5 to 5 stars.
+func ReadKitsu(r io.Reader) (*List, error) {
+	var x kitsuExport
+	if err := json.NewDecoder(r).Decode(&x); err != nil {
+		return nil, fmt.Errorf("decoding Kitsu export: %w", err)
+	}
+
+	titles := make(map[kitsuRef]string)
+	malIDs := make(map[kitsuRef]int)
+	mappings := make(map[string]kitsuMapping)
+	for _, res := range x.Included {
+		if res.Type != "mappings" {
+			continue
+		}
+		var m kitsuMapping
+		if err := json.Unmarshal(res.Attributes, &m); err != nil {
+			return nil, fmt.Errorf("decoding Kitsu mapping %s: %w", res.ID, err)
+		}
+		mappings[res.ID] = m
+		for _, item := range res.Relationships["item"].refs() {
+			addMALID(malIDs, item, m)
+		}
+	}
+	for _, res := range x.Included {
+		if res.Type != KindAnime && res.Type != KindManga {
+			continue
+		}
+		ref := kitsuRef{ID: res.ID, Type: res.Type}
+		var m kitsuMedia
+		if err := json.Unmarshal(res.Attributes, &m); err != nil {
+			return nil, fmt.Errorf("decoding Kitsu %s %s: %w", res.Type, res.ID, err)
+		}
+		titles[ref] = m.CanonicalTitle
+		for _, mr := range res.Relationships["mappings"].refs() {
+			if m, ok := mappings[mr.ID]; ok {
+				addMALID(malIDs, ref, m)
+			}
+		}
+	}
+
+	list := &List{Source: Kitsu}
+	for _, res := range x.Data {
+		var e kitsuEntry
+		if err := json.Unmarshal(res.Attributes, &e); err != nil {
+			return nil, fmt.Errorf("decoding Kitsu library entry %s: %w", res.ID, err)
+		}
+		ref, ok := kitsuMediaRef(res)
+		if !ok {
+			return nil, fmt.Errorf("library entry %s of the Kitsu export has no anime or manga", res.ID)
+		}
+		id, _ := strconv.Atoi(ref.ID)
+		score, err := kitsuScore(e)
+		if err != nil {
+			return nil, fmt.Errorf("library entry %s of the Kitsu export: %w", res.ID, err)
+		}
+		if ref.Type == KindManga {
+			list.Manga = append(list.Manga, MangaEntry{
+				SourceID: id,
+				MALID:    malIDs[ref],
+				Title:    titles[ref],
+				Status: mal.MangaListStatus{
+					Status:          kitsuMangaStatus(e.Status),
+					IsRereading:     e.Reconsuming,
+					NumChaptersRead: e.Progress,
+					Score:           score,
+					NumTimesReread:  e.ReconsumeCount,
+					Comments:        e.Notes,
+					StartDate:       kitsuDate(e.StartedAt),
+					FinishDate:      kitsuDate(e.FinishedAt),
+				},
+			})
+			continue
+		}
+		list.Anime = append(list.Anime, AnimeEntry{
+			SourceID: id,
+			MALID:    malIDs[ref],
+			Title:    titles[ref],
+			Status: mal.AnimeListStatus{
+				Status:             kitsuAnimeStatus(e.Status),
+				Score:              score,
+				NumEpisodesWatched: e.Progress,
+				IsRewatching:       e.Reconsuming,
+				NumTimesRewatched:  e.ReconsumeCount,
+				Comments:           e.Notes,
+				StartDate:          kitsuDate(e.StartedAt),
+				FinishDate:         kitsuDate(e.FinishedAt),
+			},
+		})
+	}
+	return list, nil
+}
+
+// addMALID records the MyAnimeList ID of a mapping of media.
+func addMALID(malIDs map[kitsuRef]int, media kitsuRef, m kitsuMapping) {
+	if m.ExternalSite != "myanimelist/"+media.Type {
+		return
+	}
+	if id, err := strconv.Atoi(m.ExternalID); err == nil && id > 0 {
+		malIDs[media] = id
+	}
+}
+
+// kitsuMediaRef returns the anime or manga of a library entry. Newer
+// documents also have the polymorphic media relationship.
+func kitsuMediaRef(res kitsuResource) (kitsuRef, bool) {
+	for _, name := range []string{KindAnime, KindManga, "media"} {
+		for _, ref := range res.Relationships[name].refs() {
+			if ref.Type == KindAnime || ref.Type == KindManga {
+				return ref, true
+			}
+		}
+	}
+	return kitsuRef{}, false
+}
+
+func kitsuScore(e kitsuEntry) (int, error) {
+	if e.RatingTwenty != nil {
+		return clampScore(*e.RatingTwenty / 2), nil
+	}
+	if e.Rating == "" {
+		return 0, nil
+	}
+	stars, err := strconv.ParseFloat(e.Rating, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rating %q", e.Rating)
+	}
+	return scoreStars(stars), nil
+}
+
+// kitsuDate returns the day of a timestamp of Kitsu such as
+// "2020-01-02T00:00:00.000Z".
+func kitsuDate(s string) string {
+	if len(s) < len(dateLayout) {
+		return ""
+	}
+	if _, ok := parseDate(s[:len(dateLayout)]); !ok {
+		return ""
+	}
+	return s[:len(dateLayout)]
+}
+
+func kitsuAnimeStatus(s string) mal.AnimeStatus {
+	switch s {
+	case "current":
+		return mal.AnimeStatusWatching
+	case "completed":
+		return mal.AnimeStatusCompleted
+	case "on_hold":
+		return mal.AnimeStatusOnHold
+	case "dropped":
+		return mal.AnimeStatusDropped
+	}
+	return mal.AnimeStatusPlanToWatch
+}
+
+func kitsuMangaStatus(s string) mal.MangaStatus {
+	switch s {
+	case "current":
+		return mal.MangaStatusReading
+	case "completed":
+		return mal.MangaStatusCompleted
+	case "on_hold":
+		return mal.MangaStatusOnHold
+	case "dropped":
+		return mal.MangaStatusDropped
+	}
+	return mal.MangaStatusPlanToRead
+}
@@ -0,0 +1,77 @@
+package listimport
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func TestReadKitsu(t *testing.T) {
+	f, err := os.Open("testdata/kitsu.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := ReadKitsu(f)
+	if err != nil {
+		t.Fatalf("ReadKitsu returned error: %v", err)
+	}
+	want := &List{
+		Source: Kitsu,
+		Anime: []AnimeEntry{
+			{SourceID: 1, MALID: 1, Title: "Cowboy Bebop", Status: mal.AnimeListStatus{
+				Status: mal.AnimeStatusCompleted, Score: 9, NumEpisodesWatched: 26, NumTimesRewatched: 2,
+				StartDate: "2018-05-06", FinishDate: "2018-06-07",
+			}},
+			{SourceID: 7, Title: "Trigun", Status: mal.AnimeListStatus{
+				Status: mal.AnimeStatusWatching, Score: 7, NumEpisodesWatched: 3, IsRewatching: true,
+			}},
+		},
+		Manga: []MangaEntry{
+			{SourceID: 21, MALID: 2, Title: "Berserk", Status: mal.MangaListStatus{
+				Status: mal.MangaStatusOnHold, NumChaptersRead: 50,
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadKitsu\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestReadKitsuErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"data": `,
+		`{"data": [{"id": "1", "attributes": {"status": "current"}, "relationships": {"anime": {"data": null}}}]}`,
+		`{"data": [{"id": "1", "attributes": {"rating": "great"}, "relationships": {"anime": {"data": {"type": "anime", "id": "1"}}}}]}`,
+	} {
+		if _, err := ReadKitsu(strings.NewReader(data)); err == nil {
+			t.Errorf("ReadKitsu(%s) returned no error", data)
+		}
+	}
+}
+
+func TestScoreConversions(t *testing.T) {
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"100 point 0", score100(0), 0},
+		{"100 point 1", score100(1), 1},
+		{"100 point 74", score100(74), 7},
+		{"100 point 75", score100(75), 8},
+		{"100 point 100", score100(100), 10},
+		{"stars 0.5", scoreStars(0.5), 1},
+		{"stars 2.5", scoreStars(2.5), 5},
+		{"stars 5", scoreStars(5), 10},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+}
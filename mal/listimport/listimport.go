@@ -0,0 +1,391 @@
+// Package listimport imports the anime and manga lists that were exported
+// from AniList and Kitsu into MyAnimeList.
+//
+// An export is read into a List whose entries have the statuses, scores,
+// progress, rewatch counts and dates converted to the list statuses of
+// MyAnimeList. An Importer then resolves the entries to MyAnimeList IDs and
+// returns a Plan that can be previewed before it is applied:
+//
+//	list, err := listimport.ReadAniList(f)
+//	if err != nil {
+//		return err
+//	}
+//	imp := listimport.New(c)
+//	plan, err := imp.Plan(ctx, list)
+//	if err != nil {
+//		return err
+//	}
+//	plan.WriteText(os.Stdout)
+//	report, err := imp.Apply(ctx, plan)
+//
+// Entries are resolved by the MyAnimeList IDs that the export carries, then
+// by the IDMapper of the Importer and, if the Importer has a Resolver, by
+// their titles. Entries that cannot be resolved are listed in the Unmatched
+// of the plan.
+package listimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/resolve"
+)
+
+// The sites that lists are exported from. They are the site names of package
+// idmap.
+const (
+	AniList = "anilist"
+	Kitsu   = "kitsu"
+)
+
+// The kinds of entries.
+const (
+	KindAnime = "anime"
+	KindManga = "manga"
+)
+
+// List is an exported list.
+type List struct {
+	// Source is the site the list was exported from: AniList or Kitsu.
+	Source string
+	Anime  []AnimeEntry
+	Manga  []MangaEntry
+}
+
+// AnimeEntry is an anime entry of an exported list.
+type AnimeEntry struct {
+	// SourceID is the ID of the anime on the site of the export.
+	SourceID int
+	// MALID is the MyAnimeList ID of the anime if the export has it.
+	MALID int
+	Title string
+	// Status is the entry converted to MyAnimeList. UpdatedAt, Priority,
+	// RewatchValue and Tags are not imported.
+	Status mal.AnimeListStatus
+}
+
+// MangaEntry is a manga entry of an exported list.
+type MangaEntry struct {
+	// SourceID is the ID of the manga on the site of the export.
+	SourceID int
+	// MALID is the MyAnimeList ID of the manga if the export has it.
+	MALID int
+	Title string
+	// Status is the entry converted to MyAnimeList. UpdatedAt, Priority,
+	// RereadValue and Tags are not imported.
+	Status mal.MangaListStatus
+}
+
+// IDMapper maps the IDs of anime on other sites to MyAnimeList IDs. It is
+// satisfied by idmap.Map.
+type IDMapper interface {
+	AnimeID(site, id string) (malID int, ok bool)
+}
+
+// How the entries of a plan were resolved.
+const (
+	MatchedByID      = "id"
+	MatchedByMapping = "mapping"
+	MatchedByTitle   = "title"
+)
+
+// AnimeChange is an anime list update of a plan.
+type AnimeChange struct {
+	AnimeID int
+	// Entry is the entry of the export that the update is made from.
+	Entry AnimeEntry
+	// MatchedBy is how the entry was resolved: MatchedByID, MatchedByMapping
+	// or MatchedByTitle.
+	MatchedBy string
+	// Confidence is the confidence of the title match, or 1.
+	Confidence float64
+}
+
+// MangaChange is a manga list update of a plan.
+type MangaChange struct {
+	MangaID int
+	// Entry is the entry of the export that the update is made from.
+	Entry MangaEntry
+	// MatchedBy is how the entry was resolved: MatchedByID, MatchedByMapping
+	// or MatchedByTitle.
+	MatchedBy string
+	// Confidence is the confidence of the title match, or 1.
+	Confidence float64
+}
+
+// Unmatched is an entry that is not imported.
+type Unmatched struct {
+	// Kind is KindAnime or KindManga.
+	Kind     string
+	SourceID int
+	Title    string
+	Reason   string
+}
+
+// Plan is the list updates of an import.
+type Plan struct {
+	Anime     []AnimeChange
+	Manga     []MangaChange
+	Unmatched []Unmatched
+}
+
+// Importer resolves and imports exported lists.
+type Importer struct {
+	client *mal.Client
+
+	// IDs, if set, maps the anime IDs of the site of the export to
+	// MyAnimeList IDs.
+	IDs IDMapper
+	// Resolver, if set, resolves the entries without a MyAnimeList ID by
+	// their titles. Only matches above its threshold are imported.
+	Resolver *resolve.Resolver
+}
+
+// New returns an Importer that imports lists with c, which should be
+// authenticated as the user whose lists are updated.
+func New(c *mal.Client) *Importer {
+	return &Importer{client: c}
+}
+
+// Plan resolves the entries of list. Entries that resolve to an entry that
+// was already planned are unmatched so that an entry is updated once.
+func (imp *Importer) Plan(ctx context.Context, list *List) (*Plan, error) {
+	p := new(Plan)
+	seen := make(map[int]string)
+	for _, e := range list.Anime {
+		id, by, conf, reason, err := imp.resolveAnime(ctx, list.Source, e)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" && seen[id] != "" {
+			reason = fmt.Sprintf("same MyAnimeList anime as %q", seen[id])
+		}
+		if reason != "" {
+			p.Unmatched = append(p.Unmatched, Unmatched{Kind: KindAnime, SourceID: e.SourceID, Title: e.Title, Reason: reason})
+			continue
+		}
+		seen[id] = e.Title
+		p.Anime = append(p.Anime, AnimeChange{AnimeID: id, Entry: e, MatchedBy: by, Confidence: conf})
+	}
+	seen = make(map[int]string)
+	for _, e := range list.Manga {
+		id, by, conf, reason, err := imp.resolveManga(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" && seen[id] != "" {
+			reason = fmt.Sprintf("same MyAnimeList manga as %q", seen[id])
+		}
+		if reason != "" {
+			p.Unmatched = append(p.Unmatched, Unmatched{Kind: KindManga, SourceID: e.SourceID, Title: e.Title, Reason: reason})
+			continue
+		}
+		seen[id] = e.Title
+		p.Manga = append(p.Manga, MangaChange{MangaID: id, Entry: e, MatchedBy: by, Confidence: conf})
+	}
+	return p, nil
+}
+
+func (imp *Importer) resolveAnime(ctx context.Context, site string, e AnimeEntry) (id int, by string, conf float64, reason string, err error) {
+	if e.MALID > 0 {
+		return e.MALID, MatchedByID, 1, "", nil
+	}
+	if imp.IDs != nil && e.SourceID > 0 {
+		if id, ok := imp.IDs.AnimeID(site, strconv.Itoa(e.SourceID)); ok {
+			return id, MatchedByMapping, 1, "", nil
+		}
+	}
+	if imp.Resolver == nil {
+		return 0, "", 0, "no MyAnimeList ID", nil
+	}
+	m, ok, err := imp.Resolver.BestAnime(ctx, e.Title, resolve.Hints{})
+	return titleMatch(m.Anime.ID, m.Confidence, ok, e.Title, err)
+}
+
+func (imp *Importer) resolveManga(ctx context.Context, e MangaEntry) (id int, by string, conf float64, reason string, err error) {
+	if e.MALID > 0 {
+		return e.MALID, MatchedByID, 1, "", nil
+	}
+	if imp.Resolver == nil {
+		return 0, "", 0, "no MyAnimeList ID", nil
+	}
+	m, ok, err := imp.Resolver.BestManga(ctx, e.Title, resolve.Hints{})
+	return titleMatch(m.Manga.ID, m.Confidence, ok, e.Title, err)
+}
+
+func titleMatch(id int, conf float64, ok bool, title string, err error) (int, string, float64, string, error) {
+	switch {
+	case errors.Is(err, resolve.ErrShortTitle):
+		return 0, "", 0, "title is too short to search", nil
+	case err != nil:
+		return 0, "", 0, "", fmt.Errorf("resolving %q: %w", title, err)
+	case !ok:
+		return 0, "", 0, "no confident title match", nil
+	}
+	return id, MatchedByTitle, conf, "", nil
+}
+
+// Failure is an update of a plan that failed.
+type Failure struct {
+	// Kind is KindAnime or KindManga.
+	Kind  string
+	ID    int
+	Title string
+	Err   error
+}
+
+// Report is the outcome of applying a plan.
+type Report struct {
+	// Updated is the number of list entries that were updated.
+	Updated   int
+	Failed    []Failure
+	Unmatched []Unmatched
+}
+
+// Apply updates the lists with the changes of p one at a time. Only the
+// status and the fields of an entry that are set are updated, so the scores,
+// progress and rewatches of entries already in the list are kept when the
+// export does not have them. Failed updates are recorded in the report and do
+// not stop the import. It returns early with an error only if ctx is done.
+func (imp *Importer) Apply(ctx context.Context, p *Plan) (*Report, error) {
+	r := &Report{Unmatched: p.Unmatched}
+	for _, ch := range p.Anime {
+		if err := ctx.Err(); err != nil {
+			return r, err
+		}
+		if _, _, err := imp.client.Anime.UpdateMyListStatus(ctx, ch.AnimeID, animeOptions(ch.Entry.Status)...); err != nil {
+			r.Failed = append(r.Failed, Failure{Kind: KindAnime, ID: ch.AnimeID, Title: ch.Entry.Title, Err: err})
+			continue
+		}
+		r.Updated++
+	}
+	for _, ch := range p.Manga {
+		if err := ctx.Err(); err != nil {
+			return r, err
+		}
+		if _, _, err := imp.client.Manga.UpdateMyListStatus(ctx, ch.MangaID, mangaOptions(ch.Entry.Status)...); err != nil {
+			r.Failed = append(r.Failed, Failure{Kind: KindManga, ID: ch.MangaID, Title: ch.Entry.Title, Err: err})
+			continue
+		}
+		r.Updated++
+	}
+	return r, nil
+}
+
+// animeOptions returns the options that update a list entry to st. Only the
+// status and the fields that the export has are sent, so that importing onto
+// an entry that is already in the list does not reset its score, progress or
+// rewatches with the zero values of an export that does not have them.
+func animeOptions(st mal.AnimeListStatus) []mal.UpdateMyAnimeListStatusOption {
+	opts := []mal.UpdateMyAnimeListStatusOption{st.Status}
+	if st.Score > 0 {
+		opts = append(opts, mal.Score(st.Score))
+	}
+	if st.NumEpisodesWatched > 0 {
+		opts = append(opts, mal.NumEpisodesWatched(st.NumEpisodesWatched))
+	}
+	if st.IsRewatching {
+		opts = append(opts, mal.IsRewatching(true))
+	}
+	if st.NumTimesRewatched > 0 {
+		opts = append(opts, mal.NumTimesRewatched(st.NumTimesRewatched))
+	}
+	if d, ok := parseDate(st.StartDate); ok {
+		opts = append(opts, mal.StartDate(d))
+	}
+	if d, ok := parseDate(st.FinishDate); ok {
+		opts = append(opts, mal.FinishDate(d))
+	}
+	if st.Comments != "" {
+		opts = append(opts, mal.Comments(st.Comments))
+	}
+	return opts
+}
+
+// mangaOptions is the same as animeOptions for manga.
+func mangaOptions(st mal.MangaListStatus) []mal.UpdateMyMangaListStatusOption {
+	opts := []mal.UpdateMyMangaListStatusOption{st.Status}
+	if st.Score > 0 {
+		opts = append(opts, mal.Score(st.Score))
+	}
+	if st.NumChaptersRead > 0 {
+		opts = append(opts, mal.NumChaptersRead(st.NumChaptersRead))
+	}
+	if st.NumVolumesRead > 0 {
+		opts = append(opts, mal.NumVolumesRead(st.NumVolumesRead))
+	}
+	if st.IsRereading {
+		opts = append(opts, mal.IsRereading(true))
+	}
+	if st.NumTimesReread > 0 {
+		opts = append(opts, mal.NumTimesReread(st.NumTimesReread))
+	}
+	if d, ok := parseDate(st.StartDate); ok {
+		opts = append(opts, mal.StartDate(d))
+	}
+	if d, ok := parseDate(st.FinishDate); ok {
+		opts = append(opts, mal.FinishDate(d))
+	}
+	if st.Comments != "" {
+		opts = append(opts, mal.Comments(st.Comments))
+	}
+	return opts
+}
+
+const dateLayout = "2006-01-02"
+
+func parseDate(s string) (time.Time, bool) {
+	d, err := time.Parse(dateLayout, s)
+	return d, err == nil
+}
+
+// The conversions of scores to the 0 to 10 scores of MyAnimeList, where 0 is
+// no score. Scores that round to 0 become 1 so that they stay scored.
+func score100(s float64) int   { return clampScore(s / 10) }
+func scoreStars(s float64) int { return clampScore(s * 2) }
+
+func clampScore(s float64) int {
+	if s <= 0 {
+		return 0
+	}
+	n := int(math.Round(s))
+	if n < 1 {
+		return 1
+	}
+	if n > 10 {
+		return 10
+	}
+	return n
+}
+
+// WriteText writes a preview of p as a table.
+func (p *Plan) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tID\tTITLE\tSTATUS\tSCORE\tPROGRESS\tMATCH")
+	for _, ch := range p.Anime {
+		st := ch.Entry.Status
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%d\t%s\n", KindAnime, ch.AnimeID, ch.Entry.Title, st.Status, st.Score, st.NumEpisodesWatched, matchText(ch.MatchedBy, ch.Confidence))
+	}
+	for _, ch := range p.Manga {
+		st := ch.Entry.Status
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%d\t%s\n", KindManga, ch.MangaID, ch.Entry.Title, st.Status, st.Score, st.NumChaptersRead, matchText(ch.MatchedBy, ch.Confidence))
+	}
+	for _, u := range p.Unmatched {
+		fmt.Fprintf(tw, "%s\t-\t%s\tunmatched\t\t\t%s\n", u.Kind, u.Title, u.Reason)
+	}
+	return tw.Flush()
+}
+
+func matchText(by string, conf float64) string {
+	if by == MatchedByTitle {
+		return fmt.Sprintf("%s %.2f", by, conf)
+	}
+	return by
+}
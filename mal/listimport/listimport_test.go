@@ -0,0 +1,203 @@
+package listimport
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/maltest"
+	"github.com/nstratos/go-myanimelist/mal/resolve"
+)
+
+func newTestServer(t *testing.T) *maltest.Server {
+	t.Helper()
+	srv := maltest.NewServer(&maltest.Fixtures{
+		Me: "foo",
+		Anime: []mal.Anime{
+			{ID: 1, Title: "Cowboy Bebop"},
+			{ID: 6, Title: "Trigun"},
+			{ID: 20, Title: "Naruto"},
+		},
+		Manga: []mal.Manga{
+			{ID: 2, Title: "Berserk"},
+			{ID: 13, Title: "One Piece"},
+		},
+		Users: []maltest.User{{User: mal.User{Name: "foo"}}},
+	})
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type mapIDs map[string]int
+
+func (m mapIDs) AnimeID(site, id string) (int, bool) {
+	malID, ok := m[site+":"+id]
+	return malID, ok
+}
+
+var testList = &List{
+	Source: AniList,
+	Anime: []AnimeEntry{
+		{SourceID: 1, MALID: 1, Title: "Cowboy Bebop", Status: mal.AnimeListStatus{
+			Status: mal.AnimeStatusCompleted, Score: 9, NumEpisodesWatched: 26, NumTimesRewatched: 1,
+			Comments: "classic", StartDate: "2019-01-02", FinishDate: "2019-02-03",
+		}},
+		{SourceID: 20, Title: "Naruto", Status: mal.AnimeListStatus{Status: mal.AnimeStatusDropped, NumEpisodesWatched: 30}},
+		{SourceID: 6, Title: "Trigun", Status: mal.AnimeListStatus{Status: mal.AnimeStatusWatching, NumEpisodesWatched: 4}},
+		{SourceID: 99, Title: "Cowboy Bebop", Status: mal.AnimeListStatus{Status: mal.AnimeStatusPlanToWatch}},
+		{SourceID: 98, Title: "Gone", Status: mal.AnimeListStatus{Status: mal.AnimeStatusPlanToWatch}},
+		{SourceID: 97, Title: "K", Status: mal.AnimeListStatus{Status: mal.AnimeStatusPlanToWatch}},
+	},
+	Manga: []MangaEntry{
+		{SourceID: 30013, MALID: 13, Title: "One Piece", Status: mal.MangaListStatus{
+			Status: mal.MangaStatusReading, NumChaptersRead: 120, NumVolumesRead: 12,
+		}},
+		{SourceID: 30002, Title: "Berserk", Status: mal.MangaListStatus{Status: mal.MangaStatusPlanToRead, Score: 10}},
+		{SourceID: 30404, MALID: 404, Title: "Missing", Status: mal.MangaListStatus{Status: mal.MangaStatusPlanToRead}},
+	},
+}
+
+func TestPlan(t *testing.T) {
+	srv := newTestServer(t)
+	imp := New(srv.Client())
+	imp.IDs = mapIDs{"anilist:20": 20}
+	imp.Resolver = resolve.New(srv.Client())
+
+	p, err := imp.Plan(context.Background(), testList)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	var anime []int
+	var matched []string
+	for _, ch := range p.Anime {
+		anime = append(anime, ch.AnimeID)
+		matched = append(matched, ch.MatchedBy)
+	}
+	if want := []int{1, 20, 6}; !reflect.DeepEqual(anime, want) {
+		t.Errorf("Plan anime = %v, want %v", anime, want)
+	}
+	if want := []string{MatchedByID, MatchedByMapping, MatchedByTitle}; !reflect.DeepEqual(matched, want) {
+		t.Errorf("Plan matched anime by %v, want %v", matched, want)
+	}
+	var manga []int
+	for _, ch := range p.Manga {
+		manga = append(manga, ch.MangaID)
+	}
+	if want := []int{13, 2, 404}; !reflect.DeepEqual(manga, want) {
+		t.Errorf("Plan manga = %v, want %v", manga, want)
+	}
+	want := []Unmatched{
+		{Kind: KindAnime, SourceID: 99, Title: "Cowboy Bebop", Reason: `same MyAnimeList anime as "Cowboy Bebop"`},
+		{Kind: KindAnime, SourceID: 98, Title: "Gone", Reason: "no confident title match"},
+		{Kind: KindAnime, SourceID: 97, Title: "K", Reason: "title is too short to search"},
+	}
+	if !reflect.DeepEqual(p.Unmatched, want) {
+		t.Errorf("Plan unmatched\nhave: %+v\nwant: %+v", p.Unmatched, want)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 10 || !strings.HasPrefix(lines[0], "KIND") || !strings.Contains(lines[3], "title 1.00") {
+		t.Errorf("WriteText wrote:\n%s", buf.String())
+	}
+}
+
+func TestPlanWithoutResolver(t *testing.T) {
+	srv := newTestServer(t)
+	p, err := New(srv.Client()).Plan(context.Background(), testList)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	if len(p.Anime) != 1 || len(p.Manga) != 2 || len(p.Unmatched) != 6 {
+		t.Fatalf("Plan = %+v, want 1 anime, 2 manga and 6 unmatched", p)
+	}
+	if u := p.Unmatched[0]; u.Title != "Naruto" || u.Reason != "no MyAnimeList ID" {
+		t.Errorf("Plan unmatched %+v, want Naruto without an ID", u)
+	}
+}
+
+func TestApply(t *testing.T) {
+	srv := newTestServer(t)
+	imp := New(srv.Client())
+	imp.IDs = mapIDs{"anilist:20": 20}
+	p, err := imp.Plan(context.Background(), testList)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	r, err := imp.Apply(context.Background(), p)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if r.Updated != 3 || len(r.Failed) != 1 || len(r.Unmatched) != len(p.Unmatched) {
+		t.Fatalf("Apply report = %+v, want 3 updated, 1 failed and the unmatched of the plan", r)
+	}
+	if f := r.Failed[0]; f.Kind != KindManga || f.ID != 404 || f.Err == nil {
+		t.Errorf("Apply failure = %+v, want manga 404", f)
+	}
+
+	anime := srv.AnimeList("foo")
+	if len(anime) != 2 {
+		t.Fatalf("anime list has %d entries, want 2", len(anime))
+	}
+	st := anime[0].Status
+	want := testList.Anime[0].Status
+	want.UpdatedAt, want.Tags = st.UpdatedAt, []string{}
+	if anime[0].Anime.ID != 1 || !reflect.DeepEqual(st, want) {
+		t.Errorf("anime list status of Cowboy Bebop\nhave: %+v\nwant: %+v", st, want)
+	}
+	manga := srv.MangaList("foo")
+	if len(manga) != 1 || manga[0].Status.NumVolumesRead != 12 || manga[0].Status.NumChaptersRead != 120 {
+		t.Errorf("manga list = %+v, want One Piece imported", manga)
+	}
+}
+
+func TestApplyCanceled(t *testing.T) {
+	srv := newTestServer(t)
+	imp := New(srv.Client())
+	p, err := imp.Plan(context.Background(), testList)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := imp.Apply(ctx, p); err != context.Canceled {
+		t.Errorf("Apply with canceled context returned error %v, want %v", err, context.Canceled)
+	}
+	if n := len(srv.AnimeList("foo")); n != 0 {
+		t.Errorf("anime list has %d entries after a canceled import, want 0", n)
+	}
+}
+
+func TestApplyKeepsExistingFields(t *testing.T) {
+	srv := maltest.NewServer(&maltest.Fixtures{
+		Me:    "foo",
+		Anime: []mal.Anime{{ID: 6, Title: "Trigun"}},
+		Users: []maltest.User{{
+			User: mal.User{Name: "foo"},
+			AnimeList: []mal.UserAnime{{
+				Anime:  mal.Anime{ID: 6},
+				Status: mal.AnimeListStatus{Status: mal.AnimeStatusCompleted, Score: 8, NumEpisodesWatched: 26, NumTimesRewatched: 2},
+			}},
+		}},
+	})
+	defer srv.Close()
+
+	p := &Plan{Anime: []AnimeChange{{
+		AnimeID: 6,
+		Entry:   AnimeEntry{Title: "Trigun", Status: mal.AnimeListStatus{Status: mal.AnimeStatusWatching, NumEpisodesWatched: 4}},
+	}}}
+	if _, err := New(srv.Client()).Apply(context.Background(), p); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	st := srv.AnimeList("foo")[0].Status
+	if st.Status != mal.AnimeStatusWatching || st.NumEpisodesWatched != 4 || st.Score != 8 || st.NumTimesRewatched != 2 {
+		t.Errorf("anime list status of Trigun = %+v, want the imported status and progress with the score and rewatches kept", st)
+	}
+}
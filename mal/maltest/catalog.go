@@ -0,0 +1,304 @@
+package maltest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func (s *Server) findAnime(id int) (mal.Anime, bool) {
+	for _, a := range s.anime {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return mal.Anime{}, false
+}
+
+func (s *Server) findManga(id int) (mal.Manga, bool) {
+	for _, m := range s.manga {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return mal.Manga{}, false
+}
+
+// animeNode returns the JSON object of an anime with the requested fields.
+// The my_list_status field is the status of the anime in the list of the
+// authenticated user and it is omitted when the anime is not in the list.
+func (s *Server) animeNode(a mal.Anime, fields fieldSet) map[string]interface{} {
+	node := selectFields(a, fields.without("my_list_status"), nodeDefaults)
+	if fields.has("my_list_status") && s.me != "" {
+		if u := s.user(s.me); u != nil {
+			if i := animeIndex(u, a.ID); i >= 0 {
+				node["my_list_status"] = selectFields(u.AnimeList[i].Status, fields["my_list_status"], animeListStatusDefaults)
+			}
+		}
+	}
+	return node
+}
+
+// mangaNode returns the JSON object of a manga with the requested fields like
+// animeNode.
+func (s *Server) mangaNode(m mal.Manga, fields fieldSet) map[string]interface{} {
+	node := selectFields(m, fields.without("my_list_status"), nodeDefaults)
+	if fields.has("my_list_status") && s.me != "" {
+		if u := s.user(s.me); u != nil {
+			if i := mangaIndex(u, m.ID); i >= 0 {
+				node["my_list_status"] = selectFields(u.MangaList[i].Status, fields["my_list_status"], mangaListStatusDefaults)
+			}
+		}
+	}
+	return node
+}
+
+// showNSFW reports whether the request asks for anime and manga that are not
+// safe for work, which are otherwise excluded from lists.
+func showNSFW(r *http.Request) bool {
+	show, _ := strconv.ParseBool(r.URL.Query().Get("nsfw"))
+	return show
+}
+
+func titleMatches(q string, title string, alt mal.Titles) bool {
+	q = strings.ToLower(q)
+	for _, t := range append([]string{title, alt.En, alt.Ja}, alt.Synonyms...) {
+		if t != "" && strings.Contains(strings.ToLower(t), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchQuery returns the q parameter of a search or writes an error.
+func searchQuery(w http.ResponseWriter, r *http.Request) (string, bool) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len(q) < 3 {
+		badRequest(w, "invalid q")
+		return "", false
+	}
+	return q, true
+}
+
+// writeAnime writes a page of anime. If extra is not nil, it is called to add
+// fields to the item of the anime with the given index in list.
+func (s *Server) writeAnime(w http.ResponseWriter, r *http.Request, list []mal.Anime, defaultLimit, maxLimit int, extra func(i int, item map[string]interface{})) {
+	from, to, paging, ok := s.page(w, r, len(list), defaultLimit, maxLimit)
+	if !ok {
+		return
+	}
+	fields := parseFields(r.URL.Query().Get("fields"))
+	var data []interface{}
+	for i := from; i < to; i++ {
+		item := map[string]interface{}{"node": s.animeNode(list[i], fields)}
+		if extra != nil {
+			extra(i, item)
+		}
+		data = append(data, item)
+	}
+	writeJSON(w, listResponse(data, paging))
+}
+
+// writeManga writes a page of manga like writeAnime.
+func (s *Server) writeManga(w http.ResponseWriter, r *http.Request, list []mal.Manga, defaultLimit, maxLimit int, extra func(i int, item map[string]interface{})) {
+	from, to, paging, ok := s.page(w, r, len(list), defaultLimit, maxLimit)
+	if !ok {
+		return
+	}
+	fields := parseFields(r.URL.Query().Get("fields"))
+	var data []interface{}
+	for i := from; i < to; i++ {
+		item := map[string]interface{}{"node": s.mangaNode(list[i], fields)}
+		if extra != nil {
+			extra(i, item)
+		}
+		data = append(data, item)
+	}
+	writeJSON(w, listResponse(data, paging))
+}
+
+func rankingExtra(i int, item map[string]interface{}) {
+	item["ranking"] = map[string]int{"rank": i + 1}
+}
+
+func (s *Server) animeDetails(w http.ResponseWriter, r *http.Request, id int) {
+	a, ok := s.findAnime(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "")
+		return
+	}
+	writeJSON(w, s.animeNode(a, parseFields(r.URL.Query().Get("fields"))))
+}
+
+func (s *Server) animeSearch(w http.ResponseWriter, r *http.Request) {
+	q, ok := searchQuery(w, r)
+	if !ok {
+		return
+	}
+	var found []mal.Anime
+	for _, a := range s.anime {
+		if (showNSFW(r) || a.NSFW != "black") && titleMatches(q, a.Title, a.AlternativeTitles) {
+			found = append(found, a)
+		}
+	}
+	s.writeAnime(w, r, found, 100, 100, nil)
+}
+
+func (s *Server) animeRanking(w http.ResponseWriter, r *http.Request) {
+	rankingType := r.URL.Query().Get("ranking_type")
+	byPopularity := rankingType == "bypopularity" || rankingType == "favorite"
+	var keep func(a mal.Anime) bool
+	switch rankingType {
+	case "all", "bypopularity", "favorite":
+		keep = func(a mal.Anime) bool { return true }
+	case "airing":
+		keep = func(a mal.Anime) bool { return a.Status == "currently_airing" }
+	case "upcoming":
+		keep = func(a mal.Anime) bool { return a.Status == "not_yet_aired" }
+	case "tv", "ova", "movie", "special":
+		keep = func(a mal.Anime) bool { return a.MediaType == rankingType }
+	default:
+		badRequest(w, "invalid ranking_type")
+		return
+	}
+	var ranked []mal.Anime
+	for _, a := range s.anime {
+		rank := a.Rank
+		if byPopularity {
+			rank = a.Popularity
+		}
+		if rank > 0 && keep(a) {
+			ranked = append(ranked, a)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if byPopularity {
+			return ranked[i].Popularity < ranked[j].Popularity
+		}
+		return ranked[i].Rank < ranked[j].Rank
+	})
+	s.writeAnime(w, r, ranked, 100, 500, rankingExtra)
+}
+
+func (s *Server) animeSeasonal(year, season string) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		y, err := strconv.Atoi(year)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "not_found", "")
+			return
+		}
+		switch mal.AnimeSeason(season) {
+		case mal.AnimeSeasonWinter, mal.AnimeSeasonSpring, mal.AnimeSeasonSummer, mal.AnimeSeasonFall:
+		default:
+			writeError(w, http.StatusNotFound, "not_found", "")
+			return
+		}
+		var found []mal.Anime
+		for _, a := range s.anime {
+			if a.StartSeason.Year == y && a.StartSeason.Season == season && (showNSFW(r) || a.NSFW != "black") {
+				found = append(found, a)
+			}
+		}
+		switch mal.SortSeasonalAnime(r.URL.Query().Get("sort")) {
+		case "":
+		case mal.SortSeasonalByAnimeScore:
+			sort.SliceStable(found, func(i, j int) bool { return found[i].Mean > found[j].Mean })
+		case mal.SortSeasonalByAnimeNumListUsers:
+			sort.SliceStable(found, func(i, j int) bool { return found[i].NumListUsers > found[j].NumListUsers })
+		default:
+			badRequest(w, "invalid sort")
+			return
+		}
+		from, to, paging, ok := s.page(w, r, len(found), 100, 500)
+		if !ok {
+			return
+		}
+		fields := parseFields(r.URL.Query().Get("fields"))
+		var data []interface{}
+		for _, a := range found[from:to] {
+			data = append(data, map[string]interface{}{"node": s.animeNode(a, fields)})
+		}
+		resp := listResponse(data, paging)
+		resp["season"] = mal.StartSeason{Year: y, Season: season}
+		writeJSON(w, resp)
+	}
+}
+
+func (s *Server) animeSuggestions(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.meUser(w)
+	if !ok {
+		return
+	}
+	var suggested []mal.Anime
+	for _, a := range s.anime {
+		if animeIndex(u, a.ID) < 0 && a.NSFW != "black" {
+			suggested = append(suggested, a)
+		}
+	}
+	s.writeAnime(w, r, suggested, 100, 100, nil)
+}
+
+func (s *Server) mangaDetails(w http.ResponseWriter, r *http.Request, id int) {
+	m, ok := s.findManga(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "")
+		return
+	}
+	writeJSON(w, s.mangaNode(m, parseFields(r.URL.Query().Get("fields"))))
+}
+
+func (s *Server) mangaSearch(w http.ResponseWriter, r *http.Request) {
+	q, ok := searchQuery(w, r)
+	if !ok {
+		return
+	}
+	var found []mal.Manga
+	for _, m := range s.manga {
+		if (showNSFW(r) || m.Nsfw != "black") && titleMatches(q, m.Title, m.AlternativeTitles) {
+			found = append(found, m)
+		}
+	}
+	s.writeManga(w, r, found, 100, 100, nil)
+}
+
+// mangaRankingTypes maps the manga ranking types to the media type of the
+// manga they rank.
+var mangaRankingTypes = map[string]string{
+	"manga":       "manga",
+	"oneshots":    "one_shot",
+	"doujin":      "doujinshi",
+	"lightnovels": "light_novel",
+	"novels":      "novel",
+	"manhwa":      "manhwa",
+	"manhua":      "manhua",
+}
+
+func (s *Server) mangaRanking(w http.ResponseWriter, r *http.Request) {
+	rankingType := r.URL.Query().Get("ranking_type")
+	byPopularity := rankingType == "bypopularity" || rankingType == "favorite"
+	mediaType, ok := mangaRankingTypes[rankingType]
+	if !ok && rankingType != "all" && !byPopularity {
+		badRequest(w, "invalid ranking_type")
+		return
+	}
+	var ranked []mal.Manga
+	for _, m := range s.manga {
+		rank := m.Rank
+		if byPopularity {
+			rank = m.Popularity
+		}
+		if rank > 0 && (mediaType == "" || m.MediaType == mediaType) {
+			ranked = append(ranked, m)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if byPopularity {
+			return ranked[i].Popularity < ranked[j].Popularity
+		}
+		return ranked[i].Rank < ranked[j].Rank
+	})
+	s.writeManga(w, r, ranked, 100, 500, rankingExtra)
+}
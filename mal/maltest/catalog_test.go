@@ -0,0 +1,140 @@
+package maltest
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func animeIDs(list []mal.Anime) []int {
+	ids := make([]int, len(list))
+	for i, a := range list {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func TestAnimeDetailsFields(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	a, _, err := client.Anime.Details(ctx, 1)
+	if err != nil {
+		t.Fatalf("Anime.Details returned error: %v", err)
+	}
+	if want := (&mal.Anime{ID: 1, Title: "Cowboy Bebop"}); !reflect.DeepEqual(a, want) {
+		t.Errorf("Anime.Details without fields returned\nhave: %+v\nwant: %+v", a, want)
+	}
+
+	a, _, err = client.Anime.Details(ctx, 1, mal.Fields{"num_episodes", "my_list_status{tags}"})
+	if err != nil {
+		t.Fatalf("Anime.Details returned error: %v", err)
+	}
+	if a.NumEpisodes != 26 || a.Mean != 0 {
+		t.Errorf("Anime.Details returned num_episodes %d, mean %v, want 26 and no mean", a.NumEpisodes, a.Mean)
+	}
+	if got, want := a.MyListStatus.Tags, []string{"space"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Anime.Details my_list_status tags = %v, want %v", got, want)
+	}
+	if a.MyListStatus.Status != mal.AnimeStatusCompleted {
+		t.Errorf("Anime.Details my_list_status status = %q, want the default fields too", a.MyListStatus.Status)
+	}
+
+	m, _, err := client.Manga.Details(ctx, 2, mal.Fields{"num_chapters"})
+	if err != nil {
+		t.Fatalf("Manga.Details returned error: %v", err)
+	}
+	if m.NumChapters != 370 || m.Rank != 0 {
+		t.Errorf("Manga.Details returned num_chapters %d, rank %d, want 370 and no rank", m.NumChapters, m.Rank)
+	}
+}
+
+func TestAnimeSearch(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	list, _, err := client.Anime.List(ctx, "the movie")
+	if err != nil {
+		t.Fatalf("Anime.List returned error: %v", err)
+	}
+	if got, want := animeIDs(list), []int{5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Anime.List matched %v, want %v", got, want)
+	}
+
+	_, _, err = client.Anime.List(ctx, "ab")
+	testErrorStatus(t, err, http.StatusBadRequest, "bad_request")
+
+	manga, _, err := client.Manga.List(ctx, "DEATH")
+	if err != nil {
+		t.Fatalf("Manga.List returned error: %v", err)
+	}
+	if len(manga) != 1 || manga[0].ID != 21 {
+		t.Errorf("Manga.List returned %+v, want Death Note", manga)
+	}
+}
+
+func TestAnimeRanking(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		ranking mal.AnimeRanking
+		want    []int
+	}{
+		{mal.AnimeRankingAll, []int{1, 5, 6}},
+		{mal.AnimeRankingTV, []int{1, 6}},
+		{mal.AnimeRankingMovie, []int{5}},
+		{mal.AnimeRankingByPopularity, []int{1, 6, 5}},
+		{mal.AnimeRankingUpcoming, []int{}},
+	}
+	for _, tt := range tests {
+		list, _, err := client.Anime.Ranking(ctx, tt.ranking)
+		if err != nil {
+			t.Fatalf("Anime.Ranking(%q) returned error: %v", tt.ranking, err)
+		}
+		if got := animeIDs(list); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Anime.Ranking(%q) = %v, want %v", tt.ranking, got, tt.want)
+		}
+	}
+	_, _, err := client.Anime.Ranking(ctx, "best")
+	testErrorStatus(t, err, http.StatusBadRequest, "bad_request")
+
+	manga, _, err := client.Manga.Ranking(ctx, mal.MangaRankingByPopularity)
+	if err != nil {
+		t.Fatalf("Manga.Ranking returned error: %v", err)
+	}
+	if len(manga) != 2 || manga[0].ID != 21 {
+		t.Errorf("Manga.Ranking by popularity returned %+v, want Death Note first", manga)
+	}
+}
+
+func TestAnimeSeasonal(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	list, _, err := client.Anime.Seasonal(ctx, 1998, mal.AnimeSeasonSpring, mal.SortSeasonalByAnimeNumListUsers)
+	if err != nil {
+		t.Fatalf("Anime.Seasonal returned error: %v", err)
+	}
+	if got, want := animeIDs(list), []int{1, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Anime.Seasonal = %v, want %v", got, want)
+	}
+	_, _, err = client.Anime.Seasonal(ctx, 1998, "monsoon")
+	testErrorStatus(t, err, http.StatusNotFound, "not_found")
+}
+
+func TestAnimeSuggested(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	list, _, err := client.Anime.Suggested(ctx)
+	if err != nil {
+		t.Fatalf("Anime.Suggested returned error: %v", err)
+	}
+	if got, want := animeIDs(list), []int{5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Anime.Suggested = %v, want the anime that are not in the list %v", got, want)
+	}
+}
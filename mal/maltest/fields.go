@@ -0,0 +1,143 @@
+package maltest
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// fieldSet is a parsed fields parameter such as
+// "synopsis,my_list_status{priority,comments}". Fields without subfields map
+// to nil.
+type fieldSet map[string]fieldSet
+
+// parseFields parses the value of the fields parameter.
+func parseFields(s string) fieldSet {
+	fs, _ := parseFieldList(s)
+	return fs
+}
+
+// parseFieldList parses fields up to the end of s or to the '}' that closes
+// the current list and returns the rest of s after it.
+func parseFieldList(s string) (fieldSet, string) {
+	fs := make(fieldSet)
+	for {
+		i := strings.IndexAny(s, ",{}")
+		if i < 0 {
+			fs.add(s, nil)
+			return fs, ""
+		}
+		name := s[:i]
+		switch s[i] {
+		case ',':
+			fs.add(name, nil)
+			s = s[i+1:]
+		case '}':
+			fs.add(name, nil)
+			return fs, s[i+1:]
+		case '{':
+			var sub fieldSet
+			sub, s = parseFieldList(s[i+1:])
+			fs.add(name, sub)
+			s = strings.TrimPrefix(s, ",")
+		}
+		if s == "" {
+			return fs, ""
+		}
+	}
+}
+
+func (fs fieldSet) add(name string, sub fieldSet) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+	if sub == nil {
+		if _, ok := fs[name]; ok {
+			return
+		}
+	}
+	fs[name] = sub
+}
+
+// has reports whether the field was requested.
+func (fs fieldSet) has(name string) bool {
+	_, ok := fs[name]
+	return ok
+}
+
+// without returns the fields without name.
+func (fs fieldSet) without(name string) fieldSet {
+	c := make(fieldSet, len(fs))
+	for k, v := range fs {
+		if k != name {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+// The fields that the API returns when they are not requested.
+var (
+	nodeDefaults            = []string{"id", "title", "main_picture"}
+	animeListStatusDefaults = []string{"status", "score", "num_episodes_watched", "is_rewatching", "updated_at", "start_date", "finish_date"}
+	mangaListStatusDefaults = []string{"status", "is_rereading", "num_volumes_read", "num_chapters_read", "score", "updated_at", "start_date", "finish_date"}
+	userDefaults            = []string{"id", "name", "picture", "gender", "birthday", "location", "joined_at"}
+)
+
+// toObject converts v to its JSON object representation.
+func toObject(v interface{}) map[string]interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic("maltest: " + err.Error())
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		panic("maltest: " + err.Error())
+	}
+	return obj
+}
+
+// selectFields returns the JSON object of v with only the default and the
+// requested fields. The subfields of requested objects and arrays of objects
+// are selected recursively when they are given.
+func selectFields(v interface{}, fields fieldSet, defaults []string) map[string]interface{} {
+	return selectObject(toObject(v), fields, defaults)
+}
+
+func selectObject(obj map[string]interface{}, fields fieldSet, defaults []string) map[string]interface{} {
+	out := make(map[string]interface{})
+	for _, k := range defaults {
+		if v, ok := obj[k]; ok {
+			out[k] = v
+		}
+	}
+	for k, sub := range fields {
+		v, ok := obj[k]
+		if !ok {
+			continue
+		}
+		if sub != nil {
+			var subDefaults []string
+			if k == "node" {
+				subDefaults = nodeDefaults
+			}
+			v = selectValue(v, sub, subDefaults)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func selectValue(v interface{}, fields fieldSet, defaults []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return selectObject(v, fields, defaults)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i := range v {
+			out[i] = selectValue(v[i], fields, defaults)
+		}
+		return out
+	}
+	return v
+}
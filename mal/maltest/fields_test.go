@@ -0,0 +1,91 @@
+package maltest
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		in   string
+		want fieldSet
+	}{
+		{"", fieldSet{}},
+		{"synopsis", fieldSet{"synopsis": nil}},
+		{"synopsis, mean ,", fieldSet{"synopsis": nil, "mean": nil}},
+		{"my_list_status{priority,comments},num_episodes", fieldSet{
+			"my_list_status": {"priority": nil, "comments": nil},
+			"num_episodes":   nil,
+		}},
+		{"related_anime{node{num_episodes},relation_type}", fieldSet{
+			"related_anime": {"node": {"num_episodes": nil}, "relation_type": nil},
+		}},
+		{"list_status{tags", fieldSet{"list_status": {"tags": nil}}},
+	}
+	for _, tt := range tests {
+		if got := parseFields(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFields(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	v := map[string]interface{}{
+		"id":    1,
+		"title": "foo",
+		"mean":  8.5,
+		"related_anime": []map[string]interface{}{
+			{"node": map[string]interface{}{"id": 2, "title": "bar", "mean": 7.0}, "relation_type": "sequel"},
+		},
+	}
+	got := selectFields(v, parseFields("related_anime{node{mean}},unknown"), []string{"id", "title"})
+	want := map[string]interface{}{
+		"id":    1.0,
+		"title": "foo",
+		"related_anime": []interface{}{
+			map[string]interface{}{"node": map[string]interface{}{"id": 2.0, "title": "bar", "mean": 7.0}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectFields returned\nhave: %v\nwant: %v", got, want)
+	}
+}
+
+func TestListStatusDefaults(t *testing.T) {
+	// The list status of an entry that sets every field, as the API returns
+	// it for fields=list_status, includes the start and finish dates but not
+	// the fields that must be requested by name.
+	st := mal.AnimeListStatus{
+		Status: mal.AnimeStatusCompleted, Score: 9, NumEpisodesWatched: 26, IsRewatching: true,
+		UpdatedAt: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), StartDate: "2020-01-01", FinishDate: "2020-02-01",
+		Priority: 2, NumTimesRewatched: 1, RewatchValue: 3, Tags: []string{"space"}, Comments: "classic",
+	}
+	var got []string
+	for k := range selectFields(st, fieldSet{}, animeListStatusDefaults) {
+		got = append(got, k)
+	}
+	sort.Strings(got)
+	want := []string{"finish_date", "is_rewatching", "num_episodes_watched", "score", "start_date", "status", "updated_at"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("default anime list status fields = %v, want %v", got, want)
+	}
+
+	ms := mal.MangaListStatus{
+		Status: mal.MangaStatusReading, Score: 8, NumVolumesRead: 2, NumChaptersRead: 20,
+		UpdatedAt: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), StartDate: "2020-01-01", FinishDate: "2020-02-01",
+		Priority: 1, NumTimesReread: 1, RereadValue: 2, Tags: []string{"dark"}, Comments: "grim",
+	}
+	got = got[:0]
+	for k := range selectFields(ms, fieldSet{}, mangaListStatusDefaults) {
+		got = append(got, k)
+	}
+	sort.Strings(got)
+	want = []string{"finish_date", "is_rereading", "num_chapters_read", "num_volumes_read", "score", "start_date", "status", "updated_at"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("default manga list status fields = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package maltest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func (s *Server) forumBoards(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.forum)
+}
+
+// topic returns the topic as returned by the API. When it has posts, its
+// number of posts and last post are taken from them.
+func (t Topic) topic() mal.Topic {
+	topic := t.Topic
+	if n := len(t.Posts); n > 0 {
+		last := t.Posts[n-1]
+		topic.NumberOfPosts = n
+		topic.LastPostCreatedAt = last.CreatedAt
+		topic.LastPostCreatedBy = last.CreatedBy
+	}
+	return topic
+}
+
+func (t Topic) hasPostBy(name string) bool {
+	if strings.EqualFold(t.CreatedBy.Name, name) {
+		return true
+	}
+	for _, p := range t.Posts {
+		if strings.EqualFold(p.CreatedBy.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) forumTopics(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.ToLower(q.Get("q"))
+	boardID, subboardID := 0, 0
+	for key, dst := range map[string]*int{"board_id": &boardID, "subboard_id": &subboardID} {
+		if v := q.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				badRequest(w, "invalid "+key)
+				return
+			}
+			*dst = n
+		}
+	}
+	userName, topicUserName := q.Get("user_name"), q.Get("topic_user_name")
+	if query == "" && boardID == 0 && subboardID == 0 && userName == "" && topicUserName == "" {
+		badRequest(w, "invalid q")
+		return
+	}
+	if sort := q.Get("sort"); sort != "" && sort != string(mal.SortTopicsRecent) {
+		badRequest(w, "invalid sort")
+		return
+	}
+
+	var found []mal.Topic
+	for _, t := range s.topics {
+		switch {
+		case query != "" && !strings.Contains(strings.ToLower(t.Title), query):
+		case boardID != 0 && t.BoardID != boardID:
+		case subboardID != 0 && t.SubboardID != subboardID:
+		case topicUserName != "" && !strings.EqualFold(t.CreatedBy.Name, topicUserName):
+		case userName != "" && !t.hasPostBy(userName):
+		default:
+			found = append(found, t.topic())
+		}
+	}
+	// Topics are sorted by their most recent post.
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].LastPostCreatedAt.After(found[j].LastPostCreatedAt)
+	})
+	from, to, paging, ok := s.page(w, r, len(found), 100, 100)
+	if !ok {
+		return
+	}
+	var data []interface{}
+	for _, t := range found[from:to] {
+		data = append(data, t)
+	}
+	writeJSON(w, listResponse(data, paging))
+}
+
+func (s *Server) forumTopic(w http.ResponseWriter, r *http.Request, id int) {
+	var topic *Topic
+	for i := range s.topics {
+		if s.topics[i].ID == id {
+			topic = &s.topics[i]
+		}
+	}
+	if topic == nil {
+		writeError(w, http.StatusNotFound, "not_found", "")
+		return
+	}
+	from, to, paging, ok := s.page(w, r, len(topic.Posts), 100, 100)
+	if !ok {
+		return
+	}
+	posts := topic.Posts[from:to]
+	if posts == nil {
+		posts = []mal.Post{}
+	}
+	resp := listResponse(nil, paging)
+	resp["data"] = mal.TopicDetails{Title: topic.Title, Posts: posts, Poll: topic.Poll}
+	writeJSON(w, resp)
+}
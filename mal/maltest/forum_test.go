@@ -0,0 +1,76 @@
+package maltest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func TestForum(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	f, _, err := client.Forum.Boards(ctx)
+	if err != nil {
+		t.Fatalf("Forum.Boards returned error: %v", err)
+	}
+	p, ok := f.Index().Lookup("anime db")
+	if !ok {
+		t.Fatal("Forum.Boards returned no Anime DB subboard")
+	}
+
+	topics, _, err := client.Forum.Topics(ctx, p.TopicsFilter())
+	if err != nil {
+		t.Fatalf("Forum.Topics returned error: %v", err)
+	}
+	if len(topics) != 1 || topics[0].ID != 101 {
+		t.Errorf("Forum.Topics of subboard returned %+v, want topic 101", topics)
+	}
+
+	topics, _, err = client.Forum.Topics(ctx, mal.BoardID(5))
+	if err != nil {
+		t.Fatalf("Forum.Topics returned error: %v", err)
+	}
+	if len(topics) != 2 || topics[0].ID != 101 {
+		t.Fatalf("Forum.Topics of board returned %+v, want the most recent topic first", topics)
+	}
+	if got := topics[1]; got.NumberOfPosts != 3 || got.LastPostCreatedBy.Name != "baz" {
+		t.Errorf("Forum.Topics returned %+v, want the post count and last post from the posts", got)
+	}
+
+	topics, _, err = client.Forum.Topics(ctx, mal.UserName("bar"))
+	if err != nil {
+		t.Fatalf("Forum.Topics returned error: %v", err)
+	}
+	if len(topics) != 1 || topics[0].ID != 100 {
+		t.Errorf("Forum.Topics by user name returned %+v, want topic 100", topics)
+	}
+
+	_, _, err = client.Forum.Topics(ctx)
+	testErrorStatus(t, err, http.StatusBadRequest, "bad_request")
+}
+
+func TestForumTopic(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	var bodies []string
+	d, err := client.Forum.WalkTopic(ctx, 100, func(p mal.Post) error {
+		bodies = append(bodies, p.Body)
+		return nil
+	}, mal.Limit(2))
+	if err != nil {
+		t.Fatalf("Forum.WalkTopic returned error: %v", err)
+	}
+	if len(bodies) != 3 || bodies[2] != "Trigun" {
+		t.Errorf("Forum.WalkTopic walked %q, want the 3 posts", bodies)
+	}
+	if d.Title != "Best of Spring" || d.Poll == nil || d.Poll.TotalVotes() != 3 {
+		t.Errorf("Forum.WalkTopic returned %+v, want the title and poll", d)
+	}
+
+	_, _, err = client.Forum.TopicDetails(ctx, 999)
+	testErrorStatus(t, err, http.StatusNotFound, "not_found")
+}
@@ -0,0 +1,387 @@
+// Package maltest provides an in-memory fake of the MyAnimeList API for tests.
+//
+// The fake implements every endpoint called by the mal package. It is seeded
+// from Fixtures, keeps the lists of the users in memory so that updates and
+// deletes are seen by later requests and, like the real API, only returns the
+// fields requested with the fields parameter along with the default ones:
+//
+//	srv := maltest.NewServer(&maltest.Fixtures{
+//		Anime: []mal.Anime{{ID: 1, Title: "Cowboy Bebop", NumEpisodes: 26}},
+//		Users: []maltest.User{{User: mal.User{Name: "foo"}}},
+//		Me:    "foo",
+//	})
+//	defer srv.Close()
+//
+//	c := srv.Client()
+//	a, _, err := c.Anime.Details(ctx, 1, mal.Fields{"num_episodes"})
+//
+// Errors are returned with the status codes and the JSON format of the real
+// API, so they are reported as *mal.ErrorResponse by the client.
+package maltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// Fixtures are the data that a Server is seeded with. They can be written in
+// Go or loaded from JSON with ReadFixtures or LoadFixtures.
+type Fixtures struct {
+	Anime []mal.Anime `json:"anime"`
+	Manga []mal.Manga `json:"manga"`
+	Users []User      `json:"users"`
+	// Me is the name of the authenticated user. Requests for @me and updates
+	// of the list status act on this user. When it is empty, these requests
+	// fail with 401 Unauthorized.
+	Me     string    `json:"me"`
+	Forum  mal.Forum `json:"forum"`
+	Topics []Topic   `json:"topics"`
+}
+
+// User is a user of the fake along with their lists. Only the IDs of the list
+// entry nodes are used; the rest of the node is taken from the anime and
+// manga of the fixtures.
+type User struct {
+	mal.User
+	AnimeList []mal.UserAnime `json:"anime_list"`
+	MangaList []mal.UserManga `json:"manga_list"`
+}
+
+// Topic is a forum topic of the fake along with its posts and poll. When the
+// topic has posts, its number of posts and last post are taken from them.
+type Topic struct {
+	mal.Topic
+	BoardID    int        `json:"board_id"`
+	SubboardID int        `json:"subboard_id"`
+	Posts      []mal.Post `json:"posts"`
+	Poll       *mal.Poll  `json:"poll"`
+}
+
+// ReadFixtures decodes JSON fixtures from r.
+func ReadFixtures(r io.Reader) (*Fixtures, error) {
+	f := new(Fixtures)
+	if err := json.NewDecoder(r).Decode(f); err != nil {
+		return nil, fmt.Errorf("decoding fixtures: %v", err)
+	}
+	return f, nil
+}
+
+// LoadFixtures reads JSON fixtures from the file at path.
+func LoadFixtures(path string) (*Fixtures, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadFixtures(file)
+}
+
+// Server is a fake MyAnimeList API server.
+type Server struct {
+	// URL is the base URL of the server.
+	URL string
+
+	// Now returns the time that list updates are recorded with. It defaults
+	// to time.Now and should be set before making requests.
+	Now func() time.Time
+
+	srv *httptest.Server
+
+	mu     sync.Mutex
+	anime  []mal.Anime
+	manga  []mal.Manga
+	users  []*User
+	me     string
+	forum  mal.Forum
+	topics []Topic
+}
+
+// NewServer starts and returns a new Server seeded with a copy of f. A nil f
+// starts a server without data. The caller should call Close when finished.
+func NewServer(f *Fixtures) *Server {
+	s := NewUnstartedServer(f)
+	s.srv.Start()
+	s.URL = s.srv.URL
+	return s
+}
+
+// NewUnstartedServer returns a new Server seeded with a copy of f that is not
+// started yet, which is useful to use it as an http.Handler.
+func NewUnstartedServer(f *Fixtures) *Server {
+	if f == nil {
+		f = new(Fixtures)
+	}
+	f = copyFixtures(f)
+	s := &Server{
+		Now:    time.Now,
+		anime:  f.Anime,
+		manga:  f.Manga,
+		me:     f.Me,
+		forum:  f.Forum,
+		topics: f.Topics,
+	}
+	for i := range f.Users {
+		s.users = append(s.users, &f.Users[i])
+	}
+	s.srv = httptest.NewUnstartedServer(s)
+	return s
+}
+
+// copyFixtures returns a deep copy of f so that the server never modifies the
+// fixtures of the caller.
+func copyFixtures(f *Fixtures) *Fixtures {
+	data, err := json.Marshal(f)
+	if err != nil {
+		panic(fmt.Sprintf("maltest: copying fixtures: %v", err))
+	}
+	c := new(Fixtures)
+	if err := json.Unmarshal(data, c); err != nil {
+		panic(fmt.Sprintf("maltest: copying fixtures: %v", err))
+	}
+	return c
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a mal.Client that is configured to talk to the server.
+func (s *Server) Client() *mal.Client {
+	c := mal.NewClient(s.srv.Client())
+	c.BaseURL, _ = url.Parse(s.URL + "/")
+	return c
+}
+
+// ServeHTTP serves the requests of the API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	h, ok := s.route(r.Method, path)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "")
+		return
+	}
+	h(w, r)
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request)
+
+// route returns the handler of the request for path split in segments.
+func (s *Server) route(method string, path []string) (handlerFunc, bool) {
+	get := func(h handlerFunc) (handlerFunc, bool) {
+		if method != http.MethodGet {
+			return methodNotAllowed, true
+		}
+		return h, true
+	}
+	switch {
+	case match(path, "anime"):
+		return get(s.animeSearch)
+	case match(path, "anime", "ranking"):
+		return get(s.animeRanking)
+	case match(path, "anime", "suggestions"):
+		return get(s.animeSuggestions)
+	case match(path, "anime", "season", "*", "*"):
+		return get(s.animeSeasonal(path[2], path[3]))
+	case match(path, "anime", "*"):
+		return get(withID(path[1], s.animeDetails))
+	case match(path, "anime", "*", "my_list_status"):
+		return s.myListStatus(method, withID(path[1], s.updateAnimeListStatus), withID(path[1], s.deleteAnimeListItem))
+	case match(path, "manga"):
+		return get(s.mangaSearch)
+	case match(path, "manga", "ranking"):
+		return get(s.mangaRanking)
+	case match(path, "manga", "*"):
+		return get(withID(path[1], s.mangaDetails))
+	case match(path, "manga", "*", "my_list_status"):
+		return s.myListStatus(method, withID(path[1], s.updateMangaListStatus), withID(path[1], s.deleteMangaListItem))
+	case match(path, "users", "*"):
+		return get(s.userInfo(path[1]))
+	case match(path, "users", "*", "animelist"):
+		return get(s.userAnimeList(path[1]))
+	case match(path, "users", "*", "mangalist"):
+		return get(s.userMangaList(path[1]))
+	case match(path, "forum", "boards"):
+		return get(s.forumBoards)
+	case match(path, "forum", "topics"):
+		return get(s.forumTopics)
+	case match(path, "forum", "topic", "*"):
+		return get(withID(path[2], s.forumTopic))
+	}
+	return nil, false
+}
+
+func (s *Server) myListStatus(method string, update, del handlerFunc) (handlerFunc, bool) {
+	switch method {
+	case http.MethodPatch:
+		return update, true
+	case http.MethodDelete:
+		return del, true
+	}
+	return methodNotAllowed, true
+}
+
+// match reports whether path matches the pattern segments where "*" matches
+// any segment.
+func match(path []string, pattern ...string) bool {
+	if len(path) != len(pattern) {
+		return false
+	}
+	for i := range path {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// withID adapts a handler of an ID segment. Invalid IDs are not found.
+func withID(segment string, h func(w http.ResponseWriter, r *http.Request, id int)) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(segment)
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusNotFound, "not_found", "")
+			return
+		}
+		h(w, r, id)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "")
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeError writes an error in the format of the API which is decoded into
+// mal.ErrorResponse.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message, "error": code})
+}
+
+func badRequest(w http.ResponseWriter, message string) {
+	writeError(w, http.StatusBadRequest, "bad_request", message)
+}
+
+// meUser returns the authenticated user or writes an error.
+func (s *Server) meUser(w http.ResponseWriter) (*User, bool) {
+	if s.me == "" {
+		writeError(w, http.StatusUnauthorized, "invalid_token", "")
+		return nil, false
+	}
+	u := s.user(s.me)
+	if u == nil {
+		u = &User{User: mal.User{Name: s.me}}
+		s.users = append(s.users, u)
+	}
+	return u, true
+}
+
+// user returns the user with the given name, compared case insensitively,
+// or nil.
+func (s *Server) user(name string) *User {
+	for _, u := range s.users {
+		if strings.EqualFold(u.Name, name) {
+			return u
+		}
+	}
+	return nil
+}
+
+// lookupUser returns the user of a users/{name} path or writes an error.
+func (s *Server) lookupUser(w http.ResponseWriter, name string) (*User, bool) {
+	if name == "@me" {
+		return s.meUser(w)
+	}
+	u := s.user(name)
+	if u == nil {
+		writeError(w, http.StatusNotFound, "not_found", "")
+		return nil, false
+	}
+	return u, true
+}
+
+// page returns the bounds of the requested page of n results and the paging
+// object of the response or writes an error.
+func (s *Server) page(w http.ResponseWriter, r *http.Request, n, defaultLimit, maxLimit int) (from, to int, paging mal.Paging, ok bool) {
+	q := r.URL.Query()
+	limit, offset := defaultLimit, 0
+	if v := q.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 || l > maxLimit {
+			badRequest(w, "invalid limit")
+			return 0, 0, paging, false
+		}
+		limit = l
+	}
+	if v := q.Get("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			badRequest(w, "invalid offset")
+			return 0, 0, paging, false
+		}
+		offset = o
+	}
+	from, to = offset, offset+limit
+	if from > n {
+		from = n
+	}
+	if to > n {
+		to = n
+	}
+	base := s.URL
+	if base == "" {
+		base = "http://" + r.Host
+	}
+	link := func(offset int) string {
+		q.Set("offset", strconv.Itoa(offset))
+		q.Set("limit", strconv.Itoa(limit))
+		return base + r.URL.Path + "?" + q.Encode()
+	}
+	if to < n {
+		paging.Next = link(to)
+	}
+	if from > 0 {
+		prev := from - limit
+		if prev < 0 {
+			prev = 0
+		}
+		paging.Previous = link(prev)
+	}
+	return from, to, paging, true
+}
+
+func listResponse(data []interface{}, paging mal.Paging) map[string]interface{} {
+	if data == nil {
+		data = []interface{}{}
+	}
+	p := make(map[string]string)
+	if paging.Next != "" {
+		p["next"] = paging.Next
+	}
+	if paging.Previous != "" {
+		p["previous"] = paging.Previous
+	}
+	return map[string]interface{}{"data": data, "paging": p}
+}
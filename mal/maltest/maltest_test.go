@@ -0,0 +1,114 @@
+package maltest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// newTestServer starts a server seeded with testdata/fixtures.json.
+func newTestServer(t *testing.T) (*Server, *mal.Client) {
+	t.Helper()
+	f, err := LoadFixtures("testdata/fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(f)
+	t.Cleanup(srv.Close)
+	srv.Now = func() time.Time { return time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC) }
+	return srv, srv.Client()
+}
+
+// testErrorStatus checks that err is an *mal.ErrorResponse with the given
+// status code and error.
+func testErrorStatus(t *testing.T, err error, status int, code string) {
+	t.Helper()
+	var errResp *mal.ErrorResponse
+	if !errors.As(err, &errResp) {
+		t.Fatalf("error = %v, want *mal.ErrorResponse", err)
+	}
+	if errResp.Response.StatusCode != status || errResp.Err != code {
+		t.Errorf("error = %d %q, want %d %q", errResp.Response.StatusCode, errResp.Err, status, code)
+	}
+}
+
+func TestLoadFixturesError(t *testing.T) {
+	if _, err := LoadFixtures("testdata/missing.json"); err == nil {
+		t.Error("LoadFixtures of missing file expected error, got no error.")
+	}
+	if _, err := ReadFixtures(strings.NewReader(`{"anime": 1}`)); err == nil {
+		t.Error("ReadFixtures of invalid JSON expected error, got no error.")
+	}
+}
+
+func TestServerNotFound(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, _, err := client.Anime.Details(ctx, 999)
+	testErrorStatus(t, err, http.StatusNotFound, "not_found")
+
+	req, err := client.NewRequest(http.MethodGet, "unknown/path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Do(ctx, req, nil)
+	testErrorStatus(t, err, http.StatusNotFound, "not_found")
+
+	req, err = client.NewRequest(http.MethodPost, "anime/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Do(ctx, req, nil)
+	testErrorStatus(t, err, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestServerUnauthorized(t *testing.T) {
+	srv := NewServer(&Fixtures{Anime: []mal.Anime{{ID: 1, Title: "Cowboy Bebop"}}})
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	_, _, err := client.User.MyInfo(ctx)
+	testErrorStatus(t, err, http.StatusUnauthorized, "invalid_token")
+	_, _, err = client.Anime.UpdateMyListStatus(ctx, 1, mal.AnimeStatusWatching)
+	testErrorStatus(t, err, http.StatusUnauthorized, "invalid_token")
+}
+
+func TestServerPaging(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, resp, err := client.Anime.List(ctx, "cowboy", mal.Limit(1))
+	if err != nil {
+		t.Fatalf("Anime.List returned error: %v", err)
+	}
+	if resp.NextOffset != 1 || resp.PrevOffset != 0 {
+		t.Errorf("first page offsets = next %d, prev %d, want next 1, prev 0", resp.NextOffset, resp.PrevOffset)
+	}
+	_, resp, err = client.Anime.List(ctx, "cowboy", mal.Limit(1), mal.Offset(1))
+	if err != nil {
+		t.Fatalf("Anime.List returned error: %v", err)
+	}
+	if resp.NextOffset != 0 || resp.PrevOffset != 0 {
+		t.Errorf("last page offsets = next %d, prev %d, want none", resp.NextOffset, resp.PrevOffset)
+	}
+
+	_, _, err = client.Anime.List(ctx, "cowboy", mal.Limit(101))
+	testErrorStatus(t, err, http.StatusBadRequest, "bad_request")
+}
+
+func TestServerAsHandler(t *testing.T) {
+	srv := NewUnstartedServer(&Fixtures{Anime: []mal.Anime{{ID: 1, Title: "Cowboy Bebop"}}})
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/anime/1", nil))
+	if got, want := strings.TrimSpace(rec.Body.String()), `{"id":1,"main_picture":{"large":"","medium":""},"title":"Cowboy Bebop"}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,428 @@
+package maltest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func animeIndex(u *User, animeID int) int {
+	for i, e := range u.AnimeList {
+		if e.Anime.ID == animeID {
+			return i
+		}
+	}
+	return -1
+}
+
+func mangaIndex(u *User, mangaID int) int {
+	for i, e := range u.MangaList {
+		if e.Manga.ID == mangaID {
+			return i
+		}
+	}
+	return -1
+}
+
+// AnimeList returns the current anime list of the user with the given name
+// with the nodes taken from the anime of the fixtures. It returns nil if
+// there is no such user.
+func (s *Server) AnimeList(username string) []mal.UserAnime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.user(username)
+	if u == nil {
+		return nil
+	}
+	return s.animeEntries(u)
+}
+
+// MangaList returns the current manga list of the user with the given name
+// like AnimeList.
+func (s *Server) MangaList(username string) []mal.UserManga {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.user(username)
+	if u == nil {
+		return nil
+	}
+	return s.mangaEntries(u)
+}
+
+func (s *Server) animeEntries(u *User) []mal.UserAnime {
+	entries := make([]mal.UserAnime, len(u.AnimeList))
+	for i, e := range u.AnimeList {
+		if a, ok := s.findAnime(e.Anime.ID); ok {
+			e.Anime = a
+		}
+		entries[i] = e
+	}
+	return entries
+}
+
+func (s *Server) mangaEntries(u *User) []mal.UserManga {
+	entries := make([]mal.UserManga, len(u.MangaList))
+	for i, e := range u.MangaList {
+		if m, ok := s.findManga(e.Manga.ID); ok {
+			e.Manga = m
+		}
+		entries[i] = e
+	}
+	return entries
+}
+
+func (s *Server) userInfo(name string) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The API only returns the information of the authenticated user.
+		if name != "@me" {
+			writeError(w, http.StatusNotFound, "not_found", "")
+			return
+		}
+		u, ok := s.meUser(w)
+		if !ok {
+			return
+		}
+		info := u.User
+		fields := parseFields(r.URL.Query().Get("fields"))
+		if fields.has("anime_statistics") && info.AnimeStatistics == (mal.AnimeStatistics{}) {
+			info.AnimeStatistics = mal.NewAnimeStatistics(s.animeEntries(u))
+		}
+		writeJSON(w, selectFields(info, fields, userDefaults))
+	}
+}
+
+func (s *Server) userAnimeList(name string) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := s.lookupUser(w, name)
+		if !ok {
+			return
+		}
+		q := r.URL.Query()
+		status := mal.AnimeStatus(q.Get("status"))
+		if status != "" && !validAnimeStatus(status) {
+			badRequest(w, "invalid status")
+			return
+		}
+		var entries []mal.UserAnime
+		for _, e := range s.animeEntries(u) {
+			if (status == "" || e.Status.Status == status) && (showNSFW(r) || e.Anime.NSFW != "black") {
+				entries = append(entries, e)
+			}
+		}
+		var less func(a, b mal.UserAnime) bool
+		switch mal.SortAnimeList(q.Get("sort")) {
+		case "":
+		case mal.SortAnimeListByListScore:
+			less = func(a, b mal.UserAnime) bool { return a.Status.Score > b.Status.Score }
+		case mal.SortAnimeListByListUpdatedAt:
+			less = func(a, b mal.UserAnime) bool { return a.Status.UpdatedAt.After(b.Status.UpdatedAt) }
+		case mal.SortAnimeListByAnimeTitle:
+			less = func(a, b mal.UserAnime) bool { return strings.ToLower(a.Anime.Title) < strings.ToLower(b.Anime.Title) }
+		case mal.SortAnimeListByAnimeStartDate:
+			less = func(a, b mal.UserAnime) bool { return a.Anime.StartDate > b.Anime.StartDate }
+		case mal.SortAnimeListByAnimeID:
+			less = func(a, b mal.UserAnime) bool { return a.Anime.ID < b.Anime.ID }
+		default:
+			badRequest(w, "invalid sort")
+			return
+		}
+		if less != nil {
+			sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+		}
+		from, to, paging, ok := s.page(w, r, len(entries), 10, 1000)
+		if !ok {
+			return
+		}
+		fields := parseFields(q.Get("fields"))
+		var data []interface{}
+		for _, e := range entries[from:to] {
+			data = append(data, map[string]interface{}{
+				"node":        s.animeNode(e.Anime, fields.without("list_status")),
+				"list_status": selectFields(e.Status, fields["list_status"], animeListStatusDefaults),
+			})
+		}
+		writeJSON(w, listResponse(data, paging))
+	}
+}
+
+func (s *Server) userMangaList(name string) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := s.lookupUser(w, name)
+		if !ok {
+			return
+		}
+		q := r.URL.Query()
+		status := mal.MangaStatus(q.Get("status"))
+		if status != "" && !validMangaStatus(status) {
+			badRequest(w, "invalid status")
+			return
+		}
+		var entries []mal.UserManga
+		for _, e := range s.mangaEntries(u) {
+			if (status == "" || e.Status.Status == status) && (showNSFW(r) || e.Manga.Nsfw != "black") {
+				entries = append(entries, e)
+			}
+		}
+		var less func(a, b mal.UserManga) bool
+		switch mal.SortMangaList(q.Get("sort")) {
+		case "":
+		case mal.SortMangaListByListScore:
+			less = func(a, b mal.UserManga) bool { return a.Status.Score > b.Status.Score }
+		case mal.SortMangaListByListUpdatedAt:
+			less = func(a, b mal.UserManga) bool { return a.Status.UpdatedAt.After(b.Status.UpdatedAt) }
+		case mal.SortMangaListByMangaTitle:
+			less = func(a, b mal.UserManga) bool { return strings.ToLower(a.Manga.Title) < strings.ToLower(b.Manga.Title) }
+		case mal.SortMangaListByMangaStartDate:
+			less = func(a, b mal.UserManga) bool { return a.Manga.StartDate > b.Manga.StartDate }
+		case mal.SortMangaListByMangaID:
+			less = func(a, b mal.UserManga) bool { return a.Manga.ID < b.Manga.ID }
+		default:
+			badRequest(w, "invalid sort")
+			return
+		}
+		if less != nil {
+			sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+		}
+		from, to, paging, ok := s.page(w, r, len(entries), 10, 1000)
+		if !ok {
+			return
+		}
+		fields := parseFields(q.Get("fields"))
+		var data []interface{}
+		for _, e := range entries[from:to] {
+			data = append(data, map[string]interface{}{
+				"node":        s.mangaNode(e.Manga, fields.without("list_status")),
+				"list_status": selectFields(e.Status, fields["list_status"], mangaListStatusDefaults),
+			})
+		}
+		writeJSON(w, listResponse(data, paging))
+	}
+}
+
+func validAnimeStatus(s mal.AnimeStatus) bool {
+	switch s {
+	case mal.AnimeStatusWatching, mal.AnimeStatusCompleted, mal.AnimeStatusOnHold, mal.AnimeStatusDropped, mal.AnimeStatusPlanToWatch:
+		return true
+	}
+	return false
+}
+
+func validMangaStatus(s mal.MangaStatus) bool {
+	switch s {
+	case mal.MangaStatusReading, mal.MangaStatusCompleted, mal.MangaStatusOnHold, mal.MangaStatusDropped, mal.MangaStatusPlanToRead:
+		return true
+	}
+	return false
+}
+
+// formReader reads the values of a list status update and keeps the first
+// invalid parameter.
+type formReader struct {
+	v       url.Values
+	invalid string
+}
+
+func (f *formReader) has(key string) bool {
+	_, ok := f.v[key]
+	return ok
+}
+
+func (f *formReader) int(key string, min, max int, dst *int) {
+	if !f.has(key) || f.invalid != "" {
+		return
+	}
+	n, err := strconv.Atoi(f.v.Get(key))
+	if err != nil || n < min || (max >= 0 && n > max) {
+		f.invalid = key
+		return
+	}
+	*dst = n
+}
+
+func (f *formReader) bool(key string, dst *bool) {
+	if !f.has(key) || f.invalid != "" {
+		return
+	}
+	b, err := strconv.ParseBool(f.v.Get(key))
+	if err != nil {
+		f.invalid = key
+		return
+	}
+	*dst = b
+}
+
+func (f *formReader) string(key string, dst *string) {
+	if f.has(key) {
+		*dst = f.v.Get(key)
+	}
+}
+
+func (f *formReader) date(key string, dst *string) {
+	if !f.has(key) || f.invalid != "" {
+		return
+	}
+	d := f.v.Get(key)
+	if d != "" {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			f.invalid = key
+			return
+		}
+	}
+	*dst = d
+}
+
+func (f *formReader) tags(key string, dst *[]string) {
+	if !f.has(key) {
+		return
+	}
+	tags := []string{}
+	for _, t := range strings.Split(f.v.Get(key), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	*dst = tags
+}
+
+// updateForm parses the form of a list status update or writes an error.
+func updateForm(w http.ResponseWriter, r *http.Request) (*formReader, bool) {
+	if err := r.ParseForm(); err != nil {
+		badRequest(w, "invalid body")
+		return nil, false
+	}
+	return &formReader{v: r.PostForm}, true
+}
+
+func (s *Server) now() time.Time {
+	return s.Now().UTC().Truncate(time.Second)
+}
+
+func (s *Server) updateAnimeListStatus(w http.ResponseWriter, r *http.Request, id int) {
+	u, ok := s.meUser(w)
+	if !ok {
+		return
+	}
+	a, ok := s.findAnime(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "")
+		return
+	}
+	f, ok := updateForm(w, r)
+	if !ok {
+		return
+	}
+	i := animeIndex(u, id)
+	st := mal.AnimeListStatus{Status: mal.AnimeStatusPlanToWatch, Tags: []string{}}
+	if i >= 0 {
+		st = u.AnimeList[i].Status
+	}
+	if f.has("status") {
+		st.Status = mal.AnimeStatus(f.v.Get("status"))
+		if !validAnimeStatus(st.Status) {
+			f.invalid = "status"
+		}
+	}
+	f.bool("is_rewatching", &st.IsRewatching)
+	f.int("score", 0, 10, &st.Score)
+	f.int("num_watched_episodes", 0, -1, &st.NumEpisodesWatched)
+	f.int("priority", 0, 2, &st.Priority)
+	f.int("num_times_rewatched", 0, -1, &st.NumTimesRewatched)
+	f.int("rewatch_value", 0, 5, &st.RewatchValue)
+	f.tags("tags", &st.Tags)
+	f.string("comments", &st.Comments)
+	f.date("start_date", &st.StartDate)
+	f.date("finish_date", &st.FinishDate)
+	if f.invalid != "" {
+		badRequest(w, "invalid "+f.invalid)
+		return
+	}
+	st.UpdatedAt = s.now()
+	if i >= 0 {
+		u.AnimeList[i].Status = st
+	} else {
+		u.AnimeList = append(u.AnimeList, mal.UserAnime{Anime: mal.Anime{ID: a.ID}, Status: st})
+	}
+	writeJSON(w, st)
+}
+
+func (s *Server) deleteAnimeListItem(w http.ResponseWriter, r *http.Request, id int) {
+	u, ok := s.meUser(w)
+	if !ok {
+		return
+	}
+	i := animeIndex(u, id)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "")
+		return
+	}
+	u.AnimeList = append(u.AnimeList[:i], u.AnimeList[i+1:]...)
+	writeJSON(w, []interface{}{})
+}
+
+func (s *Server) updateMangaListStatus(w http.ResponseWriter, r *http.Request, id int) {
+	u, ok := s.meUser(w)
+	if !ok {
+		return
+	}
+	m, ok := s.findManga(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "")
+		return
+	}
+	f, ok := updateForm(w, r)
+	if !ok {
+		return
+	}
+	i := mangaIndex(u, id)
+	st := mal.MangaListStatus{Status: mal.MangaStatusPlanToRead, Tags: []string{}}
+	if i >= 0 {
+		st = u.MangaList[i].Status
+	}
+	if f.has("status") {
+		st.Status = mal.MangaStatus(f.v.Get("status"))
+		if !validMangaStatus(st.Status) {
+			f.invalid = "status"
+		}
+	}
+	f.bool("is_rereading", &st.IsRereading)
+	f.int("score", 0, 10, &st.Score)
+	f.int("num_volumes_read", 0, -1, &st.NumVolumesRead)
+	f.int("num_chapters_read", 0, -1, &st.NumChaptersRead)
+	f.int("priority", 0, 2, &st.Priority)
+	f.int("num_times_reread", 0, -1, &st.NumTimesReread)
+	f.int("reread_value", 0, 5, &st.RereadValue)
+	f.tags("tags", &st.Tags)
+	f.string("comments", &st.Comments)
+	f.date("start_date", &st.StartDate)
+	f.date("finish_date", &st.FinishDate)
+	if f.invalid != "" {
+		badRequest(w, "invalid "+f.invalid)
+		return
+	}
+	st.UpdatedAt = s.now()
+	if i >= 0 {
+		u.MangaList[i].Status = st
+	} else {
+		u.MangaList = append(u.MangaList, mal.UserManga{Manga: mal.Manga{ID: m.ID}, Status: st})
+	}
+	writeJSON(w, st)
+}
+
+func (s *Server) deleteMangaListItem(w http.ResponseWriter, r *http.Request, id int) {
+	u, ok := s.meUser(w)
+	if !ok {
+		return
+	}
+	i := mangaIndex(u, id)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "")
+		return
+	}
+	u.MangaList = append(u.MangaList[:i], u.MangaList[i+1:]...)
+	writeJSON(w, []interface{}{})
+}
@@ -0,0 +1,152 @@
+package maltest
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+func TestMyInfo(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	u, _, err := client.User.MyInfo(ctx)
+	if err != nil {
+		t.Fatalf("User.MyInfo returned error: %v", err)
+	}
+	if u.Name != "foo" || u.Location != "Tokyo" || u.TimeZone != "" {
+		t.Errorf("User.MyInfo returned %+v, want default fields only", u)
+	}
+
+	u, _, err = client.User.MyInfo(ctx, mal.Fields{"time_zone", "anime_statistics"})
+	if err != nil {
+		t.Fatalf("User.MyInfo returned error: %v", err)
+	}
+	if u.TimeZone != "Asia/Tokyo" {
+		t.Errorf("User.MyInfo time_zone = %q, want %q", u.TimeZone, "Asia/Tokyo")
+	}
+	if got, want := u.AnimeStatistics.NumEpisodes, 29; got != want {
+		t.Errorf("User.MyInfo computed anime_statistics.num_episodes = %d, want %d", got, want)
+	}
+}
+
+func TestUserAnimeList(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	list, _, err := client.User.AnimeList(ctx, "foo", mal.SortAnimeListByListUpdatedAt, mal.Fields{"list_status{tags}"})
+	if err != nil {
+		t.Fatalf("User.AnimeList returned error: %v", err)
+	}
+	if len(list) != 2 || list[0].Anime.ID != 6 || list[1].Anime.ID != 1 {
+		t.Fatalf("User.AnimeList sorted by update returned %+v, want anime 6 then 1", list)
+	}
+	if got, want := list[1].Status.Tags, []string{"space"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("User.AnimeList tags = %v, want %v", got, want)
+	}
+	if list[1].Anime.Title != "Cowboy Bebop" || list[1].Anime.NumEpisodes != 0 {
+		t.Errorf("User.AnimeList node = %+v, want the default fields from the catalog", list[1].Anime)
+	}
+
+	list, _, err = client.User.AnimeList(ctx, "@me", mal.AnimeStatusWatching)
+	if err != nil {
+		t.Fatalf("User.AnimeList returned error: %v", err)
+	}
+	if len(list) != 1 || list[0].Anime.ID != 6 {
+		t.Errorf("User.AnimeList filtered by status returned %+v, want anime 6", list)
+	}
+
+	var pages [][]mal.UserAnime
+	err = client.User.WalkAnimeList(ctx, "foo", func(page []mal.UserAnime) error {
+		pages = append(pages, page)
+		return nil
+	}, mal.Limit(1))
+	if err != nil {
+		t.Fatalf("User.WalkAnimeList returned error: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Errorf("User.WalkAnimeList walked %d pages, want 2", len(pages))
+	}
+
+	_, _, err = client.User.AnimeList(ctx, "nobody")
+	testErrorStatus(t, err, http.StatusNotFound, "not_found")
+	_, _, err = client.User.AnimeList(ctx, "foo", mal.SortAnimeList("random"))
+	testErrorStatus(t, err, http.StatusBadRequest, "bad_request")
+}
+
+func TestUpdateMyAnimeListStatus(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+
+	st, _, err := client.Anime.UpdateMyListStatus(ctx, 5, mal.AnimeStatusCompleted, mal.Score(8), mal.Tags{"movie", "space"})
+	if err != nil {
+		t.Fatalf("Anime.UpdateMyListStatus returned error: %v", err)
+	}
+	want := &mal.AnimeListStatus{
+		Status:    mal.AnimeStatusCompleted,
+		Score:     8,
+		Tags:      []string{"movie", "space"},
+		UpdatedAt: time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(st, want) {
+		t.Errorf("Anime.UpdateMyListStatus returned\nhave: %+v\nwant: %+v", st, want)
+	}
+
+	st, _, err = client.Anime.UpdateMyListStatus(ctx, 6, mal.NumEpisodesWatched(4))
+	if err != nil {
+		t.Fatalf("Anime.UpdateMyListStatus returned error: %v", err)
+	}
+	if st.Status != mal.AnimeStatusWatching || st.Score != 7 || st.NumEpisodesWatched != 4 {
+		t.Errorf("Anime.UpdateMyListStatus of existing entry returned %+v, want only the episodes changed", st)
+	}
+
+	if list := srv.AnimeList("foo"); len(list) != 3 || list[2].Anime.Title != "Cowboy Bebop: Tengoku no Tobira" {
+		t.Errorf("AnimeList after update = %+v, want the new entry last", list)
+	}
+
+	_, _, err = client.Anime.UpdateMyListStatus(ctx, 1, mal.Score(11), mal.SkipValidation(true))
+	testErrorStatus(t, err, http.StatusBadRequest, "bad_request")
+	_, _, err = client.Anime.UpdateMyListStatus(ctx, 999, mal.Score(1))
+	testErrorStatus(t, err, http.StatusNotFound, "not_found")
+
+	if _, err := client.Anime.DeleteMyListItem(ctx, 1); err != nil {
+		t.Fatalf("Anime.DeleteMyListItem returned error: %v", err)
+	}
+	_, err = client.Anime.DeleteMyListItem(ctx, 1)
+	testErrorStatus(t, err, http.StatusNotFound, "not_found")
+	if list := srv.AnimeList("foo"); len(list) != 2 {
+		t.Errorf("AnimeList after delete has %d entries, want 2", len(list))
+	}
+}
+
+func TestMangaListAndUpdate(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+
+	st, _, err := client.Manga.UpdateMyListStatus(ctx, 2, mal.NumChaptersRead(120), mal.StartDate(time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatalf("Manga.UpdateMyListStatus returned error: %v", err)
+	}
+	if st.Status != mal.MangaStatusReading || st.NumChaptersRead != 120 || st.StartDate != "2020-05-01" {
+		t.Errorf("Manga.UpdateMyListStatus returned %+v", st)
+	}
+
+	list, _, err := client.User.MangaList(ctx, "@me", mal.Fields{"list_status{start_date}", "num_chapters"})
+	if err != nil {
+		t.Fatalf("User.MangaList returned error: %v", err)
+	}
+	if len(list) != 1 || list[0].Status.StartDate != "2020-05-01" || list[0].Manga.NumChapters != 370 {
+		t.Errorf("User.MangaList returned %+v", list)
+	}
+
+	if _, err := client.Manga.DeleteMyListItem(ctx, 2); err != nil {
+		t.Fatalf("Manga.DeleteMyListItem returned error: %v", err)
+	}
+	if list := srv.MangaList("foo"); len(list) != 0 {
+		t.Errorf("MangaList after delete = %+v, want empty", list)
+	}
+}
@@ -0,0 +1,168 @@
+package mal
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// TotalVotes returns the number of votes of all the options of the poll.
+func (p *Poll) TotalVotes() int {
+	total := 0
+	for _, o := range p.Options {
+		total += o.Votes
+	}
+	return total
+}
+
+// PollResult is the result of a poll option.
+type PollResult struct {
+	PollOption
+	// Percent is the percentage of the votes of the poll that the option
+	// received, rounded to two decimals. It is 0 when the poll has no votes.
+	Percent float64 `json:"percent"`
+	// Winner reports whether the option is one of the winners of the poll.
+	Winner bool `json:"winner"`
+}
+
+// Results returns the result of every option of the poll in the same order as
+// the options.
+func (p *Poll) Results() []PollResult {
+	total := p.TotalVotes()
+	most := p.mostVotes()
+	results := make([]PollResult, len(p.Options))
+	for i, o := range p.Options {
+		results[i] = PollResult{PollOption: o, Winner: most > 0 && o.Votes == most}
+		if total > 0 {
+			results[i].Percent = round2(float64(o.Votes) * 100 / float64(total))
+		}
+	}
+	return results
+}
+
+// Winners returns the options with the most votes. More than one option is
+// returned when they are tied and none when the poll has no votes.
+func (p *Poll) Winners() []PollOption {
+	most := p.mostVotes()
+	if most == 0 {
+		return nil
+	}
+	var winners []PollOption
+	for _, o := range p.Options {
+		if o.Votes == most {
+			winners = append(winners, o)
+		}
+	}
+	return winners
+}
+
+func (p *Poll) mostVotes() int {
+	most := 0
+	for _, o := range p.Options {
+		if o.Votes > most {
+			most = o.Votes
+		}
+	}
+	return most
+}
+
+// TopicPoll is the poll of a forum topic.
+type TopicPoll struct {
+	Topic Topic
+	Poll  Poll
+}
+
+// collectPollsLimit is the page size used to search topics for polls.
+const collectPollsLimit = 100
+
+// CollectPolls searches the forum topics using the given options, the same as
+// Topics, and returns the polls of all the topics found across all pages.
+// Topics without a poll are skipped. Pass at least the Query option or you
+// will get an API error.
+func (s *ForumService) CollectPolls(ctx context.Context, options ...TopicsOption) ([]TopicPoll, error) {
+	var polls []TopicPoll
+	offset := 0
+	for {
+		oo := append([]TopicsOption{Limit(collectPollsLimit)}, options...)
+		oo = append(oo, Offset(offset))
+		topics, resp, err := s.Topics(ctx, oo...)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range topics {
+			// The poll is included with every page of the topic so the
+			// smallest page is enough.
+			d, _, err := s.TopicDetails(ctx, t.ID, Limit(1))
+			if err != nil {
+				return nil, err
+			}
+			if d.Poll != nil {
+				polls = append(polls, TopicPoll{Topic: t, Poll: *d.Poll})
+			}
+		}
+		if resp.NextOffset <= offset || len(topics) == 0 {
+			return polls, nil
+		}
+		offset = resp.NextOffset
+	}
+}
+
+type pollReport struct {
+	TopicID    int          `json:"topic_id"`
+	TopicTitle string       `json:"topic_title"`
+	PollID     int          `json:"poll_id"`
+	Question   string       `json:"question"`
+	Closed     bool         `json:"closed"`
+	TotalVotes int          `json:"total_votes"`
+	Results    []PollResult `json:"results"`
+}
+
+// WritePollsJSON writes a JSON report of the polls to w. The report is an
+// array with an object for each poll that includes its total votes and the
+// result of each option.
+func WritePollsJSON(w io.Writer, polls []TopicPoll) error {
+	report := make([]pollReport, len(polls))
+	for i, tp := range polls {
+		report[i] = pollReport{
+			TopicID:    tp.Topic.ID,
+			TopicTitle: tp.Topic.Title,
+			PollID:     tp.Poll.ID,
+			Question:   tp.Poll.Question,
+			Closed:     tp.Poll.Closed,
+			TotalVotes: tp.Poll.TotalVotes(),
+			Results:    tp.Poll.Results(),
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// WritePollsCSV writes a CSV report of the polls to w with a row for each
+// poll option.
+func WritePollsCSV(w io.Writer, polls []TopicPoll) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"topic_id", "topic_title", "poll_id", "question", "closed", "total_votes", "option_id", "option_text", "votes", "percent", "winner"})
+	for _, tp := range polls {
+		total := strconv.Itoa(tp.Poll.TotalVotes())
+		for _, r := range tp.Poll.Results() {
+			cw.Write([]string{
+				strconv.Itoa(tp.Topic.ID),
+				tp.Topic.Title,
+				strconv.Itoa(tp.Poll.ID),
+				tp.Poll.Question,
+				strconv.FormatBool(tp.Poll.Closed),
+				total,
+				strconv.Itoa(r.ID),
+				r.Text,
+				strconv.Itoa(r.Votes),
+				strconv.FormatFloat(r.Percent, 'f', -1, 64),
+				strconv.FormatBool(r.Winner),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,172 @@
+package mal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPollResults(t *testing.T) {
+	p := &Poll{Options: []PollOption{
+		{ID: 1, Text: "A", Votes: 2},
+		{ID: 2, Text: "B", Votes: 1},
+		{ID: 3, Text: "C", Votes: 0},
+	}}
+	if got, want := p.TotalVotes(), 3; got != want {
+		t.Errorf("Poll.TotalVotes() = %d, want %d", got, want)
+	}
+	want := []PollResult{
+		{PollOption: p.Options[0], Percent: 66.67, Winner: true},
+		{PollOption: p.Options[1], Percent: 33.33},
+		{PollOption: p.Options[2], Percent: 0},
+	}
+	if got := p.Results(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll.Results() returned\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestPollWinners(t *testing.T) {
+	tests := []struct {
+		name  string
+		votes []int
+		want  []int
+	}{
+		{"single winner", []int{1, 5, 2}, []int{2}},
+		{"tie", []int{4, 1, 4}, []int{1, 3}},
+		{"no votes", []int{0, 0}, nil},
+		{"no options", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Poll{}
+			for i, v := range tt.votes {
+				p.Options = append(p.Options, PollOption{ID: i + 1, Votes: v})
+			}
+			var got []int
+			for _, o := range p.Winners() {
+				got = append(got, o.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Poll.Winners() = %v, want %v", got, tt.want)
+			}
+			for _, r := range p.Results() {
+				isWinner := false
+				for _, id := range tt.want {
+					isWinner = isWinner || r.ID == id
+				}
+				if r.Winner != isWinner {
+					t.Errorf("Poll.Results() option %d Winner = %v, want %v", r.ID, r.Winner, isWinner)
+				}
+			}
+		})
+	}
+}
+
+func TestForumServiceCollectPolls(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/forum/topics", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			testURLValues(t, r, urlValues{"q": "best of", "limit": "100", "offset": "0"})
+			fmt.Fprint(w, `{"data": [{"id": 1, "title": "Best of Spring"}, {"id": 2, "title": "No poll"}], "paging": {"next": "?offset=2"}}`)
+		case "2":
+			fmt.Fprint(w, `{"data": [{"id": 3, "title": "Best of Summer"}]}`)
+		default:
+			t.Errorf("unexpected offset %q", r.URL.Query().Get("offset"))
+		}
+	})
+	for id, poll := range map[int]string{
+		1: `{"id": 10, "question": "Best?", "closed": true, "options": [{"id": 1, "text": "A", "votes": 3}, {"id": 2, "text": "B", "votes": 1}]}`,
+		2: `null`,
+		3: `{"id": 30, "question": "Best summer?", "options": [{"id": 5, "text": "C", "votes": 2}, {"id": 6, "text": "D, \"E\"", "votes": 2}]}`,
+	} {
+		poll := poll
+		mux.HandleFunc(fmt.Sprintf("/forum/topic/%d", id), func(w http.ResponseWriter, r *http.Request) {
+			testURLValues(t, r, urlValues{"limit": "1"})
+			fmt.Fprintf(w, `{"data": {"title": "x", "posts": [], "poll": %s}}`, poll)
+		})
+	}
+
+	ctx := context.Background()
+	polls, err := client.Forum.CollectPolls(ctx, Query("best of"))
+	if err != nil {
+		t.Fatalf("Forum.CollectPolls returned error: %v", err)
+	}
+	if len(polls) != 2 || polls[0].Topic.ID != 1 || polls[1].Topic.ID != 3 {
+		t.Fatalf("Forum.CollectPolls returned %+v, want the polls of topics 1 and 3", polls)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePollsCSV(&buf, polls); err != nil {
+		t.Fatalf("WritePollsCSV returned error: %v", err)
+	}
+	wantCSV := `topic_id,topic_title,poll_id,question,closed,total_votes,option_id,option_text,votes,percent,winner
+1,Best of Spring,10,Best?,true,4,1,A,3,75,true
+1,Best of Spring,10,Best?,true,4,2,B,1,25,false
+3,Best of Summer,30,Best summer?,false,4,5,C,2,50,true
+3,Best of Summer,30,Best summer?,false,4,6,"D, ""E""",2,50,true
+`
+	if got := buf.String(); got != wantCSV {
+		t.Errorf("WritePollsCSV\nhave: %s\nwant: %s", got, wantCSV)
+	}
+
+	buf.Reset()
+	if err := WritePollsJSON(&buf, polls[:1]); err != nil {
+		t.Fatalf("WritePollsJSON returned error: %v", err)
+	}
+	wantJSON := `[
+  {
+    "topic_id": 1,
+    "topic_title": "Best of Spring",
+    "poll_id": 10,
+    "question": "Best?",
+    "closed": true,
+    "total_votes": 4,
+    "results": [
+      {
+        "id": 1,
+        "text": "A",
+        "votes": 3,
+        "percent": 75,
+        "winner": true
+      },
+      {
+        "id": 2,
+        "text": "B",
+        "votes": 1,
+        "percent": 25,
+        "winner": false
+      }
+    ]
+  }
+]
+`
+	if got := buf.String(); got != wantJSON {
+		t.Errorf("WritePollsJSON\nhave: %s\nwant: %s", got, wantJSON)
+	}
+}
+
+func TestForumServiceCollectPollsError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/forum/topics", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"id": 1}]}`)
+	})
+	mux.HandleFunc("/forum/topic/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"topic deleted","error":"not_found"}`, 404)
+	})
+
+	ctx := context.Background()
+	_, err := client.Forum.CollectPolls(ctx, Query("x"))
+	if err == nil {
+		t.Fatal("Forum.CollectPolls expected not found error, got no error.")
+	}
+	testErrorResponse(t, err, ErrorResponse{Message: "topic deleted", Err: "not_found"})
+}
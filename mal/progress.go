@@ -0,0 +1,243 @@
+package mal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrProgressBeyondTotal is returned by the progress methods when the
+// requested number of episodes, chapters or volumes is greater than the known
+// total of the anime or manga.
+var ErrProgressBeyondTotal = errors.New("progress beyond known total")
+
+// timeNow returns the current time. It is a variable so that tests can
+// replace it to produce predictable start and finish dates.
+var timeNow = time.Now
+
+const (
+	animeProgressFields = "num_episodes,my_list_status{start_date,finish_date,num_times_rewatched}"
+	mangaProgressFields = "num_chapters,num_volumes,my_list_status{start_date,finish_date,num_times_reread}"
+)
+
+// WatchNextEpisode marks the next episode of the anime specified by animeID as
+// watched in the user's list. It applies the same status transitions as
+// SetEpisodesWatched. If the anime is already completed and not being
+// rewatched, a rewatch is started from the first episode.
+func (s *AnimeService) WatchNextEpisode(ctx context.Context, animeID int) (*AnimeListStatus, *Response, error) {
+	a, resp, err := s.Details(ctx, animeID, Fields{animeProgressFields})
+	if err != nil {
+		return nil, resp, err
+	}
+	st := a.MyListStatus
+	if st.Status == AnimeStatusCompleted && !st.IsRewatching {
+		a.MyListStatus.IsRewatching = true
+		a.MyListStatus.NumEpisodesWatched = 0
+	}
+	return s.updateProgress(ctx, a, a.MyListStatus.NumEpisodesWatched+1)
+}
+
+// SetEpisodesWatched sets the number of watched episodes of the anime
+// specified by animeID in the user's list and applies the conventional
+// MyAnimeList status transitions:
+//
+//   - An anime that is not in the list or is planned to watch becomes watching
+//     and gets a start date of today if it has none.
+//   - Watching the last episode completes the anime and sets a finish date of
+//     today if it has none.
+//   - Watching the last episode of a rewatch increments the number of times
+//     rewatched and ends the rewatch.
+//   - Moving a completed anime below its last episode starts a rewatch.
+//
+// If the anime has a known number of episodes and episodes is greater than
+// that, an error wrapping ErrProgressBeyondTotal is returned and the list is
+// not updated.
+func (s *AnimeService) SetEpisodesWatched(ctx context.Context, animeID, episodes int) (*AnimeListStatus, *Response, error) {
+	a, resp, err := s.Details(ctx, animeID, Fields{animeProgressFields})
+	if err != nil {
+		return nil, resp, err
+	}
+	return s.updateProgress(ctx, a, episodes)
+}
+
+func (s *AnimeService) updateProgress(ctx context.Context, a *Anime, episodes int) (*AnimeListStatus, *Response, error) {
+	options, err := animeProgressOptions(a, episodes, timeNow())
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.UpdateMyListStatus(ctx, a.ID, options...)
+}
+
+func animeProgressOptions(a *Anime, episodes int, now time.Time) ([]UpdateMyAnimeListStatusOption, error) {
+	total := a.NumEpisodes
+	if episodes < 0 {
+		return nil, fmt.Errorf("anime %d: invalid number of episodes watched: %d", a.ID, episodes)
+	}
+	if total > 0 && episodes > total {
+		return nil, fmt.Errorf("anime %d: %w: %d episodes watched of %d", a.ID, ErrProgressBeyondTotal, episodes, total)
+	}
+	st := a.MyListStatus
+	last := total > 0 && episodes == total
+
+	options := []UpdateMyAnimeListStatusOption{NumEpisodesWatched(episodes)}
+	switch {
+	case st.IsRewatching:
+		if last {
+			options = append(options,
+				IsRewatching(false),
+				NumTimesRewatched(st.NumTimesRewatched+1),
+			)
+		} else {
+			options = append(options, IsRewatching(true))
+		}
+	case st.Status == AnimeStatusCompleted:
+		if total > 0 && !last {
+			options = append(options, IsRewatching(true))
+		}
+	case last:
+		options = append(options, AnimeStatusCompleted)
+		if st.StartDate == "" {
+			options = append(options, StartDate(now))
+		}
+		if st.FinishDate == "" {
+			options = append(options, FinishDate(now))
+		}
+	case episodes > 0 && (st.Status == "" || st.Status == AnimeStatusPlanToWatch):
+		options = append(options, AnimeStatusWatching)
+		if st.StartDate == "" {
+			options = append(options, StartDate(now))
+		}
+	}
+	return options, nil
+}
+
+// ReadNextChapter marks the next chapter of the manga specified by mangaID as
+// read in the user's list. It applies the same status transitions as
+// SetChaptersRead. If the manga is already completed and not being reread, a
+// reread is started from the first chapter and volume.
+func (s *MangaService) ReadNextChapter(ctx context.Context, mangaID int) (*MangaListStatus, *Response, error) {
+	m, resp, err := s.Details(ctx, mangaID, Fields{mangaProgressFields})
+	if err != nil {
+		return nil, resp, err
+	}
+	st := m.MyListStatus
+	if st.Status == MangaStatusCompleted && !st.IsRereading {
+		m.MyListStatus.IsRereading = true
+		chapters, volumes := 1, 0
+		return s.updateProgress(ctx, m, &chapters, &volumes)
+	}
+	chapters := st.NumChaptersRead + 1
+	return s.updateProgress(ctx, m, &chapters, nil)
+}
+
+// SetChaptersRead sets the number of read chapters of the manga specified by
+// mangaID in the user's list and applies the conventional MyAnimeList status
+// transitions:
+//
+//   - A manga that is not in the list or is planned to read becomes reading
+//     and gets a start date of today if it has none.
+//   - Reading the last chapter completes the manga, sets the volumes read to
+//     the total and sets a finish date of today if it has none.
+//   - Reading the last chapter of a reread increments the number of times
+//     reread and ends the reread.
+//   - Moving a completed manga below its last chapter starts a reread.
+//
+// If the manga has a known number of chapters and chapters is greater than
+// that, an error wrapping ErrProgressBeyondTotal is returned and the list is
+// not updated.
+func (s *MangaService) SetChaptersRead(ctx context.Context, mangaID, chapters int) (*MangaListStatus, *Response, error) {
+	m, resp, err := s.Details(ctx, mangaID, Fields{mangaProgressFields})
+	if err != nil {
+		return nil, resp, err
+	}
+	return s.updateProgress(ctx, m, &chapters, nil)
+}
+
+// SetVolumesRead sets the number of read volumes of the manga specified by
+// mangaID in the user's list. It applies the same status transitions as
+// SetChaptersRead with reading the last volume also completing the manga.
+func (s *MangaService) SetVolumesRead(ctx context.Context, mangaID, volumes int) (*MangaListStatus, *Response, error) {
+	m, resp, err := s.Details(ctx, mangaID, Fields{mangaProgressFields})
+	if err != nil {
+		return nil, resp, err
+	}
+	return s.updateProgress(ctx, m, nil, &volumes)
+}
+
+func (s *MangaService) updateProgress(ctx context.Context, m *Manga, chapters, volumes *int) (*MangaListStatus, *Response, error) {
+	options, err := mangaProgressOptions(m, chapters, volumes, timeNow())
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.UpdateMyListStatus(ctx, m.ID, options...)
+}
+
+// mangaProgressOptions returns the options that update the progress of m to
+// the given chapters and volumes. A nil chapters or volumes is left as it is
+// in the list, so that only the side that changes can complete the manga and
+// only the counts that change are sent.
+func mangaProgressOptions(m *Manga, chapters, volumes *int, now time.Time) ([]UpdateMyMangaListStatusOption, error) {
+	st := m.MyListStatus
+	ch, vol := st.NumChaptersRead, st.NumVolumesRead
+	if chapters != nil {
+		ch = *chapters
+	}
+	if volumes != nil {
+		vol = *volumes
+	}
+	if ch < 0 || vol < 0 {
+		return nil, fmt.Errorf("manga %d: invalid progress: %d chapters and %d volumes read", m.ID, ch, vol)
+	}
+	if chapters != nil && m.NumChapters > 0 && ch > m.NumChapters {
+		return nil, fmt.Errorf("manga %d: %w: %d chapters read of %d", m.ID, ErrProgressBeyondTotal, ch, m.NumChapters)
+	}
+	if volumes != nil && m.NumVolumes > 0 && vol > m.NumVolumes {
+		return nil, fmt.Errorf("manga %d: %w: %d volumes read of %d", m.ID, ErrProgressBeyondTotal, vol, m.NumVolumes)
+	}
+	last := (chapters != nil && m.NumChapters > 0 && ch == m.NumChapters) ||
+		(volumes != nil && m.NumVolumes > 0 && vol == m.NumVolumes)
+
+	var options []UpdateMyMangaListStatusOption
+	if chapters != nil || last && m.NumChapters > 0 {
+		if last && m.NumChapters > 0 {
+			ch = m.NumChapters
+		}
+		options = append(options, NumChaptersRead(ch))
+	}
+	if volumes != nil || last && m.NumVolumes > 0 {
+		if last && m.NumVolumes > 0 {
+			vol = m.NumVolumes
+		}
+		options = append(options, NumVolumesRead(vol))
+	}
+	switch {
+	case st.IsRereading:
+		if last {
+			options = append(options,
+				IsRereading(false),
+				NumTimesReread(st.NumTimesReread+1),
+			)
+		} else {
+			options = append(options, IsRereading(true))
+		}
+	case st.Status == MangaStatusCompleted:
+		if (m.NumChapters > 0 || m.NumVolumes > 0) && !last {
+			options = append(options, IsRereading(true))
+		}
+	case last:
+		options = append(options, MangaStatusCompleted)
+		if st.StartDate == "" {
+			options = append(options, StartDate(now))
+		}
+		if st.FinishDate == "" {
+			options = append(options, FinishDate(now))
+		}
+	case (ch > 0 || vol > 0) && (st.Status == "" || st.Status == MangaStatusPlanToRead):
+		options = append(options, MangaStatusReading)
+		if st.StartDate == "" {
+			options = append(options, StartDate(now))
+		}
+	}
+	return options, nil
+}
@@ -0,0 +1,307 @@
+package mal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func setTimeNow(t *testing.T, now time.Time) {
+	t.Helper()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+func TestAnimeServiceSetEpisodesWatched(t *testing.T) {
+	setTimeNow(t, time.Date(2022, 02, 20, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		details  string
+		episodes int
+		wantBody string
+	}{
+		{
+			name:     "not in list",
+			details:  `{"id":1,"num_episodes":12}`,
+			episodes: 1,
+			wantBody: "num_watched_episodes=1&start_date=2022-02-20&status=watching",
+		},
+		{
+			name:     "plan to watch",
+			details:  `{"id":1,"num_episodes":12,"my_list_status":{"status":"plan_to_watch"}}`,
+			episodes: 3,
+			wantBody: "num_watched_episodes=3&start_date=2022-02-20&status=watching",
+		},
+		{
+			name:     "watching keeps start date",
+			details:  `{"id":1,"num_episodes":12,"my_list_status":{"status":"watching","start_date":"2022-01-01"}}`,
+			episodes: 4,
+			wantBody: "num_watched_episodes=4",
+		},
+		{
+			name:     "last episode completes",
+			details:  `{"id":1,"num_episodes":12,"my_list_status":{"status":"watching","start_date":"2022-01-01"}}`,
+			episodes: 12,
+			wantBody: "finish_date=2022-02-20&num_watched_episodes=12&status=completed",
+		},
+		{
+			name:     "last episode of rewatch",
+			details:  `{"id":1,"num_episodes":12,"my_list_status":{"status":"completed","is_rewatching":true,"num_times_rewatched":1}}`,
+			episodes: 12,
+			wantBody: "is_rewatching=false&num_times_rewatched=2&num_watched_episodes=12",
+		},
+		{
+			name:     "completed moved back starts rewatch",
+			details:  `{"id":1,"num_episodes":12,"my_list_status":{"status":"completed"}}`,
+			episodes: 2,
+			wantBody: "is_rewatching=true&num_watched_episodes=2",
+		},
+		{
+			name:     "unknown total",
+			details:  `{"id":1,"my_list_status":{"status":"watching","start_date":"2022-01-01"}}`,
+			episodes: 500,
+			wantBody: "num_watched_episodes=500",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc("/anime/1", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, http.MethodGet)
+				testURLValues(t, r, urlValues{"fields": animeProgressFields})
+				fmt.Fprint(w, tt.details)
+			})
+			mux.HandleFunc("/anime/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, http.MethodPatch)
+				testBody(t, r, tt.wantBody)
+				fmt.Fprint(w, `{"status":"watching"}`)
+			})
+
+			ctx := context.Background()
+			if _, _, err := client.Anime.SetEpisodesWatched(ctx, 1, tt.episodes); err != nil {
+				t.Errorf("Anime.SetEpisodesWatched returned error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAnimeServiceSetEpisodesWatchedBeyondTotal(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/anime/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":1,"num_episodes":12,"my_list_status":{"status":"watching"}}`)
+	})
+	mux.HandleFunc("/anime/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Anime.SetEpisodesWatched beyond total should not update the list")
+	})
+
+	ctx := context.Background()
+	_, _, err := client.Anime.SetEpisodesWatched(ctx, 1, 13)
+	if !errors.Is(err, ErrProgressBeyondTotal) {
+		t.Errorf("Anime.SetEpisodesWatched returned err = %v, want %v", err, ErrProgressBeyondTotal)
+	}
+}
+
+func TestAnimeServiceWatchNextEpisode(t *testing.T) {
+	setTimeNow(t, time.Date(2022, 02, 20, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		details  string
+		wantBody string
+	}{
+		{
+			name:     "watching",
+			details:  `{"id":1,"num_episodes":12,"my_list_status":{"status":"watching","num_episodes_watched":4,"start_date":"2022-01-01"}}`,
+			wantBody: "num_watched_episodes=5",
+		},
+		{
+			name:     "completed starts rewatch",
+			details:  `{"id":1,"num_episodes":12,"my_list_status":{"status":"completed","num_episodes_watched":12}}`,
+			wantBody: "is_rewatching=true&num_watched_episodes=1",
+		},
+		{
+			name:     "completed single episode rewatch",
+			details:  `{"id":1,"num_episodes":1,"my_list_status":{"status":"completed","num_episodes_watched":1,"num_times_rewatched":3}}`,
+			wantBody: "is_rewatching=false&num_times_rewatched=4&num_watched_episodes=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc("/anime/1", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.details)
+			})
+			mux.HandleFunc("/anime/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, http.MethodPatch)
+				testBody(t, r, tt.wantBody)
+				fmt.Fprint(w, `{}`)
+			})
+
+			ctx := context.Background()
+			if _, _, err := client.Anime.WatchNextEpisode(ctx, 1); err != nil {
+				t.Errorf("Anime.WatchNextEpisode returned error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAnimeServiceWatchNextEpisodeError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/anime/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"anime deleted","error":"not_found"}`, http.StatusNotFound)
+	})
+
+	ctx := context.Background()
+	_, resp, err := client.Anime.WatchNextEpisode(ctx, 1)
+	if err == nil {
+		t.Fatal("Anime.WatchNextEpisode expected not found error, got no error.")
+	}
+	testResponseStatusCode(t, resp, http.StatusNotFound, "Anime.WatchNextEpisode")
+	testErrorResponse(t, err, ErrorResponse{Message: "anime deleted", Err: "not_found"})
+}
+
+func TestMangaServiceProgress(t *testing.T) {
+	setTimeNow(t, time.Date(2022, 02, 20, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		details  string
+		update   func(ctx context.Context, s *MangaService) error
+		wantBody string
+	}{
+		{
+			name:    "read next chapter of plan to read",
+			details: `{"id":1,"num_chapters":50,"num_volumes":5,"my_list_status":{"status":"plan_to_read"}}`,
+			update: func(ctx context.Context, s *MangaService) error {
+				_, _, err := s.ReadNextChapter(ctx, 1)
+				return err
+			},
+			wantBody: "num_chapters_read=1&start_date=2022-02-20&status=reading",
+		},
+		{
+			name:    "last chapter completes",
+			details: `{"id":1,"num_chapters":50,"num_volumes":5,"my_list_status":{"status":"reading","num_volumes_read":4,"start_date":"2022-01-01"}}`,
+			update: func(ctx context.Context, s *MangaService) error {
+				_, _, err := s.SetChaptersRead(ctx, 1, 50)
+				return err
+			},
+			wantBody: "finish_date=2022-02-20&num_chapters_read=50&num_volumes_read=5&status=completed",
+		},
+		{
+			name:    "last volume completes",
+			details: `{"id":1,"num_chapters":50,"num_volumes":5,"my_list_status":{"status":"reading","num_chapters_read":42,"start_date":"2022-01-01"}}`,
+			update: func(ctx context.Context, s *MangaService) error {
+				_, _, err := s.SetVolumesRead(ctx, 1, 5)
+				return err
+			},
+			wantBody: "finish_date=2022-02-20&num_chapters_read=50&num_volumes_read=5&status=completed",
+		},
+		{
+			name:    "last chapter of reread",
+			details: `{"id":1,"num_chapters":50,"my_list_status":{"status":"completed","is_rereading":true,"num_chapters_read":49}}`,
+			update: func(ctx context.Context, s *MangaService) error {
+				_, _, err := s.ReadNextChapter(ctx, 1)
+				return err
+			},
+			wantBody: "is_rereading=false&num_chapters_read=50&num_times_reread=1",
+		},
+		{
+			name:    "completed starts reread",
+			details: `{"id":1,"num_chapters":50,"my_list_status":{"status":"completed","num_chapters_read":50}}`,
+			update: func(ctx context.Context, s *MangaService) error {
+				_, _, err := s.ReadNextChapter(ctx, 1)
+				return err
+			},
+			wantBody: "is_rereading=true&num_chapters_read=1&num_volumes_read=0",
+		},
+		{
+			name:    "completed with volumes moves below last chapter",
+			details: `{"id":1,"num_chapters":50,"num_volumes":5,"my_list_status":{"status":"completed","num_chapters_read":50,"num_volumes_read":5}}`,
+			update: func(ctx context.Context, s *MangaService) error {
+				_, _, err := s.SetChaptersRead(ctx, 1, 10)
+				return err
+			},
+			wantBody: "is_rereading=true&num_chapters_read=10",
+		},
+		{
+			name:    "completed with volumes moves below last volume",
+			details: `{"id":1,"num_chapters":50,"num_volumes":5,"my_list_status":{"status":"completed","num_chapters_read":50,"num_volumes_read":5}}`,
+			update: func(ctx context.Context, s *MangaService) error {
+				_, _, err := s.SetVolumesRead(ctx, 1, 2)
+				return err
+			},
+			wantBody: "is_rereading=true&num_volumes_read=2",
+		},
+		{
+			name:    "reread with volumes continues",
+			details: `{"id":1,"num_chapters":50,"num_volumes":5,"my_list_status":{"status":"completed","is_rereading":true,"num_chapters_read":10,"num_volumes_read":5}}`,
+			update: func(ctx context.Context, s *MangaService) error {
+				_, _, err := s.ReadNextChapter(ctx, 1)
+				return err
+			},
+			wantBody: "is_rereading=true&num_chapters_read=11",
+		},
+		{
+			name:    "completed with volumes starts reread",
+			details: `{"id":1,"num_chapters":50,"num_volumes":5,"my_list_status":{"status":"completed","num_chapters_read":50,"num_volumes_read":5}}`,
+			update: func(ctx context.Context, s *MangaService) error {
+				_, _, err := s.ReadNextChapter(ctx, 1)
+				return err
+			},
+			wantBody: "is_rereading=true&num_chapters_read=1&num_volumes_read=0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc("/manga/1", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, http.MethodGet)
+				testURLValues(t, r, urlValues{"fields": mangaProgressFields})
+				fmt.Fprint(w, tt.details)
+			})
+			mux.HandleFunc("/manga/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, http.MethodPatch)
+				testBody(t, r, tt.wantBody)
+				fmt.Fprint(w, `{}`)
+			})
+
+			if err := tt.update(context.Background(), client.Manga); err != nil {
+				t.Errorf("progress update returned error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMangaServiceSetChaptersReadBeyondTotal(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/manga/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":1,"num_chapters":50,"num_volumes":5}`)
+	})
+	mux.HandleFunc("/manga/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Manga.SetChaptersRead beyond total should not update the list")
+	})
+
+	ctx := context.Background()
+	if _, _, err := client.Manga.SetChaptersRead(ctx, 1, 51); !errors.Is(err, ErrProgressBeyondTotal) {
+		t.Errorf("Manga.SetChaptersRead returned err = %v, want %v", err, ErrProgressBeyondTotal)
+	}
+	if _, _, err := client.Manga.SetVolumesRead(ctx, 1, 6); !errors.Is(err, ErrProgressBeyondTotal) {
+		t.Errorf("Manga.SetVolumesRead returned err = %v, want %v", err, ErrProgressBeyondTotal)
+	}
+}
@@ -0,0 +1,374 @@
+// Package recommend suggests anime to a user based on their anime list.
+//
+// The suggestions combine the recommendations that MyAnimeList users made
+// for the anime the user rated highly with the genres and studios the user
+// tends to rate above their average:
+//
+//	r := recommend.New(c)
+//	suggestions, err := r.Recommend(ctx, "@me", 10)
+//	for _, s := range suggestions {
+//		fmt.Printf("%s (%.2f)\n", s.Anime.Title, s.Score)
+//		for _, reason := range s.Reasons {
+//			fmt.Println("  " + reason.Text)
+//		}
+//	}
+//
+// Unlike AnimeService.Suggested, it works for any user whose list is visible
+// and explains each suggestion. Anime that are already on the list, in any
+// status, are never suggested.
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/internal/pool"
+)
+
+// Suggestion is a suggested anime.
+type Suggestion struct {
+	// Anime is the suggested anime including its genres and studios when its
+	// details were fetched.
+	Anime mal.Anime
+	// Score ranks the suggestions. It is the sum of the scores of the
+	// reasons.
+	Score float64
+	// Reasons explain the score, largest first.
+	Reasons []Reason
+}
+
+// Reason is a part of the score of a suggestion.
+type Reason struct {
+	Text  string
+	Score float64
+}
+
+const (
+	defaultMinScore      = 8
+	defaultMaxSeeds      = 25
+	defaultMaxCandidates = 50
+	defaultGenreWeight   = 1
+	defaultStudioWeight  = 0.5
+	defaultConcurrency   = 4
+	// pageSize is the largest page size allowed by the user list endpoints.
+	pageSize = 1000
+	// unscoredWeight is the weight of the seeds of users who have not scored
+	// any anime.
+	unscoredWeight = 0.7
+	// affinityPrior is the number of anime of a genre or studio that makes
+	// its affinity count for half. It keeps one anime from deciding the
+	// affinity.
+	affinityPrior = 2
+	// minAffinity is the smallest affinity that counts. Smaller ones are
+	// rounding noise of users who score consistently.
+	minAffinity = 0.05
+)
+
+// Recommender suggests anime. New returns a Recommender with the default
+// settings which can be changed before calling Recommend.
+type Recommender struct {
+	// MinScore is the lowest list score of the anime whose recommendations
+	// are followed. New sets it to 8. When the user has not scored any
+	// anime, the recommendations of their completed anime are followed
+	// instead.
+	MinScore int
+	// MaxSeeds is the largest number of anime whose recommendations are
+	// followed, highest scored first. New sets it to 25.
+	MaxSeeds int
+	// MaxCandidates is the number of best recommended anime whose details
+	// are fetched to weigh their genres and studios. New sets it to 50.
+	MaxCandidates int
+	// GenreWeight and StudioWeight scale how much the affinity of the user
+	// for the genres and studios of a suggestion counts. New sets them to 1
+	// and 0.5. Zero ignores genres or studios.
+	GenreWeight  float64
+	StudioWeight float64
+	// Concurrency is the number of details requested at the same time. New
+	// sets it to 4.
+	Concurrency int
+
+	user  *mal.UserService
+	anime *mal.AnimeService
+}
+
+// New returns a Recommender that uses c to fetch lists and anime details.
+func New(c *mal.Client) *Recommender {
+	return &Recommender{
+		MinScore:      defaultMinScore,
+		MaxSeeds:      defaultMaxSeeds,
+		MaxCandidates: defaultMaxCandidates,
+		GenreWeight:   defaultGenreWeight,
+		StudioWeight:  defaultStudioWeight,
+		Concurrency:   defaultConcurrency,
+		user:          c.User,
+		anime:         c.Anime,
+	}
+}
+
+// Recommend returns up to n suggestions for the user indicated by username
+// (or use @me), best first. If n is zero or negative all suggestions are
+// returned.
+func (r *Recommender) Recommend(ctx context.Context, username string, n int) ([]Suggestion, error) {
+	var list []mal.UserAnime
+	err := r.user.WalkAnimeList(ctx, username, func(page []mal.UserAnime) error {
+		list = append(list, page...)
+		return nil
+	}, mal.Fields{"list_status", "genres", "studios"}, mal.Limit(pageSize))
+	if err != nil {
+		return nil, fmt.Errorf("fetching anime list: %w", err)
+	}
+
+	seeds := r.seeds(list)
+	ids := make([]int, len(seeds))
+	for i, s := range seeds {
+		ids[i] = s.entry.Anime.ID
+	}
+	details, err := r.details(ctx, ids, mal.Fields{"recommendations"})
+	if err != nil {
+		return nil, err
+	}
+	for i := range seeds {
+		seeds[i].recommendations = details[i].Recommendations
+	}
+
+	candidates := recommended(list, seeds)
+	if r.MaxCandidates > 0 && len(candidates) > r.MaxCandidates {
+		candidates = candidates[:r.MaxCandidates]
+	}
+	if r.GenreWeight != 0 || r.StudioWeight != 0 {
+		ids = make([]int, len(candidates))
+		for i, c := range candidates {
+			ids[i] = c.Anime.ID
+		}
+		details, err := r.details(ctx, ids, mal.Fields{"genres", "studios", "mean"})
+		if err != nil {
+			return nil, err
+		}
+		aff := newAffinities(list)
+		for i := range candidates {
+			title := candidates[i].Anime.Title
+			candidates[i].Anime = details[i]
+			if candidates[i].Anime.Title == "" {
+				candidates[i].Anime.Title = title
+			}
+			r.addAffinities(&candidates[i], aff)
+		}
+	}
+
+	rank(candidates)
+	if n > 0 && len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates, nil
+}
+
+// seed is an anime of the list whose recommendations are followed.
+type seed struct {
+	entry           mal.UserAnime
+	weight          float64
+	recommendations []mal.RecommendedAnime
+}
+
+// seeds returns the best scored anime of the list.
+func (r *Recommender) seeds(list []mal.UserAnime) []seed {
+	var seeds []seed
+	scored := false
+	for _, e := range list {
+		if e.Status.Score > 0 {
+			scored = true
+		}
+		if r.MinScore > 0 && e.Status.Score >= r.MinScore {
+			seeds = append(seeds, seed{entry: e, weight: float64(e.Status.Score) / 10})
+		}
+	}
+	if !scored {
+		for _, e := range list {
+			if e.Status.Status == mal.AnimeStatusCompleted {
+				seeds = append(seeds, seed{entry: e, weight: unscoredWeight})
+			}
+		}
+	}
+	sort.SliceStable(seeds, func(i, j int) bool {
+		return seeds[i].entry.Status.Score > seeds[j].entry.Status.Score
+	})
+	if r.MaxSeeds > 0 && len(seeds) > r.MaxSeeds {
+		seeds = seeds[:r.MaxSeeds]
+	}
+	return seeds
+}
+
+// recommended returns the anime recommended for the seeds that are not on
+// the list, best first. Each seed adds its weight times the logarithm of the
+// number of recommendations, so that a few recommendations from many liked
+// anime count more than many recommendations from one.
+func recommended(list []mal.UserAnime, seeds []seed) []Suggestion {
+	onList := make(map[int]bool, len(list))
+	for _, e := range list {
+		onList[e.Anime.ID] = true
+	}
+	index := make(map[int]int)
+	var candidates []Suggestion
+	for _, s := range seeds {
+		for _, rec := range s.recommendations {
+			id := rec.Node.ID
+			if onList[id] || rec.NumRecommendations <= 0 {
+				continue
+			}
+			i, ok := index[id]
+			if !ok {
+				i = len(candidates)
+				index[id] = i
+				candidates = append(candidates, Suggestion{Anime: rec.Node})
+			}
+			text := fmt.Sprintf("recommended by %d %s who liked %q", rec.NumRecommendations, plural(rec.NumRecommendations, "user", "users"), s.entry.Anime.Title)
+			if s.entry.Status.Score > 0 {
+				text += fmt.Sprintf(" (you scored it %d)", s.entry.Status.Score)
+			}
+			candidates[i].add(text, s.weight*math.Log2(1+float64(rec.NumRecommendations)))
+		}
+	}
+	rank(candidates)
+	return candidates
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func (s *Suggestion) add(text string, score float64) {
+	s.Reasons = append(s.Reasons, Reason{Text: text, Score: score})
+	s.Score += score
+}
+
+// rank sorts the suggestions and their reasons by score.
+func rank(suggestions []Suggestion) {
+	for _, s := range suggestions {
+		sort.SliceStable(s.Reasons, func(i, j int) bool { return s.Reasons[i].Score > s.Reasons[j].Score })
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Anime.ID < suggestions[j].Anime.ID
+	})
+}
+
+// affinities are how much above or below their mean score a user scores the
+// anime of each genre and studio.
+type affinities struct {
+	genres  map[string]float64
+	studios map[string]float64
+}
+
+// newAffinities computes the affinities from the scored anime of the list.
+// The difference from the mean is shrunk towards zero for genres and studios
+// with few anime.
+func newAffinities(list []mal.UserAnime) affinities {
+	var sum, n float64
+	for _, e := range list {
+		if e.Status.Score > 0 {
+			sum += float64(e.Status.Score)
+			n++
+		}
+	}
+	aff := affinities{genres: make(map[string]float64), studios: make(map[string]float64)}
+	if n == 0 {
+		return aff
+	}
+	mean := sum / n
+	type total struct{ sum, n float64 }
+	genres := make(map[string]total)
+	studios := make(map[string]total)
+	for _, e := range list {
+		if e.Status.Score == 0 {
+			continue
+		}
+		d := float64(e.Status.Score) - mean
+		for _, g := range e.Anime.Genres {
+			t := genres[g.Name]
+			genres[g.Name] = total{t.sum + d, t.n + 1}
+		}
+		for _, s := range e.Anime.Studios {
+			t := studios[s.Name]
+			studios[s.Name] = total{t.sum + d, t.n + 1}
+		}
+	}
+	for name, t := range genres {
+		aff.genres[name] = t.sum / (t.n + affinityPrior)
+	}
+	for name, t := range studios {
+		aff.studios[name] = t.sum / (t.n + affinityPrior)
+	}
+	return aff
+}
+
+// addAffinities adds the average affinity of the user for the genres and
+// the studios of the suggestion, explained by the genre or studio that
+// counts the most.
+func (r *Recommender) addAffinities(s *Suggestion, aff affinities) {
+	var genres, studios []string
+	for _, g := range s.Anime.Genres {
+		genres = append(genres, g.Name)
+	}
+	for _, st := range s.Anime.Studios {
+		studios = append(studios, st.Name)
+	}
+	addAffinity(s, "genre", genres, aff.genres, r.GenreWeight)
+	addAffinity(s, "studio", studios, aff.studios, r.StudioWeight)
+}
+
+func addAffinity(s *Suggestion, what string, names []string, aff map[string]float64, weight float64) {
+	if weight == 0 || len(names) == 0 {
+		return
+	}
+	var sum float64
+	strongest := ""
+	for _, name := range names {
+		a := aff[name]
+		if math.Abs(a) < minAffinity {
+			continue
+		}
+		sum += a
+		if strongest == "" || math.Abs(a) > math.Abs(aff[strongest]) {
+			strongest = name
+		}
+	}
+	if strongest == "" {
+		return
+	}
+	a := aff[strongest]
+	direction := "above"
+	if a < 0 {
+		direction = "below"
+	}
+	text := fmt.Sprintf("you score the %s %s %.1f %s your average", what, strongest, math.Abs(a), direction)
+	s.add(text, weight*sum/float64(len(names)))
+}
+
+// details fetches the details of the anime concurrently and returns them in
+// the same order. The first error stops the requests.
+func (r *Recommender) details(ctx context.Context, ids []int, fields mal.Fields) ([]mal.Anime, error) {
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	found := make([]mal.Anime, len(ids))
+	err := pool.Run(ctx, concurrency, len(ids), func(ctx context.Context, i int) error {
+		a, _, err := r.anime.Details(ctx, ids[i], fields)
+		if err != nil {
+			return fmt.Errorf("fetching anime %d: %w", ids[i], err)
+		}
+		found[i] = *a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
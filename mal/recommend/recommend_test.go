@@ -0,0 +1,139 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/maltest"
+)
+
+func newTestRecommender(t *testing.T) *Recommender {
+	t.Helper()
+	f, err := maltest.LoadFixtures("testdata/fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := maltest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return New(srv.Client())
+}
+
+func ids(suggestions []Suggestion) []int {
+	var ids []int
+	for _, s := range suggestions {
+		ids = append(ids, s.Anime.ID)
+	}
+	return ids
+}
+
+func TestRecommend(t *testing.T) {
+	r := newTestRecommender(t)
+	r.Concurrency = 2
+
+	got, err := r.Recommend(context.Background(), "@me", 0)
+	if err != nil {
+		t.Fatalf("Recommend returned error: %v", err)
+	}
+	if want := []int{41, 40}; !reflect.DeepEqual(ids(got), want) {
+		t.Fatalf("Recommend suggested %v, want %v", ids(got), want)
+	}
+
+	s := got[0]
+	if s.Anime.Title != "Outlaw Star" || s.Anime.Mean != 7.8 || len(s.Anime.Studios) != 1 {
+		t.Errorf("Recommend suggested anime %+v, want its details", s.Anime)
+	}
+	wantReasons := []string{
+		`recommended by 20 users who liked "Trigun" (you scored it 8)`,
+		`recommended by 2 users who liked "Cowboy Bebop" (you scored it 10)`,
+		"you score the studio Sunrise 0.9 above your average",
+		"you score the genre Sci-Fi 0.8 above your average",
+	}
+	var reasons []string
+	var sum float64
+	for _, r := range s.Reasons {
+		reasons = append(reasons, r.Text)
+		sum += r.Score
+	}
+	if !reflect.DeepEqual(reasons, wantReasons) {
+		t.Errorf("Recommend reasons\nhave: %q\nwant: %q", reasons, wantReasons)
+	}
+	if math.Abs(sum-s.Score) > 1e-9 {
+		t.Errorf("Recommend score %v is not the sum of the reasons %v", s.Score, sum)
+	}
+	if len(got[1].Reasons) != 1 {
+		t.Errorf("Recommend reasons for neutral genres and studios = %+v, want only the recommendation", got[1].Reasons)
+	}
+
+	got, err = r.Recommend(context.Background(), "foo", 1)
+	if err != nil {
+		t.Fatalf("Recommend returned error: %v", err)
+	}
+	if want := []int{41}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("Recommend with n = 1 suggested %v, want %v", ids(got), want)
+	}
+}
+
+func TestRecommendWithoutScores(t *testing.T) {
+	r := newTestRecommender(t)
+	r.GenreWeight, r.StudioWeight = 0, 0
+
+	got, err := r.Recommend(context.Background(), "bar", 0)
+	if err != nil {
+		t.Fatalf("Recommend returned error: %v", err)
+	}
+	if want := []int{6, 40, 30, 41}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("Recommend suggested %v, want the recommendations of the completed anime %v", ids(got), want)
+	}
+	if got[0].Anime.Title != "Trigun" || got[0].Reasons[0].Text != `recommended by 50 users who liked "Cowboy Bebop"` {
+		t.Errorf("Recommend suggestion = %+v", got[0])
+	}
+}
+
+func TestRecommendLimits(t *testing.T) {
+	r := newTestRecommender(t)
+	r.MaxSeeds = 1
+	r.MaxCandidates = 1
+	got, err := r.Recommend(context.Background(), "@me", 0)
+	if err != nil {
+		t.Fatalf("Recommend returned error: %v", err)
+	}
+	if want := []int{40}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("Recommend with one seed and candidate suggested %v, want %v", ids(got), want)
+	}
+}
+
+func TestRecommendError(t *testing.T) {
+	r := newTestRecommender(t)
+	_, err := r.Recommend(context.Background(), "nobody", 0)
+	var errResp *mal.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != http.StatusNotFound {
+		t.Errorf("Recommend for unknown user returned error %v, want a not found error response", err)
+	}
+}
+
+func TestNewAffinities(t *testing.T) {
+	entry := func(score int, genres ...string) mal.UserAnime {
+		e := mal.UserAnime{Status: mal.AnimeListStatus{Score: score}}
+		for _, g := range genres {
+			e.Anime.Genres = append(e.Anime.Genres, mal.Genre{Name: g})
+		}
+		return e
+	}
+	aff := newAffinities([]mal.UserAnime{
+		entry(9, "Comedy"),
+		entry(9, "Comedy"),
+		entry(3, "Horror"),
+		entry(0, "Horror"),
+	})
+	// The mean is 7 so Comedy is 4 above it over 2 anime and Horror 4 below
+	// it over 1 anime.
+	want := map[string]float64{"Comedy": 1, "Horror": -4.0 / 3}
+	if !reflect.DeepEqual(aff.genres, want) {
+		t.Errorf("newAffinities genres = %v, want %v", aff.genres, want)
+	}
+}
@@ -0,0 +1,372 @@
+// Package recorder provides an http.RoundTripper that records the requests
+// made to the MyAnimeList API along with their responses into cassette files
+// and replays them later without network access.
+//
+// A cassette is recorded once against the real API:
+//
+//	rec, err := recorder.New("testdata/cassettes/anime.json", recorder.Record)
+//	// ...
+//	rec.Transport = oauth2Client.Transport
+//	defer rec.Stop() // Saves the cassette.
+//	c := mal.NewClient(rec.Client())
+//
+// and then replayed by using recorder.Replay as the mode. Recorded requests
+// are matched by method, path, query and form body. The order of the query
+// parameters and of the names in the fields parameter does not matter. When
+// the same request is made more than once, the interactions are replayed in
+// the order they were recorded.
+//
+// Credentials are never written to cassettes. Only a few safe headers are
+// recorded and tokens, secrets and codes are redacted from query parameters,
+// form bodies and JSON response bodies.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// Replay serves the interactions of an existing cassette and fails the
+	// requests that were not recorded.
+	Replay Mode = iota
+	// Record makes the requests using the Transport of the Recorder and
+	// records them to a new cassette.
+	Record
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Replay:
+		return "replay"
+	case Record:
+		return "record"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Cassette holds recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Redacted replaces the values of credentials in cassettes.
+const Redacted = "REDACTED"
+
+// recordedHeaders are the only headers written to cassettes.
+var recordedHeaders = []string{"Content-Type"}
+
+// secretParams are the query and form parameters that are redacted.
+var secretParams = []string{"access_token", "refresh_token", "client_id", "client_secret", "code", "code_verifier", "password"}
+
+var secretJSON = regexp.MustCompile(`("(?:access_token|refresh_token|client_secret|id_token)"\s*:\s*)"[^"]*"`)
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	// Transport makes the requests in Record mode. It defaults to
+	// http.DefaultTransport. Use the transport of the OAuth2 client to record
+	// authenticated requests.
+	Transport http.RoundTripper
+
+	// Redact, if set, is called with every interaction before it is recorded
+	// to redact more data than the defaults.
+	Redact func(i *Interaction)
+
+	path string
+	mode Mode
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New returns a Recorder that uses the cassette file at path. In Replay mode,
+// the cassette is loaded and it is an error if it does not exist. In Record
+// mode, the cassette is written when Stop is called.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode}
+	switch mode {
+	case Replay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("recorder: loading cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("recorder: decoding cassette %s: %v", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	case Record:
+	default:
+		return nil, fmt.Errorf("recorder: unknown mode %v", mode)
+	}
+	return r, nil
+}
+
+// Mode returns the mode of the recorder.
+func (r *Recorder) Mode() Mode { return r.mode }
+
+// Client returns an HTTP client that uses the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop saves the cassette in Record mode. It does nothing in Replay mode.
+func (r *Recorder) Stop() error {
+	if r.mode != Record {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("recorder: saving cassette: %v", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("recorder: saving cassette: %v", err)
+	}
+	return nil
+}
+
+// RoundTrip records or replays the request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == Replay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
+
+func (r *Recorder) record(req *http.Request, body string) (*http.Response, error) {
+	t := r.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+	resp, err := t.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	i := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    redactURL(req.URL),
+			Header: safeHeader(req.Header),
+			Body:   redactForm(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     safeHeader(resp.Header),
+			Body:       secretJSON.ReplaceAllString(string(data), `$1"`+Redacted+`"`),
+		},
+	}
+	if r.Redact != nil {
+		r.Redact(&i)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body string) (*http.Response, error) {
+	key := requestKey(req.Method, redactURL(req.URL), redactForm(body))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for n, i := range r.cassette.Interactions {
+		if r.used[n] {
+			continue
+		}
+		if requestKey(i.Request.Method, i.Request.URL, i.Request.Body) != key {
+			continue
+		}
+		r.used[n] = true
+		header := i.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+			StatusCode:    i.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(i.Response.Body)),
+			ContentLength: int64(len(i.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, &MissingError{Method: req.Method, URL: redactURL(req.URL)}
+}
+
+// MissingError is returned in Replay mode for requests that are not in the
+// cassette or whose recorded interactions were all replayed already.
+type MissingError struct {
+	Method string
+	URL    string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("recorder: no recorded interaction for %s %s", e.Method, e.URL)
+}
+
+// IsMissing reports whether err is caused by a request that was not recorded.
+func IsMissing(err error) bool {
+	var m *MissingError
+	return errors.As(err, &m)
+}
+
+func safeHeader(h http.Header) http.Header {
+	var out http.Header
+	for _, k := range recordedHeaders {
+		if v := h.Values(k); len(v) > 0 {
+			if out == nil {
+				out = make(http.Header)
+			}
+			out[k] = append([]string(nil), v...)
+		}
+	}
+	return out
+}
+
+func redactValues(v url.Values) {
+	for _, k := range secretParams {
+		if _, ok := v[k]; ok {
+			v.Set(k, Redacted)
+		}
+	}
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	q := c.Query()
+	redactValues(q)
+	c.RawQuery = q.Encode()
+	c.User = nil
+	return c.String()
+}
+
+// redactForm redacts the secrets of a form body. Bodies that are not forms
+// are returned as they are.
+func redactForm(body string) string {
+	if body == "" {
+		return ""
+	}
+	v, err := url.ParseQuery(body)
+	if err != nil {
+		return body
+	}
+	redactValues(v)
+	return v.Encode()
+}
+
+// requestKey returns the normalized form of a request used for matching.
+func requestKey(method, rawURL, body string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL + " " + body
+	}
+	q := u.Query()
+	for i, f := range q["fields"] {
+		q["fields"][i] = NormalizeFields(f)
+	}
+	if v, err := url.ParseQuery(body); err == nil {
+		body = v.Encode()
+	}
+	return method + " " + u.Path + "?" + q.Encode() + " " + body
+}
+
+// NormalizeFields returns the value of a fields parameter with the names at
+// every level sorted and spaces removed, so that equivalent values compare
+// equal. For example "synopsis, my_list_status{tags,priority}" is normalized
+// to "my_list_status{priority,tags},synopsis".
+func NormalizeFields(fields string) string {
+	var names []string
+	depth, start := 0, 0
+	add := func(s string) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return
+		}
+		if i := strings.IndexByte(s, '{'); i >= 0 && strings.HasSuffix(s, "}") {
+			s = strings.TrimSpace(s[:i]) + "{" + NormalizeFields(s[i+1:len(s)-1]) + "}"
+		}
+		names = append(names, s)
+	}
+	for i, c := range fields {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				add(fields[start:i])
+				start = i + 1
+			}
+		}
+	}
+	add(fields[start:])
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
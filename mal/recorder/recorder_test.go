@@ -0,0 +1,227 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// setup starts a test API server and returns a client that records to a
+// cassette in a temporary directory.
+func setup(t *testing.T) (rec *Recorder, client *mal.Client, mux *http.ServeMux, path string) {
+	t.Helper()
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	path = filepath.Join(t.TempDir(), "cassettes", "test.json")
+	rec, err := New(path, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = newClient(t, rec, server.URL)
+	return rec, client, mux, path
+}
+
+func newClient(t *testing.T, rec *Recorder, baseURL string) *mal.Client {
+	t.Helper()
+	client := mal.NewClient(rec.Client())
+	u, err := url.Parse(baseURL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = u
+	return client
+}
+
+func TestRecordReplay(t *testing.T) {
+	rec, client, mux, path := setup(t)
+	ctx := context.Background()
+
+	calls := 0
+	mux.HandleFunc("/anime/1", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprintf(w, `{"id":1,"title":"Cowboy Bebop","num_episodes":%d}`, calls)
+	})
+	mux.HandleFunc("/anime/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"watching","score":8}`)
+	})
+	mux.HandleFunc("/anime/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"","error":"not_found"}`)
+	})
+
+	fields := mal.Fields{"num_episodes", "my_list_status{tags,priority}"}
+	for i := 0; i < 2; i++ {
+		if _, _, err := client.Anime.Details(ctx, 1, fields); err != nil {
+			t.Fatalf("Anime.Details returned error: %v", err)
+		}
+	}
+	if _, _, err := client.Anime.UpdateMyListStatus(ctx, 1, mal.AnimeStatusWatching, mal.Score(8)); err != nil {
+		t.Fatalf("Anime.UpdateMyListStatus returned error: %v", err)
+	}
+	if _, _, err := client.Anime.Details(ctx, 2); err == nil {
+		t.Fatal("Anime.Details of missing anime expected error, got no error.")
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "session=secret") {
+		t.Errorf("cassette contains unsafe header:\n%s", data)
+	}
+
+	// Replay against a base URL that is not served with the names of the
+	// fields reordered.
+	rec, err = New(path, Replay)
+	if err != nil {
+		t.Fatalf("New in Replay mode returned error: %v", err)
+	}
+	client = newClient(t, rec, "http://127.0.0.1:0")
+	fields = mal.Fields{"my_list_status{ priority, tags }", "num_episodes"}
+	for want := 1; want <= 2; want++ {
+		a, _, err := client.Anime.Details(ctx, 1, fields)
+		if err != nil {
+			t.Fatalf("replayed Anime.Details returned error: %v", err)
+		}
+		if a.NumEpisodes != want {
+			t.Errorf("replayed Anime.Details num_episodes = %d, want %d", a.NumEpisodes, want)
+		}
+	}
+	st, _, err := client.Anime.UpdateMyListStatus(ctx, 1, mal.Score(8), mal.AnimeStatusWatching)
+	if err != nil {
+		t.Fatalf("replayed Anime.UpdateMyListStatus returned error: %v", err)
+	}
+	if st.Score != 8 {
+		t.Errorf("replayed Anime.UpdateMyListStatus score = %d, want 8", st.Score)
+	}
+	_, resp, err := client.Anime.Details(ctx, 2)
+	if err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("replayed Anime.Details of missing anime returned %v, want 404 error", err)
+	}
+
+	_, _, err = client.Anime.Details(ctx, 1, fields)
+	if !IsMissing(err) {
+		t.Errorf("Anime.Details after all interactions were replayed returned %v, want missing interaction error", err)
+	}
+	_, _, err = client.Anime.UpdateMyListStatus(ctx, 1, mal.Score(9))
+	if !IsMissing(err) {
+		t.Errorf("Anime.UpdateMyListStatus with other body returned %v, want missing interaction error", err)
+	}
+}
+
+func form(code string) func(v *url.Values) {
+	return func(v *url.Values) {
+		v.Set("code", code)
+		v.Set("grant_type", "authorization_code")
+	}
+}
+
+func TestRecordRedact(t *testing.T) {
+	rec, client, mux, path := setup(t)
+	ctx := context.Background()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			t.Error("recorder did not pass the Authorization header")
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.Form.Get("code") != "abc" {
+			t.Errorf("recorder did not pass the body, form: %v", r.Form)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"token_type":"Bearer","access_token": "at-123","refresh_token":"rt-456","name":"foo"}`)
+	})
+
+	req, err := client.NewRequest(http.MethodPost, "token?client_id=my-client", form("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer at-000")
+	v := make(map[string]string)
+	if _, err := client.Do(ctx, req, &v); err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	if v["access_token"] != "at-123" {
+		t.Errorf("recorded response access_token = %q, want the real token", v["access_token"])
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"at-000", "at-123", "rt-456", "my-client", "abc", "Authorization"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "authorization_code") || !strings.Contains(string(data), `\"name\":\"foo\"`) {
+		t.Errorf("cassette redacted more than the secrets:\n%s", data)
+	}
+
+	rec, err = New(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = newClient(t, rec, "http://127.0.0.1:0")
+	req, err = client.NewRequest(http.MethodPost, "token?client_id=other-client", form("xyz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v = make(map[string]string)
+	if _, err := client.Do(ctx, req, &v); err != nil {
+		t.Fatalf("replayed Do with other secrets returned error: %v", err)
+	}
+	if v["access_token"] != Redacted {
+		t.Errorf("replayed access_token = %q, want %q", v["access_token"], Redacted)
+	}
+}
+
+func TestNewReplayMissingCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"), Replay)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("New of missing cassette returned %v, want not exist error", err)
+	}
+	if _, err := New("cassette.json", Mode(5)); err == nil {
+		t.Error("New with unknown mode expected error, got no error.")
+	}
+}
+
+func TestNormalizeFields(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"title", "title"},
+		{"synopsis, my_list_status{tags,priority}", "my_list_status{priority,tags},synopsis"},
+		{"list_status{ tags , start_date },node{ my_list_status{b,a}, id }", "list_status{start_date,tags},node{id,my_list_status{a,b}}"},
+		{"b,,a,", "a,b"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeFields(tt.in); got != tt.want {
+			t.Errorf("NormalizeFields(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package resolve
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// title is a title split into its base and the season and part markers that
+// tell the entries of a series apart.
+type title struct {
+	// base is the normalized title without the markers.
+	base string
+	// season is the season number or zero when the title has none, which
+	// usually means the first season.
+	season int
+	// part is the part or cour number or zero when the title has none.
+	part int
+	// final is set for titles such as "The Final Season".
+	final bool
+}
+
+// romanization replaces the spellings that differ between romanizations of
+// Japanese, such as "Kyoujin", "Kyōjin" and "Kyojin", with a single one. It
+// is applied to titles in the form returned by cleanup.
+var romanization = strings.NewReplacer(
+	"ā", "a", "ī", "i", "ū", "u", "ē", "e", "ō", "o",
+	"â", "a", "î", "i", "û", "u", "ê", "e", "ô", "o",
+	"ou", "o", "oo", "o", "uu", "u",
+	" wo ", " o ", " and ", " ",
+)
+
+var ordinals = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5,
+	"sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10,
+}
+
+var romanNumerals = map[string]int{
+	"ii": 2, "iii": 3, "iv": 4, "v": 5, "vi": 6, "vii": 7, "viii": 8, "ix": 9, "x": 10,
+}
+
+// number matches a number written with digits, roman numerals or words.
+const number = `(\d+|[ivx]+|first|second|third|fourth|fifth|sixth|seventh|eighth|ninth|tenth)`
+
+var (
+	// The markers are matched against the lowercase title with punctuation
+	// replaced by spaces and surrounding spaces.
+	seasonRE = regexp.MustCompile(` (?:season ` + number + `|s(\d+)|` + number + `(?:st|nd|rd|th)? season) `)
+	partRE   = regexp.MustCompile(` (?:part|cour|pt) ` + number + ` `)
+	finalRE  = regexp.MustCompile(` (?:the )?final season `)
+	// trailingRE matches a number or roman numeral at the end of a title,
+	// as in "Mob Psycho 100 II", which is taken as the season when the title
+	// has no other marker.
+	trailingRE = regexp.MustCompile(` ([2-9]|ii|iii|iv|v|vi|vii|viii|ix) $`)
+	// shorthandRE matches season markers such as "S3".
+	shorthandRE = regexp.MustCompile(`(?i)\bs(\d+)\b`)
+)
+
+func parseNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	if n, ok := ordinals[s]; ok {
+		return n
+	}
+	return romanNumerals[s]
+}
+
+// cleanup lowercases s and replaces punctuation with spaces, leaving single
+// spaces between words and a space at both ends.
+func cleanup(s string) string {
+	var b strings.Builder
+	b.WriteByte(' ')
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if r == '\'' || r == '’' {
+			continue
+		}
+		if r == '&' {
+			b.WriteString(" and ")
+			space = true
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	if !space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// parseTitle normalizes s and extracts its season and part markers.
+func parseTitle(s string) title {
+	var t title
+	c := cleanup(s)
+	if m := finalRE.FindStringIndex(c); m != nil {
+		t.final = true
+		c = c[:m[0]] + " " + c[m[1]:]
+	}
+	if m := seasonRE.FindStringSubmatchIndex(c); m != nil {
+		for i := 2; i < len(m); i += 2 {
+			if m[i] >= 0 {
+				t.season = parseNumber(c[m[i]:m[i+1]])
+				break
+			}
+		}
+		c = c[:m[0]] + " " + c[m[1]:]
+	}
+	if m := partRE.FindStringSubmatchIndex(c); m != nil {
+		t.part = parseNumber(c[m[2]:m[3]])
+		c = c[:m[0]] + " " + c[m[1]:]
+	}
+	if t.season == 0 && !t.final {
+		if m := trailingRE.FindStringSubmatchIndex(c); m != nil {
+			t.season = parseNumber(c[m[2]:m[3]])
+			c = c[:m[0]] + " "
+		}
+	}
+	t.base = strings.Join(strings.Fields(romanization.Replace(c)), " ")
+	return t
+}
+
+// queries returns the search queries for s: s itself with shorthand season
+// markers such as "S3" spelled out the way MyAnimeList titles spell them and
+// s without any markers, if they differ. The search of the API does not
+// normalize romanization so the original spelling is kept.
+func queries(s string) []string {
+	var qq []string
+	add := func(q string) {
+		q = strings.Join(strings.Fields(q), " ")
+		if len(q) < minQuery {
+			return
+		}
+		for _, o := range qq {
+			if strings.EqualFold(o, q) {
+				return
+			}
+		}
+		qq = append(qq, q)
+	}
+	add(shorthandRE.ReplaceAllString(s, "Season $1"))
+
+	t := parseTitle(s)
+	c := cleanup(s)
+	for _, re := range []*regexp.Regexp{finalRE, seasonRE, partRE} {
+		c = re.ReplaceAllString(c, " ")
+	}
+	if t.season != 0 && !seasonRE.MatchString(cleanup(s)) {
+		c = trailingRE.ReplaceAllString(c, " ")
+	}
+	add(c)
+	return qq
+}
+
+// similarity returns how similar the normalized strings a and b are from 0
+// to 1 as the Dice coefficient of their letter pairs.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	pa, pb := bigrams(a), bigrams(b)
+	if len(pa) == 0 || len(pb) == 0 {
+		return 0
+	}
+	counts := make(map[[2]rune]int, len(pa))
+	for _, p := range pa {
+		counts[p]++
+	}
+	common := 0
+	for _, p := range pb {
+		if counts[p] > 0 {
+			counts[p]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(pa)+len(pb))
+}
+
+// bigrams returns the pairs of adjacent letters of the words of s.
+func bigrams(s string) [][2]rune {
+	var pairs [][2]rune
+	for _, w := range strings.Fields(s) {
+		r := []rune(w)
+		if len(r) == 1 {
+			pairs = append(pairs, [2]rune{r[0], ' '})
+		}
+		for i := 0; i+1 < len(r); i++ {
+			pairs = append(pairs, [2]rune{r[i], r[i+1]})
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,72 @@
+package resolve
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTitle(t *testing.T) {
+	tests := []struct {
+		in   string
+		want title
+	}{
+		{"Shingeki no Kyojin", title{base: "shingeki no kyojin"}},
+		{"Shingeki no Kyoujin S3 Part 2", title{base: "shingeki no kyojin", season: 3, part: 2}},
+		{"Shingeki no Kyōjin Season 3 Part.2", title{base: "shingeki no kyojin", season: 3, part: 2}},
+		{"Shingeki no Kyojin: The Final Season", title{base: "shingeki no kyojin", final: true}},
+		{"Re:Zero kara Hajimeru Isekai Seikatsu 2nd Season", title{base: "re zero kara hajimeru isekai seikatsu", season: 2}},
+		{"Kimetsu no Yaiba Third Season", title{base: "kimetsu no yaiba", season: 3}},
+		{"Vinland Saga Season II", title{base: "vinland saga", season: 2}},
+		{"Mob Psycho 100 II", title{base: "mob psycho 100", season: 2}},
+		{"Boku no Hero Academia 5", title{base: "boku no hero academia", season: 5}},
+		{"Steins;Gate 0", title{base: "steins gate 0"}},
+		{"Kono Subarashii Sekai ni Shukufuku wo!", title{base: "kono subarashii sekai ni shukufuku o"}},
+		{"Spy × Family", title{base: "spy family"}},
+		{"Tom & Jerry", title{base: "tom jerry"}},
+		{"Tom and Jerry", title{base: "tom jerry"}},
+		{"Ore no Imouto ga Konnani Kawaii Wake ga Nai.", title{base: "ore no imoto ga konnani kawaii wake ga nai"}},
+		{"Sword Art Online: Alicization - War of Underworld Cour 2", title{base: "sword art online alicization war of underworld", part: 2}},
+		{"進撃の巨人 Season 3 Part.2", title{base: "進撃の巨人", season: 3, part: 2}},
+	}
+	for _, tt := range tests {
+		if got := parseTitle(tt.in); got != tt.want {
+			t.Errorf("parseTitle(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQueries(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Shingeki no Kyojin S3 Part 2", []string{"Shingeki no Kyojin Season 3 Part 2", "shingeki no kyojin"}},
+		{"Mob Psycho 100 II", []string{"Mob Psycho 100 II", "mob psycho 100"}},
+		{"  Trigun ", []string{"Trigun"}},
+		{"Ao S2", []string{"Ao Season 2"}},
+		{"K", nil},
+	}
+	for _, tt := range tests {
+		if got := queries(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("queries(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"trigun", "trigun", 1},
+		{"trigun", "naruto", 0},
+		{"night", "nacht", 0.25},
+		{"a b", "a c", 0.5},
+		{"", "trigun", 0},
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
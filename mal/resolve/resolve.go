@@ -0,0 +1,304 @@
+// Package resolve maps free text titles, such as the titles reported by media
+// players, to MyAnimeList anime and manga.
+//
+// The title is searched with the API and the results are scored against
+// their title, English and Japanese titles and synonyms after normalizing
+// punctuation, romanization variants and season and part markers, so that
+// "Shingeki no Kyoujin S3 Part 2" matches "Shingeki no Kyojin Season 3 Part
+// 2". Hints such as the media type or the year adjust the confidence:
+//
+//	r := resolve.New(c)
+//	m, ok, err := r.BestAnime(ctx, "Shingeki no Kyojin S3 Part 2", resolve.Hints{Episode: 5})
+//	if err != nil {
+//		return err
+//	}
+//	if !ok {
+//		// Not confident enough to link automatically.
+//	}
+//	fmt.Println(m.Anime.ID, m.Confidence)
+package resolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// Hints are what is known about an entry besides its title. Zero values are
+// ignored.
+type Hints struct {
+	// MediaType is the media type such as "tv", "movie" or "manga".
+	MediaType string
+	// Year is the year the anime started airing or the manga started
+	// publishing.
+	Year int
+	// Episode is an episode that the anime has, such as the episode being
+	// watched.
+	Episode int
+	// Chapter is a chapter that the manga has.
+	Chapter int
+}
+
+// AnimeMatch is an anime that matches a title.
+type AnimeMatch struct {
+	Anime mal.Anime
+	// MatchedTitle is the title, alternative title or synonym of the anime
+	// that matched best.
+	MatchedTitle string
+	// Confidence is how likely the anime is the one meant by the title from
+	// 0 to 1.
+	Confidence float64
+}
+
+// MangaMatch is a manga that matches a title.
+type MangaMatch struct {
+	Manga mal.Manga
+	// MatchedTitle is the title, alternative title or synonym of the manga
+	// that matched best.
+	MatchedTitle string
+	// Confidence is how likely the manga is the one meant by the title from
+	// 0 to 1.
+	Confidence float64
+}
+
+const (
+	defaultThreshold = 0.85
+	defaultLimit     = 20
+	// minQuery is the shortest search query allowed by the API.
+	minQuery = 3
+)
+
+// The factors that the confidence is multiplied with when a marker or hint
+// does not match.
+const (
+	seasonMismatch    = 0.6
+	partMismatch      = 0.8
+	finalMismatch     = 0.8
+	mediaTypeMismatch = 0.8
+	nearYear          = 0.9
+	yearMismatch      = 0.7
+	numberMismatch    = 0.5
+)
+
+// ErrShortTitle is returned when a title is too short to be searched.
+var ErrShortTitle = errors.New("resolve: title is too short to search")
+
+var (
+	animeFields = mal.Fields{"alternative_titles", "media_type", "start_date", "start_season", "num_episodes"}
+	mangaFields = mal.Fields{"alternative_titles", "media_type", "start_date", "num_chapters", "num_volumes"}
+)
+
+// Resolver resolves titles. New returns a Resolver with the default settings
+// which can be changed before using it.
+type Resolver struct {
+	// Threshold is the lowest confidence of the matches returned by
+	// BestAnime and BestManga. New sets it to 0.85.
+	Threshold float64
+	// Limit is the number of results requested by each search. New sets it
+	// to 20.
+	Limit int
+	// NSFW includes entries that are not safe for work in the searches.
+	NSFW bool
+
+	anime *mal.AnimeService
+	manga *mal.MangaService
+}
+
+// New returns a Resolver that uses c to search anime and manga.
+func New(c *mal.Client) *Resolver {
+	return &Resolver{
+		Threshold: defaultThreshold,
+		Limit:     defaultLimit,
+		anime:     c.Anime,
+		manga:     c.Manga,
+	}
+}
+
+func (r *Resolver) options(fields mal.Fields) []mal.Option {
+	oo := []mal.Option{fields}
+	if r.Limit > 0 {
+		oo = append(oo, mal.Limit(r.Limit))
+	}
+	if r.NSFW {
+		oo = append(oo, mal.NSFW(true))
+	}
+	return oo
+}
+
+// Anime returns the anime that match text, best first. The title is searched
+// as given and without its season and part markers, since the search of the
+// API often misses titles with markers spelled differently.
+func (r *Resolver) Anime(ctx context.Context, text string, hints Hints) ([]AnimeMatch, error) {
+	qq := queries(text)
+	if len(qq) == 0 {
+		return nil, ErrShortTitle
+	}
+	q := parseTitle(text)
+	seen := make(map[int]bool)
+	var matches []AnimeMatch
+	for _, query := range qq {
+		found, _, err := r.anime.List(ctx, query, r.options(animeFields)...)
+		if err != nil {
+			return nil, fmt.Errorf("searching anime %q: %w", query, err)
+		}
+		for _, a := range found {
+			if seen[a.ID] {
+				continue
+			}
+			seen[a.ID] = true
+			m := AnimeMatch{Anime: a}
+			m.Confidence, m.MatchedTitle = bestTitle(q, a.Title, a.AlternativeTitles)
+			year := a.StartSeason.Year
+			if year == 0 {
+				year = startYear(a.StartDate)
+			}
+			m.Confidence *= hintFactor(hints.MediaType, a.MediaType, hints.Year, year, hints.Episode, a.NumEpisodes)
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Confidence != matches[j].Confidence {
+			return matches[i].Confidence > matches[j].Confidence
+		}
+		return matches[i].Anime.ID < matches[j].Anime.ID
+	})
+	return matches, nil
+}
+
+// BestAnime returns the best anime match for text. It reports false if
+// there is no match with at least the Threshold confidence.
+func (r *Resolver) BestAnime(ctx context.Context, text string, hints Hints) (AnimeMatch, bool, error) {
+	matches, err := r.Anime(ctx, text, hints)
+	if err != nil {
+		return AnimeMatch{}, false, err
+	}
+	if len(matches) == 0 || matches[0].Confidence < r.Threshold {
+		return AnimeMatch{}, false, nil
+	}
+	return matches[0], true, nil
+}
+
+// Manga returns the manga that match text, best first, like Anime.
+func (r *Resolver) Manga(ctx context.Context, text string, hints Hints) ([]MangaMatch, error) {
+	qq := queries(text)
+	if len(qq) == 0 {
+		return nil, ErrShortTitle
+	}
+	q := parseTitle(text)
+	seen := make(map[int]bool)
+	var matches []MangaMatch
+	for _, query := range qq {
+		found, _, err := r.manga.List(ctx, query, r.options(mangaFields)...)
+		if err != nil {
+			return nil, fmt.Errorf("searching manga %q: %w", query, err)
+		}
+		for _, m := range found {
+			if seen[m.ID] {
+				continue
+			}
+			seen[m.ID] = true
+			mm := MangaMatch{Manga: m}
+			mm.Confidence, mm.MatchedTitle = bestTitle(q, m.Title, m.AlternativeTitles)
+			mm.Confidence *= hintFactor(hints.MediaType, m.MediaType, hints.Year, startYear(m.StartDate), hints.Chapter, m.NumChapters)
+			matches = append(matches, mm)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Confidence != matches[j].Confidence {
+			return matches[i].Confidence > matches[j].Confidence
+		}
+		return matches[i].Manga.ID < matches[j].Manga.ID
+	})
+	return matches, nil
+}
+
+// BestManga returns the best manga match for text. It reports false if
+// there is no match with at least the Threshold confidence.
+func (r *Resolver) BestManga(ctx context.Context, text string, hints Hints) (MangaMatch, bool, error) {
+	matches, err := r.Manga(ctx, text, hints)
+	if err != nil {
+		return MangaMatch{}, false, err
+	}
+	if len(matches) == 0 || matches[0].Confidence < r.Threshold {
+		return MangaMatch{}, false, nil
+	}
+	return matches[0], true, nil
+}
+
+// bestTitle returns the confidence of the title of an entry that matches q
+// best along with that title.
+func bestTitle(q title, main string, alt mal.Titles) (float64, string) {
+	best, bestTitle := 0.0, ""
+	for _, t := range append([]string{main, alt.En, alt.Ja}, alt.Synonyms...) {
+		if t == "" {
+			continue
+		}
+		if c := match(q, parseTitle(t)); c > best {
+			best, bestTitle = c, t
+		}
+	}
+	return best, bestTitle
+}
+
+// match returns how well the title c matches q from 0 to 1. A title without
+// a season or part number is taken as the first season or part.
+func match(q, c title) float64 {
+	conf := similarity(q.base, c.base)
+	if orOne(q.season) != orOne(c.season) {
+		conf *= seasonMismatch
+	}
+	if orOne(q.part) != orOne(c.part) {
+		conf *= partMismatch
+	}
+	if q.final != c.final {
+		conf *= finalMismatch
+	}
+	return conf
+}
+
+func orOne(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// hintFactor returns the factor that the confidence of an entry is
+// multiplied with for the hints that it does not match. The number is an
+// episode or chapter which should not be more than the total of the entry,
+// when the total is known.
+func hintFactor(wantType, mediaType string, wantYear, year, number, total int) float64 {
+	f := 1.0
+	if wantType != "" && mediaType != "" && !strings.EqualFold(wantType, mediaType) {
+		f *= mediaTypeMismatch
+	}
+	if wantYear != 0 && year != 0 && wantYear != year {
+		if d := wantYear - year; d == 1 || d == -1 {
+			f *= nearYear
+		} else {
+			f *= yearMismatch
+		}
+	}
+	if number > 0 && total > 0 && number > total {
+		f *= numberMismatch
+	}
+	return f
+}
+
+// startYear returns the year of a date such as "2006-01-02", "2006-01" or
+// "2006" or zero.
+func startYear(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	y, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0
+	}
+	return y
+}
@@ -0,0 +1,175 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/nstratos/go-myanimelist/mal"
+)
+
+// setup sets up a test HTTP server along with a mal.Client that is configured
+// to talk to that test server.
+func setup() (client *mal.Client, mux *http.ServeMux, teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client = mal.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, mux, server.Close
+}
+
+const titansJSON = `{"data": [
+	{"node": {"id": 16498, "title": "Shingeki no Kyojin", "alternative_titles": {"en": "Attack on Titan", "ja": "進撃の巨人"}, "media_type": "tv", "start_season": {"year": 2013}, "num_episodes": 25}},
+	{"node": {"id": 25777, "title": "Shingeki no Kyojin Season 2", "alternative_titles": {"en": "Attack on Titan Season 2"}, "media_type": "tv", "start_season": {"year": 2017}, "num_episodes": 12}},
+	{"node": {"id": 35760, "title": "Shingeki no Kyojin Season 3", "alternative_titles": {"en": "Attack on Titan Season 3"}, "media_type": "tv", "start_season": {"year": 2018}, "num_episodes": 12}},
+	{"node": {"id": 38524, "title": "Shingeki no Kyojin Season 3 Part 2", "alternative_titles": {"en": "Attack on Titan Season 3 Part 2", "synonyms": ["SnK S3 P2"]}, "media_type": "tv", "start_season": {"year": 2019}, "num_episodes": 10}},
+	{"node": {"id": 40028, "title": "Shingeki no Kyojin: The Final Season", "alternative_titles": {"en": "Attack on Titan: Final Season"}, "media_type": "tv", "start_date": "2020-12-07", "num_episodes": 16}},
+	{"node": {"id": 25781, "title": "Shingeki no Kyojin: Kuinaki Sentaku", "media_type": "ova", "start_season": {"year": 2014}, "num_episodes": 2}}
+]}`
+
+// serveSearch serves the anime search with the titles of the Attack on Titan
+// franchise for every query and records the queries.
+func serveSearch(t *testing.T, mux *http.ServeMux) func() []string {
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	mux.HandleFunc("/anime", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if got, want := q.Get("fields"), "alternative_titles,media_type,start_date,start_season,num_episodes"; got != want {
+			t.Errorf("fields = %q, want %q", got, want)
+		}
+		if got, want := q.Get("limit"), "20"; got != want {
+			t.Errorf("limit = %q, want %q", got, want)
+		}
+		mu.Lock()
+		queries = append(queries, q.Get("q"))
+		mu.Unlock()
+		fmt.Fprint(w, titansJSON)
+	})
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return queries
+	}
+}
+
+func TestResolverAnime(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	queries := serveSearch(t, mux)
+
+	r := New(client)
+	matches, err := r.Anime(context.Background(), "Shingeki no Kyoujin S3 Part 2", Hints{})
+	if err != nil {
+		t.Fatalf("Anime returned error: %v", err)
+	}
+	if want := []string{"Shingeki no Kyoujin Season 3 Part 2", "shingeki no kyoujin"}; !reflect.DeepEqual(queries(), want) {
+		t.Errorf("Anime searched %q, want %q", queries(), want)
+	}
+	if len(matches) != 6 {
+		t.Fatalf("Anime returned %d matches, want each anime once", len(matches))
+	}
+	best := matches[0]
+	if best.Anime.ID != 38524 || best.Confidence != 1 || best.MatchedTitle != "Shingeki no Kyojin Season 3 Part 2" {
+		t.Errorf("Anime best match = %d %q with confidence %v, want 38524 with confidence 1", best.Anime.ID, best.MatchedTitle, best.Confidence)
+	}
+	if got := matches[1].Anime.ID; got != 35760 {
+		t.Errorf("Anime second match = %d, want the other part of the season 35760", got)
+	}
+	for i := 1; i < len(matches); i++ {
+		if matches[i].Confidence > matches[i-1].Confidence {
+			t.Errorf("Anime matches are not sorted by confidence: %v after %v", matches[i].Confidence, matches[i-1].Confidence)
+		}
+	}
+}
+
+func TestResolverBestAnime(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	serveSearch(t, mux)
+
+	tests := []struct {
+		text   string
+		hints  Hints
+		wantID int
+		wantOK bool
+	}{
+		{"Attack on Titan Season 3 Part 2", Hints{}, 38524, true},
+		{"Attack on Titan - The Final Season", Hints{Year: 2020}, 40028, true},
+		{"Shingeki no Kyojin", Hints{Episode: 20, MediaType: "tv"}, 16498, true},
+		{"進撃の巨人", Hints{}, 16498, true},
+		{"Shingeki no Kyojin Season 3", Hints{Year: 2018, Episode: 12}, 35760, true},
+		// No anime of the season has 13 episodes.
+		{"Shingeki no Kyojin Season 3", Hints{Episode: 13}, 0, false},
+		// The first season is not a movie.
+		{"Shingeki no Kyojin", Hints{MediaType: "movie"}, 0, false},
+		{"Fullmetal Alchemist", Hints{}, 0, false},
+	}
+	r := New(client)
+	for _, tt := range tests {
+		m, ok, err := r.BestAnime(context.Background(), tt.text, tt.hints)
+		if err != nil {
+			t.Errorf("BestAnime(%q) returned error: %v", tt.text, err)
+			continue
+		}
+		if ok != tt.wantOK || m.Anime.ID != tt.wantID {
+			t.Errorf("BestAnime(%q, %+v) = %d with confidence %v, %v, want %d, %v", tt.text, tt.hints, m.Anime.ID, m.Confidence, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
+
+func TestResolverManga(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/manga", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("nsfw"), "true"; got != want {
+			t.Errorf("nsfw = %q, want %q", got, want)
+		}
+		fmt.Fprint(w, `{"data": [
+			{"node": {"id": 2, "title": "Berserk", "media_type": "manga", "start_date": "1989-08-25", "num_chapters": 0}},
+			{"node": {"id": 92299, "title": "Berserk: Shinen no Kami", "media_type": "light_novel", "start_date": "2016"}}
+		]}`)
+	})
+
+	r := New(client)
+	r.NSFW = true
+	m, ok, err := r.BestManga(context.Background(), "BERSERK!", Hints{MediaType: "manga", Year: 1989, Chapter: 370})
+	if err != nil {
+		t.Fatalf("BestManga returned error: %v", err)
+	}
+	if !ok || m.Manga.ID != 2 || m.Confidence != 1 {
+		t.Errorf("BestManga = %d with confidence %v, %v, want 2 with confidence 1", m.Manga.ID, m.Confidence, ok)
+	}
+	matches, err := r.Manga(context.Background(), "Berserk", Hints{Year: 1990})
+	if err != nil {
+		t.Fatalf("Manga returned error: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Confidence != nearYear {
+		t.Errorf("Manga matches = %+v, want the first with confidence %v", matches, nearYear)
+	}
+}
+
+func TestResolverErrors(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/anime", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"bad_request","message":"invalid q"}`, http.StatusBadRequest)
+	})
+
+	r := New(client)
+	if _, err := r.Anime(context.Background(), "K!", Hints{}); err != ErrShortTitle {
+		t.Errorf("Anime with short title returned error %v, want %v", err, ErrShortTitle)
+	}
+	_, _, err := r.BestAnime(context.Background(), "Trigun", Hints{})
+	var errResp *mal.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != http.StatusBadRequest {
+		t.Errorf("BestAnime returned error %v, want the error response of the search", err)
+	}
+}
@@ -0,0 +1,346 @@
+package scrobble
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The sources of the events.
+const (
+	Plex     = "plex"
+	Jellyfin = "jellyfin"
+	Generic  = "generic"
+)
+
+// The sites of the external IDs of the events.
+const (
+	SiteMAL     = "mal"
+	SiteAniDB   = "anidb"
+	SiteAniList = "anilist"
+	SiteKitsu   = "kitsu"
+	SiteTVDB    = "tvdb"
+	SiteTMDB    = "tmdb"
+	SiteIMDB    = "imdb"
+)
+
+// Event is a playback event of a media server.
+type Event struct {
+	// Source is the format of the payload: Plex, Jellyfin or Generic.
+	Source string
+	// ID identifies the event when the payload has an ID. Events with the
+	// same ID are handled once.
+	ID string
+	// User is the name of the user of the media server.
+	User string
+	// Watched reports whether the event marks the media as watched. Other
+	// events are ignored.
+	Watched bool
+	// Movie reports whether the media is a movie rather than an episode.
+	Movie bool
+	// Title is the title of the series of an episode or of a movie.
+	Title string
+	// Season and Episode are the numbers of an episode.
+	Season  int
+	Episode int
+	// Year is the year of a movie or the year an episode aired.
+	Year int
+	// IDs are the IDs of the series or the movie on other sites, such as
+	// SiteAniDB, keyed by site.
+	IDs map[string]string
+}
+
+// maxPayload is the largest payload accepted. Plex sends a thumbnail along
+// with the payload which is skipped without being read into memory.
+const maxPayload = 1 << 20
+
+// parseEvent parses the payload of a webhook request. Plex sends a multipart
+// form with the JSON payload in the payload field while Jellyfin and the
+// generic format send JSON that is told apart by its fields.
+func parseEvent(r *http.Request) (Event, error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "application/json"
+	}
+	if mediaType == "multipart/form-data" {
+		data, err := plexPayload(r.Body, params["boundary"])
+		if err != nil {
+			return Event{}, err
+		}
+		return parsePlex(data)
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxPayload+1))
+	if err != nil {
+		return Event{}, err
+	}
+	if len(data) > maxPayload {
+		return Event{}, errors.New("payload is too large")
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Event{}, fmt.Errorf("invalid JSON payload: %v", err)
+	}
+	switch {
+	case fields["NotificationType"] != nil:
+		return parseJellyfin(data)
+	case fields["event"] != nil && fields["Metadata"] != nil:
+		return parsePlex(data)
+	case fields["event"] != nil:
+		return parseGeneric(data)
+	}
+	return Event{}, errors.New("unknown payload format")
+}
+
+// plexPayload returns the payload field of a Plex multipart form.
+func plexPayload(body io.Reader, boundary string) ([]byte, error) {
+	if boundary == "" {
+		return nil, errors.New("multipart form without boundary")
+	}
+	mr := multipart.NewReader(body, boundary)
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, errors.New("multipart form without payload field")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.FormName() != "payload" {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(p, maxPayload+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxPayload {
+			return nil, errors.New("payload is too large")
+		}
+		return data, nil
+	}
+}
+
+// plexEvent is the part of the payload of the Plex webhooks that is used.
+type plexEvent struct {
+	Event   string `json:"event"`
+	Account struct {
+		Title string `json:"title"`
+	} `json:"Account"`
+	Metadata struct {
+		Type             string `json:"type"`
+		Title            string `json:"title"`
+		GrandparentTitle string `json:"grandparentTitle"`
+		ParentIndex      int    `json:"parentIndex"`
+		Index            int    `json:"index"`
+		Year             int    `json:"year"`
+		GUID             string `json:"guid"`
+		GrandparentGUID  string `json:"grandparentGuid"`
+		GUIDs            []struct {
+			ID string `json:"id"`
+		} `json:"Guid"`
+	} `json:"Metadata"`
+}
+
+func parsePlex(data []byte) (Event, error) {
+	var p plexEvent
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Event{}, fmt.Errorf("invalid Plex payload: %v", err)
+	}
+	m := p.Metadata
+	e := Event{
+		Source:  Plex,
+		User:    p.Account.Title,
+		Watched: p.Event == "media.scrobble",
+		Movie:   m.Type == "movie",
+		Year:    m.Year,
+		IDs:     make(map[string]string),
+	}
+	if e.Movie {
+		e.Title = m.Title
+		addGUID(e.IDs, m.GUID)
+		for _, g := range m.GUIDs {
+			addGUID(e.IDs, g.ID)
+		}
+	} else {
+		// The GUIDs of an episode identify the episode so only the GUID of
+		// the show is used.
+		e.Title = m.GrandparentTitle
+		e.Season = m.ParentIndex
+		e.Episode = m.Index
+		addGUID(e.IDs, m.GrandparentGUID)
+	}
+	return e, nil
+}
+
+// legacyGUIDRE matches the GUIDs of the legacy Plex agents such as
+// "com.plexapp.agents.hama://anidb-1234/1/5?lang=en" or
+// "com.plexapp.agents.thetvdb://81797?lang=en".
+var legacyGUIDRE = regexp.MustCompile(`^com\.plexapp\.agents\.([a-z]+)://(?:([a-z]+)-)?([0-9]+)`)
+
+// addGUID adds the site and ID of a Plex GUID such as "tvdb://81797" to ids.
+func addGUID(ids map[string]string, guid string) {
+	site, id := "", ""
+	if m := legacyGUIDRE.FindStringSubmatch(guid); m != nil {
+		site, id = m[1], m[3]
+		if m[2] != "" {
+			site = m[2]
+		}
+	} else if i := strings.Index(guid, "://"); i > 0 {
+		site, id = guid[:i], guid[i+3:]
+	}
+	switch site {
+	case "thetvdb":
+		site = SiteTVDB
+	case "themoviedb":
+		site = SiteTMDB
+	case "myanimelist":
+		site = SiteMAL
+	}
+	switch site {
+	case SiteMAL, SiteAniDB, SiteAniList, SiteKitsu, SiteTVDB, SiteTMDB, SiteIMDB:
+		if _, ok := ids[site]; !ok && id != "" {
+			ids[site] = id
+		}
+	}
+}
+
+// jellyfinEvent is the part of the payload of the Jellyfin webhook plugin
+// that is used. The provider IDs are sent as fields such as Provider_tvdb.
+type jellyfinEvent struct {
+	NotificationType     string `json:"NotificationType"`
+	NotificationUsername string `json:"NotificationUsername"`
+	ItemType             string `json:"ItemType"`
+	Name                 string `json:"Name"`
+	SeriesName           string `json:"SeriesName"`
+	SeasonNumber         int    `json:"SeasonNumber"`
+	EpisodeNumber        int    `json:"EpisodeNumber"`
+	Year                 int    `json:"Year"`
+	PlayedToCompletion   bool   `json:"PlayedToCompletion"`
+	Played               bool   `json:"Played"`
+	SaveReason           string `json:"SaveReason"`
+}
+
+func parseJellyfin(data []byte) (Event, error) {
+	var j jellyfinEvent
+	if err := json.Unmarshal(data, &j); err != nil {
+		return Event{}, fmt.Errorf("invalid Jellyfin payload: %v", err)
+	}
+	e := Event{
+		Source: Jellyfin,
+		User:   j.NotificationUsername,
+		Watched: j.NotificationType == "PlaybackStop" && j.PlayedToCompletion ||
+			j.NotificationType == "UserDataSaved" && j.SaveReason == "TogglePlayed" && j.Played,
+		Movie: j.ItemType == "Movie",
+		Year:  j.Year,
+		IDs:   make(map[string]string),
+	}
+	if e.Movie {
+		e.Title = j.Name
+		// The provider IDs are the IDs of the item, which for episodes are
+		// the IDs of the episode rather than the series, so they are only
+		// used for movies.
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return Event{}, fmt.Errorf("invalid Jellyfin payload: %v", err)
+		}
+		for k, v := range fields {
+			if !strings.HasPrefix(k, "Provider_") {
+				continue
+			}
+			var id idText
+			if err := json.Unmarshal(v, &id); err != nil || id == "" {
+				continue
+			}
+			site := strings.ToLower(strings.TrimPrefix(k, "Provider_"))
+			if site == "myanimelist" {
+				site = SiteMAL
+			}
+			e.IDs[site] = string(id)
+		}
+	} else {
+		e.Title = j.SeriesName
+		e.Season = j.SeasonNumber
+		e.Episode = j.EpisodeNumber
+	}
+	return e, nil
+}
+
+// genericEvent is the generic payload for media servers and scripts that
+// can send custom JSON:
+//
+//	{
+//		"event": "watched",
+//		"id": "optional unique event ID",
+//		"user": "alice",
+//		"title": "Cowboy Bebop",
+//		"season": 1,
+//		"episode": 5,
+//		"year": 1998,
+//		"movie": false,
+//		"ids": {"mal": "1", "anidb": "23"}
+//	}
+type genericEvent struct {
+	Event   string            `json:"event"`
+	ID      string            `json:"id"`
+	User    string            `json:"user"`
+	Title   string            `json:"title"`
+	Season  int               `json:"season"`
+	Episode int               `json:"episode"`
+	Year    int               `json:"year"`
+	Movie   bool              `json:"movie"`
+	IDs     map[string]idText `json:"ids"`
+}
+
+// idText is an ID that may be sent as a JSON string or number.
+type idText string
+
+func (t *idText) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		*t = idText(n.String())
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("ID must be a string or a number, got %s", data)
+	}
+	*t = idText(s)
+	return nil
+}
+
+func parseGeneric(data []byte) (Event, error) {
+	var g genericEvent
+	if err := json.Unmarshal(data, &g); err != nil {
+		return Event{}, fmt.Errorf("invalid payload: %v", err)
+	}
+	e := Event{
+		Source:  Generic,
+		ID:      g.ID,
+		User:    g.User,
+		Watched: g.Event == "watched" || g.Event == "scrobble",
+		Movie:   g.Movie,
+		Title:   g.Title,
+		Season:  g.Season,
+		Episode: g.Episode,
+		Year:    g.Year,
+		IDs:     make(map[string]string),
+	}
+	for site, id := range g.IDs {
+		if id != "" {
+			e.IDs[strings.ToLower(site)] = string(id)
+		}
+	}
+	return e, nil
+}
+
+// malID returns the MyAnimeList ID of the event if it has one.
+func (e Event) malID() (int, bool) {
+	id, err := strconv.Atoi(e.IDs[SiteMAL])
+	return id, err == nil && id > 0
+}
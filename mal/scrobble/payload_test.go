@@ -0,0 +1,149 @@
+package scrobble
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const plexEpisode = `{
+	"event": "media.scrobble",
+	"Account": {"id": 1, "title": "alice"},
+	"Metadata": {
+		"type": "episode",
+		"title": "First Battle",
+		"grandparentTitle": "Shingeki no Kyojin",
+		"parentIndex": 3,
+		"index": 2,
+		"year": 2018,
+		"guid": "com.plexapp.agents.hama://anidb-13241/1/2?lang=en",
+		"grandparentGuid": "com.plexapp.agents.hama://anidb-13241?lang=en",
+		"Guid": [{"id": "tvdb://6570230"}]
+	}
+}`
+
+// plexRequest returns a webhook request of Plex with payload and a thumbnail.
+func plexRequest(t *testing.T, payload string) *http.Request {
+	t.Helper()
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	if err := mw.WriteField("payload", payload); err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := mw.CreateFormFile("thumb", "thumb.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb.Write(bytes.Repeat([]byte{0xff}, 1024))
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/scrobble", &b)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func jsonRequest(payload string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/scrobble", strings.NewReader(payload))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+		want Event
+	}{
+		{
+			name: "plex episode",
+			req:  plexRequest(t, plexEpisode),
+			want: Event{Source: Plex, User: "alice", Watched: true, Title: "Shingeki no Kyojin", Season: 3, Episode: 2, Year: 2018, IDs: map[string]string{SiteAniDB: "13241"}},
+		},
+		{
+			name: "plex movie as JSON",
+			req: jsonRequest(`{"event": "media.stop", "Account": {"title": "bob"}, "Metadata": {"type": "movie", "title": "Akira", "year": 1988,
+				"guid": "plex://movie/5d776825880197001ec967c6", "Guid": [{"id": "imdb://tt0094625"}, {"id": "tmdb://149"}]}}`),
+			want: Event{Source: Plex, User: "bob", Movie: true, Title: "Akira", Year: 1988, IDs: map[string]string{SiteIMDB: "tt0094625", SiteTMDB: "149"}},
+		},
+		{
+			name: "jellyfin episode",
+			req: jsonRequest(`{"NotificationType": "PlaybackStop", "NotificationUsername": "alice", "ItemType": "Episode", "Name": "Asteroid Blues",
+				"SeriesName": "Cowboy Bebop", "SeasonNumber": 1, "EpisodeNumber": 1, "Year": 1998, "PlayedToCompletion": true, "Provider_tvdb": "76885"}`),
+			want: Event{Source: Jellyfin, User: "alice", Watched: true, Title: "Cowboy Bebop", Season: 1, Episode: 1, Year: 1998, IDs: map[string]string{}},
+		},
+		{
+			name: "jellyfin movie marked played",
+			req: jsonRequest(`{"NotificationType": "UserDataSaved", "SaveReason": "TogglePlayed", "Played": true, "NotificationUsername": "alice",
+				"ItemType": "Movie", "Name": "Cowboy Bebop: Tengoku no Tobira", "Year": 2001, "Provider_AniDB": 1, "Provider_MyAnimeList": "5", "Provider_Imdb": ""}`),
+			want: Event{Source: Jellyfin, User: "alice", Watched: true, Movie: true, Title: "Cowboy Bebop: Tengoku no Tobira", Year: 2001, IDs: map[string]string{SiteAniDB: "1", SiteMAL: "5"}},
+		},
+		{
+			name: "jellyfin stopped early",
+			req:  jsonRequest(`{"NotificationType": "PlaybackStop", "PlayedToCompletion": false, "ItemType": "Episode", "SeriesName": "Trigun", "SeasonNumber": 1, "EpisodeNumber": 3}`),
+			want: Event{Source: Jellyfin, Title: "Trigun", Season: 1, Episode: 3, IDs: map[string]string{}},
+		},
+		{
+			name: "generic",
+			req:  jsonRequest(`{"event": "watched", "id": "e1", "user": "alice", "title": "Trigun", "episode": 3, "ids": {"MAL": 6, "kitsu": "7"}}`),
+			want: Event{Source: Generic, ID: "e1", User: "alice", Watched: true, Title: "Trigun", Episode: 3, IDs: map[string]string{SiteMAL: "6", SiteKitsu: "7"}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseEvent(tt.req)
+		if err != nil {
+			t.Errorf("%s: parseEvent returned error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseEvent\nhave: %+v\nwant: %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseEventErrors(t *testing.T) {
+	noPayload := plexRequest(t, "")
+	noPayload.Header.Set("Content-Type", "multipart/form-data")
+	for _, r := range []*http.Request{
+		jsonRequest(`not json`),
+		jsonRequest(`{"hello": "world"}`),
+		jsonRequest(`{"event": "watched", "ids": {"mal": true}}`),
+		jsonRequest(`{"event": "watched", "id": "` + strings.Repeat("x", maxPayload) + `"}`),
+		noPayload,
+	} {
+		if _, err := parseEvent(r); err == nil {
+			t.Errorf("parseEvent(%.40s) returned no error", r.Header.Get("Content-Type"))
+		}
+	}
+}
+
+func TestAddGUID(t *testing.T) {
+	tests := []struct {
+		guid string
+		site string
+		id   string
+	}{
+		{"com.plexapp.agents.hama://anidb-23/1/5?lang=en", SiteAniDB, "23"},
+		{"com.plexapp.agents.hama://tvdb-76885/1/5?lang=en", SiteTVDB, "76885"},
+		{"com.plexapp.agents.thetvdb://76885?lang=en", SiteTVDB, "76885"},
+		{"com.plexapp.agents.myanimelist://1?lang=en", SiteMAL, "1"},
+		{"tmdb://30991", SiteTMDB, "30991"},
+		{"plex://show/5d9c086c46115600200aa2fe", "", ""},
+		{"local://123", "", ""},
+	}
+	for _, tt := range tests {
+		ids := make(map[string]string)
+		addGUID(ids, tt.guid)
+		want := map[string]string{}
+		if tt.site != "" {
+			want[tt.site] = tt.id
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("addGUID(%q) = %v, want %v", tt.guid, ids, want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// Package scrobble updates MyAnimeList anime lists from the playback webhooks
+// of media servers.
+//
+// Handler accepts the webhooks of Plex, of the Jellyfin webhook plugin and a
+// generic JSON payload for other servers and scripts. When an episode or a
+// movie is watched, it finds the anime by its MyAnimeList ID, by the IDs of
+// other sites through an IDMapper or by its title, and updates the number of
+// watched episodes of the account of the user:
+//
+//	h := &scrobble.Handler{
+//		Accounts: map[string]*mal.Client{"alice": c},
+//		Secret:   "long random string",
+//	}
+//	http.Handle("/scrobble", h)
+//
+// The webhook URL of the media server is then /scrobble?token=<secret>.
+//
+// The number of watched episodes is set to the watched episode rather than
+// incremented and duplicate events are ignored, so events that are sent
+// twice never count twice.
+package scrobble
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/resolve"
+)
+
+// IDMapper maps the IDs of anime on other sites to MyAnimeList IDs.
+type IDMapper interface {
+	// AnimeID returns the MyAnimeList ID of the anime with the given ID on
+	// site, such as SiteAniDB.
+	AnimeID(site, id string) (malID int, ok bool)
+}
+
+// The statuses of a Result.
+const (
+	// StatusUpdated means that the list was updated.
+	StatusUpdated = "updated"
+	// StatusIgnored means that the event did not need an update, for example
+	// because it was not a watched event or the episode was already watched.
+	StatusIgnored = "ignored"
+	// StatusDuplicate means that the event was already handled.
+	StatusDuplicate = "duplicate"
+	// StatusUnresolved means that the anime of the event was not found.
+	StatusUnresolved = "unresolved"
+)
+
+// Result is the outcome of an event. It is the JSON body of the responses of
+// the Handler.
+type Result struct {
+	Status string `json:"status"`
+	// Reason explains why an event was ignored or unresolved.
+	Reason  string `json:"reason,omitempty"`
+	AnimeID int    `json:"anime_id,omitempty"`
+	// Confidence is the confidence of the title match when the anime was
+	// found by its title.
+	Confidence float64 `json:"confidence,omitempty"`
+	// NumEpisodesWatched and ListStatus are the list status of the anime
+	// after an update.
+	NumEpisodesWatched int             `json:"num_episodes_watched,omitempty"`
+	ListStatus         mal.AnimeStatus `json:"list_status,omitempty"`
+}
+
+// Handler receives webhooks and updates the lists of the users. Configure the
+// handler before serving requests.
+type Handler struct {
+	// Accounts maps the names of the users of the media servers to the
+	// clients of their MyAnimeList accounts. The client of the name "*" is
+	// used for the users that are not listed. The events of other users are
+	// ignored.
+	Accounts map[string]*mal.Client
+	// Secret, if set, must be sent as the token query parameter since the
+	// webhooks of media servers are not signed.
+	Secret string
+	// IDs, if set, maps the IDs of other sites that the events carry to
+	// MyAnimeList IDs, for example with the dataset of an idmap.Map.
+	IDs IDMapper
+	// Threshold is the lowest confidence of the title matches that are
+	// updated. It defaults to the default threshold of resolve.Resolver.
+	Threshold float64
+	// Store remembers the handled events. It defaults to a MemoryStore.
+	Store Store
+	// OnEvent, if set, is called with every event that was parsed and its
+	// result or error, for example to log them.
+	OnEvent func(e Event, res Result, err error)
+
+	once sync.Once
+}
+
+func (h *Handler) store() Store {
+	h.once.Do(func() {
+		if h.Store == nil {
+			h.Store = NewMemoryStore(0)
+		}
+	})
+	return h.Store
+}
+
+// errUpstream marks the errors of the requests to MyAnimeList.
+type errUpstream struct{ err error }
+
+func (e errUpstream) Error() string { return e.err.Error() }
+func (e errUpstream) Unwrap() error { return e.err }
+
+// ServeHTTP handles a webhook. It responds with the Result as JSON and the
+// status 200 OK unless the anime was not found, which responds with 422
+// Unprocessable Entity. It responds with 400 Bad Request for payloads it does
+// not understand, 401 Unauthorized when the secret does not match and 502 Bad
+// Gateway when the requests to MyAnimeList fail.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Secret != "" && subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(h.Secret)) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	e, err := parseEvent(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := h.Handle(r.Context(), e)
+	if h.OnEvent != nil {
+		h.OnEvent(e, res, err)
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		var up errUpstream
+		if errors.As(err, &up) {
+			code = http.StatusBadGateway
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if res.Status == StatusUnresolved {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// Handle handles an event that was parsed from a webhook or created by the
+// caller.
+func (h *Handler) Handle(ctx context.Context, e Event) (Result, error) {
+	store := h.store()
+	if !e.Watched {
+		return Result{Status: StatusIgnored, Reason: "not a watched event"}, nil
+	}
+	c := h.account(e.User)
+	if c == nil {
+		return Result{Status: StatusIgnored, Reason: fmt.Sprintf("no account for user %q", e.User)}, nil
+	}
+	if !e.Movie && e.Season == 0 && (e.Source == Plex || e.Source == Jellyfin) {
+		return Result{Status: StatusIgnored, Reason: "specials are not mapped"}, nil
+	}
+	episode := e.Episode
+	if e.Movie || episode <= 0 {
+		episode = 1
+	}
+
+	res, err := h.resolve(ctx, c, e)
+	if err != nil || res.Status == StatusUnresolved {
+		return res, err
+	}
+
+	key := e.Source + ":" + e.ID
+	if e.ID == "" {
+		key = e.User + ":" + strconv.Itoa(res.AnimeID) + ":" + strconv.Itoa(episode)
+	}
+	added, err := store.Add(key)
+	if err != nil {
+		return Result{}, fmt.Errorf("recording event: %v", err)
+	}
+	if !added {
+		res.Status = StatusDuplicate
+		return res, nil
+	}
+	res, err = h.update(ctx, c, res, episode)
+	if err != nil {
+		if rerr := store.Remove(key); rerr != nil {
+			err = fmt.Errorf("%v (forgetting event: %v)", err, rerr)
+		}
+		return Result{}, err
+	}
+	return res, nil
+}
+
+func (h *Handler) account(user string) *mal.Client {
+	if c, ok := h.Accounts[user]; ok {
+		return c
+	}
+	return h.Accounts["*"]
+}
+
+// idSites are the sites whose IDs are mapped first since they identify anime
+// the way MyAnimeList does, unlike sites that list all the seasons of a show
+// under one ID.
+var idSites = []string{SiteAniDB, SiteAniList, SiteKitsu}
+
+// resolve finds the anime of the event by its MyAnimeList ID, by the IDs of
+// other sites or by its title.
+func (h *Handler) resolve(ctx context.Context, c *mal.Client, e Event) (Result, error) {
+	if id, ok := e.malID(); ok {
+		return Result{AnimeID: id}, nil
+	}
+	if h.IDs != nil {
+		var sites []string
+		for site := range e.IDs {
+			sites = append(sites, site)
+		}
+		sort.Slice(sites, func(i, j int) bool {
+			if ri, rj := siteRank(sites[i]), siteRank(sites[j]); ri != rj {
+				return ri < rj
+			}
+			return sites[i] < sites[j]
+		})
+		for _, site := range sites {
+			if id, ok := h.IDs.AnimeID(site, e.IDs[site]); ok {
+				return Result{AnimeID: id}, nil
+			}
+		}
+	}
+
+	text := e.Title
+	if !e.Movie && e.Season > 1 {
+		text += " Season " + strconv.Itoa(e.Season)
+	}
+	hints := resolve.Hints{Episode: e.Episode}
+	if e.Movie {
+		hints = resolve.Hints{MediaType: "movie", Year: e.Year}
+	}
+	r := resolve.New(c)
+	if h.Threshold > 0 {
+		r.Threshold = h.Threshold
+	}
+	m, ok, err := r.BestAnime(ctx, text, hints)
+	switch {
+	case errors.Is(err, resolve.ErrShortTitle):
+		return Result{Status: StatusUnresolved, Reason: fmt.Sprintf("title %q is too short to search", e.Title)}, nil
+	case err != nil:
+		return Result{}, errUpstream{err}
+	case !ok:
+		return Result{Status: StatusUnresolved, Reason: fmt.Sprintf("no confident match for %q", text)}, nil
+	}
+	return Result{AnimeID: m.Anime.ID, Confidence: m.Confidence}, nil
+}
+
+func siteRank(site string) int {
+	for i, s := range idSites {
+		if s == site {
+			return i
+		}
+	}
+	return len(idSites)
+}
+
+// update sets the number of watched episodes of the anime to episode with
+// AnimeService.SetEpisodesWatched, which applies the status transitions of the
+// list, unless the episode was already watched. An episode before the last
+// one of a completed anime starts a rewatch. Episodes beyond the known number
+// of episodes count as the last one.
+func (h *Handler) update(ctx context.Context, c *mal.Client, res Result, episode int) (Result, error) {
+	a, _, err := c.Anime.Details(ctx, res.AnimeID, mal.Fields{"num_episodes", "my_list_status{status,num_episodes_watched,is_rewatching}"})
+	if err != nil {
+		return Result{}, errUpstream{fmt.Errorf("fetching anime %d: %w", res.AnimeID, err)}
+	}
+	if a.NumEpisodes > 0 && episode > a.NumEpisodes {
+		episode = a.NumEpisodes
+	}
+	st := a.MyListStatus
+	var watched bool
+	switch {
+	case st.Status == "" || st.Status == mal.AnimeStatusPlanToWatch:
+	case st.Status == mal.AnimeStatusCompleted && !st.IsRewatching:
+		// Starting a rewatch needs the number of episodes of the anime.
+		watched = a.NumEpisodes == 0 || episode >= st.NumEpisodesWatched
+	default:
+		watched = st.NumEpisodesWatched >= episode
+	}
+	if watched {
+		res.Status, res.Reason = StatusIgnored, fmt.Sprintf("episode %d is already watched", episode)
+		return res, nil
+	}
+
+	updated, _, err := c.Anime.SetEpisodesWatched(ctx, res.AnimeID, episode)
+	if err != nil {
+		return Result{}, errUpstream{fmt.Errorf("updating anime %d: %w", res.AnimeID, err)}
+	}
+	res.Status = StatusUpdated
+	res.NumEpisodesWatched = updated.NumEpisodesWatched
+	res.ListStatus = updated.Status
+	return res, nil
+}
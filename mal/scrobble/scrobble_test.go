@@ -0,0 +1,222 @@
+package scrobble
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nstratos/go-myanimelist/mal"
+	"github.com/nstratos/go-myanimelist/mal/maltest"
+)
+
+func newTestHandler(t *testing.T) (*Handler, *maltest.Server) {
+	t.Helper()
+	f, err := maltest.LoadFixtures("testdata/fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := maltest.NewServer(f)
+	t.Cleanup(srv.Close)
+	h := &Handler{
+		Accounts: map[string]*mal.Client{"alice": srv.Client()},
+		Secret:   "s3cret",
+	}
+	return h, srv
+}
+
+// post sends payload to h and decodes the Result of the response.
+func post(t *testing.T, h http.Handler, token, payload string) (int, Result) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/scrobble?token="+token, strings.NewReader(payload))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	var res Result
+	if w.Header().Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+	}
+	return w.Code, res
+}
+
+func listStatus(t *testing.T, srv *maltest.Server, animeID int) mal.AnimeListStatus {
+	t.Helper()
+	for _, e := range srv.AnimeList("alice") {
+		if e.Anime.ID == animeID {
+			return e.Status
+		}
+	}
+	t.Fatalf("anime %d is not on the list", animeID)
+	return mal.AnimeListStatus{}
+}
+
+func TestHandlerByID(t *testing.T) {
+	h, srv := newTestHandler(t)
+	const payload = `{"event": "watched", "id": "e1", "user": "alice", "title": "Cowboy Bebop", "episode": 5, "ids": {"mal": 1}}`
+
+	code, res := post(t, h, "s3cret", payload)
+	want := Result{Status: StatusUpdated, AnimeID: 1, NumEpisodesWatched: 5, ListStatus: mal.AnimeStatusWatching}
+	if code != http.StatusOK || !reflect.DeepEqual(res, want) {
+		t.Fatalf("first event returned %d %+v, want %d %+v", code, res, http.StatusOK, want)
+	}
+	if st := listStatus(t, srv, 1); st.NumEpisodesWatched != 5 || st.StartDate != "2020-01-01" {
+		t.Errorf("list status after first event = %+v, want 5 episodes and the old start date", st)
+	}
+
+	code, res = post(t, h, "s3cret", payload)
+	if code != http.StatusOK || res.Status != StatusDuplicate {
+		t.Errorf("repeated event returned %d %+v, want duplicate", code, res)
+	}
+
+	code, res = post(t, h, "s3cret", `{"event": "watched", "user": "alice", "episode": 4, "ids": {"mal": "1"}}`)
+	if code != http.StatusOK || res.Status != StatusIgnored || res.Reason != "episode 4 is already watched" {
+		t.Errorf("earlier episode returned %d %+v, want ignored", code, res)
+	}
+
+	code, res = post(t, h, "s3cret", `{"event": "scrobble", "user": "alice", "episode": 26, "ids": {"mal": "1"}}`)
+	want = Result{Status: StatusUpdated, AnimeID: 1, NumEpisodesWatched: 26, ListStatus: mal.AnimeStatusCompleted}
+	if code != http.StatusOK || !reflect.DeepEqual(res, want) {
+		t.Fatalf("last episode returned %d %+v, want %d %+v", code, res, http.StatusOK, want)
+	}
+	if st, today := listStatus(t, srv, 1), time.Now().Format("2006-01-02"); st.FinishDate != today {
+		t.Errorf("finish date after last episode = %q, want %q", st.FinishDate, today)
+	}
+
+	code, res = post(t, h, "s3cret", `{"event": "watched", "user": "alice", "episode": 25, "ids": {"mal": "16498"}}`)
+	if code != http.StatusOK || res.Status != StatusIgnored || res.Reason != "episode 25 is already watched" {
+		t.Errorf("last episode of completed anime returned %d %+v, want ignored", code, res)
+	}
+
+	// An earlier episode of a completed anime starts a rewatch and the last
+	// one ends it.
+	code, res = post(t, h, "s3cret", `{"event": "watched", "user": "alice", "episode": 3, "ids": {"mal": "16498"}}`)
+	want = Result{Status: StatusUpdated, AnimeID: 16498, NumEpisodesWatched: 3, ListStatus: mal.AnimeStatusCompleted}
+	if code != http.StatusOK || !reflect.DeepEqual(res, want) {
+		t.Fatalf("rewatched episode returned %d %+v, want %d %+v", code, res, http.StatusOK, want)
+	}
+	if st := listStatus(t, srv, 16498); !st.IsRewatching {
+		t.Errorf("list status after rewatched episode = %+v, want rewatching", st)
+	}
+	code, res = post(t, h, "s3cret", `{"event": "watched", "id": "e2", "user": "alice", "episode": 25, "ids": {"mal": "16498"}}`)
+	if code != http.StatusOK || res.Status != StatusUpdated {
+		t.Fatalf("last rewatched episode returned %d %+v, want updated", code, res)
+	}
+	if st := listStatus(t, srv, 16498); st.IsRewatching || st.NumTimesRewatched != 1 {
+		t.Errorf("list status after rewatch = %+v, want the rewatch counted and ended", st)
+	}
+}
+
+func TestHandlerByTitle(t *testing.T) {
+	h, srv := newTestHandler(t)
+
+	code, res := post(t, h, "s3cret", `{"event": "media.scrobble", "Account": {"title": "alice"},
+		"Metadata": {"type": "episode", "grandparentTitle": "Attack on Titan", "parentIndex": 3, "index": 2}}`)
+	if code != http.StatusOK || res.Status != StatusUpdated || res.AnimeID != 35760 || res.Confidence < 0.85 {
+		t.Fatalf("Plex episode returned %d %+v, want anime 35760 updated", code, res)
+	}
+	st := listStatus(t, srv, 35760)
+	if st.Status != mal.AnimeStatusWatching || st.NumEpisodesWatched != 2 || st.StartDate != time.Now().Format("2006-01-02") {
+		t.Errorf("list status of new anime = %+v, want watching 2 episodes from today", st)
+	}
+
+	code, res = post(t, h, "s3cret", `{"NotificationType": "PlaybackStop", "PlayedToCompletion": true, "NotificationUsername": "alice",
+		"ItemType": "Movie", "Name": "Cowboy Bebop: The Movie", "Year": 2001}`)
+	if code != http.StatusOK || res.Status != StatusUpdated || res.AnimeID != 5 || res.ListStatus != mal.AnimeStatusCompleted {
+		t.Fatalf("Jellyfin movie returned %d %+v, want anime 5 completed", code, res)
+	}
+
+	code, res = post(t, h, "s3cret", `{"event": "watched", "user": "alice", "title": "Some Unknown Show", "episode": 1}`)
+	if code != http.StatusUnprocessableEntity || res.Status != StatusUnresolved {
+		t.Errorf("unknown title returned %d %+v, want %d unresolved", code, res, http.StatusUnprocessableEntity)
+	}
+}
+
+type mapIDs map[string]int
+
+func (m mapIDs) AnimeID(site, id string) (int, bool) {
+	malID, ok := m[site+":"+id]
+	return malID, ok
+}
+
+func TestHandlerIDMapper(t *testing.T) {
+	h, _ := newTestHandler(t)
+	h.IDs = mapIDs{"anidb:13241": 35760, "tvdb:267440": 16498}
+
+	code, res := post(t, h, "s3cret", `{"event": "watched", "user": "alice", "title": "x", "episode": 1, "ids": {"tvdb": "267440", "anidb": "13241"}}`)
+	if code != http.StatusOK || res.Status != StatusUpdated || res.AnimeID != 35760 || res.Confidence != 0 {
+		t.Errorf("mapped event returned %d %+v, want anime 35760 mapped by its AniDB ID", code, res)
+	}
+}
+
+func TestHandlerIgnored(t *testing.T) {
+	h, _ := newTestHandler(t)
+	tests := []struct {
+		payload string
+		reason  string
+	}{
+		{`{"event": "media.play", "Account": {"title": "alice"}, "Metadata": {"type": "episode", "grandparentTitle": "Cowboy Bebop", "parentIndex": 1, "index": 5}}`, "not a watched event"},
+		{`{"event": "watched", "user": "bob", "ids": {"mal": "1"}}`, `no account for user "bob"`},
+		{`{"event": "media.scrobble", "Account": {"title": "alice"}, "Metadata": {"type": "episode", "grandparentTitle": "Cowboy Bebop", "parentIndex": 0, "index": 1}}`, "specials are not mapped"},
+	}
+	for _, tt := range tests {
+		code, res := post(t, h, "s3cret", tt.payload)
+		if code != http.StatusOK || res.Status != StatusIgnored || res.Reason != tt.reason {
+			t.Errorf("post(%.40s) returned %d %+v, want ignored because %s", tt.payload, code, res, tt.reason)
+		}
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	if code, _ := post(t, h, "wrong", `{"event": "watched", "user": "alice", "ids": {"mal": "1"}}`); code != http.StatusUnauthorized {
+		t.Errorf("wrong token returned %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code, _ := post(t, h, "s3cret", `garbage`); code != http.StatusBadRequest {
+		t.Errorf("garbage payload returned %d, want %d", code, http.StatusBadRequest)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/scrobble?token=s3cret", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "POST" {
+		t.Errorf("GET returned %d with Allow %q, want %d with Allow POST", w.Code, w.Header().Get("Allow"), http.StatusMethodNotAllowed)
+	}
+
+	var events []Event
+	h.OnEvent = func(e Event, res Result, err error) {
+		if err == nil {
+			t.Errorf("OnEvent called without the error of the event")
+		}
+		events = append(events, e)
+	}
+	const missing = `{"event": "watched", "id": "e2", "user": "alice", "episode": 1, "ids": {"mal": "999"}}`
+	if code, _ := post(t, h, "s3cret", missing); code != http.StatusBadGateway {
+		t.Errorf("missing anime returned %d, want %d", code, http.StatusBadGateway)
+	}
+	if len(events) != 1 || events[0].ID != "e2" {
+		t.Errorf("OnEvent received %+v, want the event e2", events)
+	}
+	// The failed event is forgotten so that it can be retried.
+	if added, _ := h.Store.Add("generic:e2"); !added {
+		t.Errorf("failed event is still in the store")
+	}
+}
+
+func TestHandleWildcardAccount(t *testing.T) {
+	h, _ := newTestHandler(t)
+	h.Accounts = map[string]*mal.Client{"*": h.Accounts["alice"]}
+
+	res, err := h.Handle(context.Background(), Event{Source: Generic, User: "anyone", Watched: true, Episode: 6, IDs: map[string]string{SiteMAL: "1"}})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if res.Status != StatusUpdated || res.NumEpisodesWatched != 6 {
+		t.Errorf("Handle returned %+v, want 6 episodes updated", res)
+	}
+}
@@ -0,0 +1,66 @@
+package scrobble
+
+import "sync"
+
+// Store remembers the events that were handled so that duplicate events are
+// ignored.
+type Store interface {
+	// Add records key. It reports false if key was already recorded.
+	Add(key string) (bool, error)
+	// Remove forgets key so that an event that failed can be handled again.
+	Remove(key string) error
+}
+
+const defaultStoreSize = 1000
+
+// MemoryStore is a Store that remembers the most recent keys in memory. It is
+// safe for concurrent use.
+type MemoryStore struct {
+	mu    sync.Mutex
+	size  int
+	keys  map[string]bool
+	order []string
+}
+
+// NewMemoryStore returns a MemoryStore that remembers up to size keys,
+// forgetting the oldest first. If size is zero or negative it remembers 1000
+// keys.
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = defaultStoreSize
+	}
+	return &MemoryStore{size: size, keys: make(map[string]bool)}
+}
+
+// Add records key. It reports false if key was already recorded.
+func (s *MemoryStore) Add(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys[key] {
+		return false, nil
+	}
+	for len(s.order) >= s.size {
+		delete(s.keys, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.keys[key] = true
+	s.order = append(s.order, key)
+	return true, nil
+}
+
+// Remove forgets key.
+func (s *MemoryStore) Remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.keys[key] {
+		return nil
+	}
+	delete(s.keys, key)
+	for i, k := range s.order {
+		if k == key {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package scrobble
+
+import "testing"
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore(2)
+	add := func(key string, want bool) {
+		t.Helper()
+		added, err := s.Add(key)
+		if err != nil {
+			t.Fatalf("Add(%q) returned error: %v", key, err)
+		}
+		if added != want {
+			t.Errorf("Add(%q) = %v, want %v", key, added, want)
+		}
+	}
+	add("a", true)
+	add("a", false)
+	add("b", true)
+	// Adding c forgets a, the oldest key.
+	add("c", true)
+	add("a", true)
+	add("c", false)
+
+	if err := s.Remove("c"); err != nil {
+		t.Fatalf("Remove returned error: %v", err)
+	}
+	if err := s.Remove("missing"); err != nil {
+		t.Fatalf("Remove of missing key returned error: %v", err)
+	}
+	add("c", true)
+	add("a", false)
+}
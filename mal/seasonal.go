@@ -0,0 +1,140 @@
+package mal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// seasonalPageSize is the largest page size allowed for seasonal anime.
+const seasonalPageSize = 500
+
+var seasonIndex = map[AnimeSeason]int{
+	AnimeSeasonWinter: 0,
+	AnimeSeasonSpring: 1,
+	AnimeSeasonSummer: 2,
+	AnimeSeasonFall:   3,
+}
+
+var seasonsByIndex = [...]AnimeSeason{AnimeSeasonWinter, AnimeSeasonSpring, AnimeSeasonSummer, AnimeSeasonFall}
+
+// SeasonalAnime is an anime of the seasonal charts of a range of seasons.
+type SeasonalAnime struct {
+	Anime Anime `json:"anime"`
+	// Seasons are the seasons whose charts include the anime, in
+	// chronological order. Continuing shows appear in more than one.
+	Seasons []StartSeason `json:"seasons"`
+}
+
+// SeasonalRange returns the seasonal anime of every season from the season
+// from to the season to, inclusive. The charts of the seasons are paged fully
+// and an anime that appears in more than one of them is returned once, with
+// all the seasons it appeared in.
+//
+// The options are the same as for Seasonal. Limit sets the size of the pages
+// and defaults to the largest allowed, and Offset is ignored as every season
+// is paged from the start. The SortSeasonalAnime options sort the anime of
+// the whole range and request the field they sort by if the Fields option does
+// not include it. Without one, the anime are in the order they first appear.
+func (s *AnimeService) SeasonalRange(ctx context.Context, from, to StartSeason, options ...SeasonalAnimeOption) ([]SeasonalAnime, error) {
+	first, err := seasonNumber(from)
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	last, err := seasonNumber(to)
+	if err != nil {
+		return nil, fmt.Errorf("to: %w", err)
+	}
+	if first > last {
+		return nil, fmt.Errorf("season %d %s is after %d %s", from.Year, from.Season, to.Year, to.Season)
+	}
+
+	var (
+		sortBy SortSeasonalAnime
+		fields Fields
+		oo     = []SeasonalAnimeOption{Limit(seasonalPageSize)}
+	)
+	for _, o := range options {
+		switch o := o.(type) {
+		case SortSeasonalAnime:
+			sortBy = o
+		case Fields:
+			fields = append(fields, o...)
+		case Offset:
+		default:
+			oo = append(oo, o)
+		}
+	}
+	if f := seasonalSortField[sortBy]; f != "" && !hasField(fields, f) {
+		fields = append(fields, f)
+	}
+	if len(fields) != 0 {
+		oo = append(oo, fields)
+	}
+	oo = append(oo, Offset(0))
+
+	var result []SeasonalAnime
+	index := make(map[int]int)
+	for n := first; n <= last; n++ {
+		season := StartSeason{Year: n / 4, Season: string(seasonsByIndex[n%4])}
+		offset := 0
+		for {
+			oo[len(oo)-1] = Offset(offset)
+			page, resp, err := s.Seasonal(ctx, season.Year, AnimeSeason(season.Season), oo...)
+			if err != nil {
+				return nil, fmt.Errorf("%d %s: %w", season.Year, season.Season, err)
+			}
+			for _, a := range page {
+				i, ok := index[a.ID]
+				if !ok {
+					i = len(result)
+					index[a.ID] = i
+					result = append(result, SeasonalAnime{Anime: a})
+				}
+				if seasons := result[i].Seasons; len(seasons) == 0 || seasons[len(seasons)-1] != season {
+					result[i].Seasons = append(seasons, season)
+				}
+			}
+			if resp.NextOffset <= offset || len(page) == 0 {
+				break
+			}
+			offset = resp.NextOffset
+		}
+	}
+
+	switch sortBy {
+	case SortSeasonalByAnimeScore:
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Anime.Mean > result[j].Anime.Mean })
+	case SortSeasonalByAnimeNumListUsers:
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Anime.NumListUsers > result[j].Anime.NumListUsers })
+	}
+	return result, nil
+}
+
+// seasonalSortField is the anime field that each SortSeasonalAnime sorts by.
+var seasonalSortField = map[SortSeasonalAnime]string{
+	SortSeasonalByAnimeScore:        "mean",
+	SortSeasonalByAnimeNumListUsers: "num_list_users",
+}
+
+func hasField(fields Fields, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// seasonNumber numbers the seasons consecutively so that the season after
+// the fall of a year is the winter of the next.
+func seasonNumber(s StartSeason) (int, error) {
+	i, ok := seasonIndex[AnimeSeason(s.Season)]
+	if !ok {
+		return 0, fmt.Errorf("invalid season %q", s.Season)
+	}
+	if s.Year <= 0 {
+		return 0, fmt.Errorf("invalid year %d", s.Year)
+	}
+	return s.Year*4 + i, nil
+}
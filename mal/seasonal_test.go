@@ -0,0 +1,101 @@
+package mal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAnimeServiceSeasonalRange(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/anime/season/2022/fall", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		offset := r.URL.Query().Get("offset")
+		testURLValues(t, r, urlValues{
+			"fields": "title,num_list_users",
+			"limit":  "500",
+			"offset": offset,
+			"nsfw":   "true",
+		})
+		switch offset {
+		case "0":
+			fmt.Fprint(w, `{"data": [{"node": {"id": 1, "num_list_users": 100}}, {"node": {"id": 2, "num_list_users": 300}}], "paging": {"next": "?offset=2"}}`)
+		case "2":
+			fmt.Fprint(w, `{"data": [{"node": {"id": 3, "num_list_users": 200}}]}`)
+		default:
+			t.Errorf("unexpected offset %s", offset)
+		}
+	})
+	mux.HandleFunc("/anime/season/2023/winter", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		fmt.Fprint(w, `{"data": [{"node": {"id": 4, "num_list_users": 300}}, {"node": {"id": 1, "num_list_users": 100}}]}`)
+	})
+
+	ctx := context.Background()
+	got, err := client.Anime.SeasonalRange(ctx,
+		StartSeason{Year: 2022, Season: "fall"},
+		StartSeason{Year: 2023, Season: "winter"},
+		SortSeasonalByAnimeNumListUsers,
+		Fields{"title"},
+		Offset(10),
+		NSFW(true),
+	)
+	if err != nil {
+		t.Fatalf("Anime.SeasonalRange returned error: %v", err)
+	}
+	fall := StartSeason{Year: 2022, Season: "fall"}
+	winter := StartSeason{Year: 2023, Season: "winter"}
+	want := []SeasonalAnime{
+		{Anime: Anime{ID: 2, NumListUsers: 300}, Seasons: []StartSeason{fall}},
+		{Anime: Anime{ID: 4, NumListUsers: 300}, Seasons: []StartSeason{winter}},
+		{Anime: Anime{ID: 3, NumListUsers: 200}, Seasons: []StartSeason{fall}},
+		{Anime: Anime{ID: 1, NumListUsers: 100}, Seasons: []StartSeason{fall, winter}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Anime.SeasonalRange returned\nhave: %+v\n\nwant: %+v", got, want)
+	}
+}
+
+func TestAnimeServiceSeasonalRangeError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/anime/season/2023/winter", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"node": {"id": 1}}]}`)
+	})
+	mux.HandleFunc("/anime/season/2023/spring", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"mal is down","error":"internal"}`, http.StatusInternalServerError)
+	})
+
+	ctx := context.Background()
+	_, err := client.Anime.SeasonalRange(ctx, StartSeason{Year: 2023, Season: "winter"}, StartSeason{Year: 2023, Season: "summer"})
+	if err == nil {
+		t.Fatal("Anime.SeasonalRange expected internal error, got no error.")
+	}
+	testErrorResponse(t, err, ErrorResponse{Message: "mal is down", Err: "internal"})
+}
+
+func TestAnimeServiceSeasonalRangeInvalid(t *testing.T) {
+	client, _, teardown := setup()
+	defer teardown()
+
+	tests := []struct {
+		name     string
+		from, to StartSeason
+	}{
+		{"invalid season", StartSeason{Year: 2023, Season: "autumn"}, StartSeason{Year: 2023, Season: "fall"}},
+		{"invalid year", StartSeason{Year: 2023, Season: "winter"}, StartSeason{Season: "fall"}},
+		{"from after to", StartSeason{Year: 2023, Season: "spring"}, StartSeason{Year: 2023, Season: "winter"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.Anime.SeasonalRange(context.Background(), tt.from, tt.to); err == nil {
+				t.Errorf("Anime.SeasonalRange(%v, %v) expected error, got no error.", tt.from, tt.to)
+			}
+		})
+	}
+}
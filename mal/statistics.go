@@ -0,0 +1,222 @@
+package mal
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MangaStatistics about the user. The MyAnimeList API does not provide manga
+// statistics so they are computed from the user's manga list, see
+// UserService.ComputeMangaStatistics.
+type MangaStatistics struct {
+	NumItemsReading    int     `json:"num_items_reading"`
+	NumItemsCompleted  int     `json:"num_items_completed"`
+	NumItemsOnHold     int     `json:"num_items_on_hold"`
+	NumItemsDropped    int     `json:"num_items_dropped"`
+	NumItemsPlanToRead int     `json:"num_items_plan_to_read"`
+	NumItems           int     `json:"num_items"`
+	NumChaptersRead    int     `json:"num_chapters_read"`
+	NumVolumesRead     int     `json:"num_volumes_read"`
+	NumTimesReread     int     `json:"num_times_reread"`
+	MeanScore          float64 `json:"mean_score"`
+}
+
+// NumDaysRead estimates the days spent reading assuming each chapter takes
+// perChapter to read.
+func (s MangaStatistics) NumDaysRead(perChapter time.Duration) float64 {
+	return round2(float64(s.NumChaptersRead) * perChapter.Hours() / 24)
+}
+
+// NewMangaStatistics computes manga statistics from the entries of a manga
+// list. Chapters and volumes of completed rereads are included in the number
+// of chapters and volumes read. Entries without a score are not included in
+// the mean score.
+func NewMangaStatistics(list []UserManga) MangaStatistics {
+	var ms mangaStats
+	ms.add(list)
+	return ms.result()
+}
+
+// mangaStats accumulates the manga statistics of a list page by page.
+type mangaStats struct {
+	st     MangaStatistics
+	scores scoreMean
+}
+
+func (ms *mangaStats) add(list []UserManga) {
+	st := &ms.st
+	for _, m := range list {
+		switch m.Status.Status {
+		case MangaStatusReading:
+			st.NumItemsReading++
+		case MangaStatusCompleted:
+			st.NumItemsCompleted++
+		case MangaStatusOnHold:
+			st.NumItemsOnHold++
+		case MangaStatusDropped:
+			st.NumItemsDropped++
+		case MangaStatusPlanToRead:
+			st.NumItemsPlanToRead++
+		}
+		st.NumItems++
+		st.NumChaptersRead += m.Status.NumChaptersRead + m.Status.NumTimesReread*m.Manga.NumChapters
+		st.NumVolumesRead += m.Status.NumVolumesRead + m.Status.NumTimesReread*m.Manga.NumVolumes
+		st.NumTimesReread += m.Status.NumTimesReread
+		ms.scores.add(m.Status.Score)
+	}
+}
+
+func (ms *mangaStats) result() MangaStatistics {
+	st := ms.st
+	st.MeanScore = ms.scores.mean()
+	return st
+}
+
+// NewAnimeStatistics computes anime statistics from the entries of an anime
+// list, the same way MyAnimeList computes the AnimeStatistics of a User. The
+// days are derived from the episodes watched and the average episode duration
+// of each anime, including the episodes of completed rewatches. Entries
+// without a score are not included in the mean score.
+func NewAnimeStatistics(list []UserAnime) AnimeStatistics {
+	var as animeStats
+	as.add(list)
+	return as.result()
+}
+
+// animeStats accumulates the anime statistics of a list page by page. The
+// days are rounded once all the pages are added.
+type animeStats struct {
+	st     AnimeStatistics
+	scores scoreMean
+}
+
+func (as *animeStats) add(list []UserAnime) {
+	st := &as.st
+	for _, a := range list {
+		episodes := a.Status.NumEpisodesWatched + a.Status.NumTimesRewatched*a.Anime.NumEpisodes
+		days := float64(episodes*a.Anime.AverageEpisodeDuration) / (24 * 60 * 60)
+		switch a.Status.Status {
+		case AnimeStatusWatching:
+			st.NumItemsWatching++
+			st.NumDaysWatching += days
+		case AnimeStatusCompleted:
+			st.NumItemsCompleted++
+			st.NumDaysCompleted += days
+		case AnimeStatusOnHold:
+			st.NumItemsOnHold++
+			st.NumDaysOnHold += days
+		case AnimeStatusDropped:
+			st.NumItemsDropped++
+			st.NumDaysDropped += days
+		case AnimeStatusPlanToWatch:
+			st.NumItemsPlanToWatch++
+		}
+		st.NumItems++
+		st.NumDaysWatched += days
+		st.NumEpisodes += episodes
+		st.NumTimesRewatched += a.Status.NumTimesRewatched
+		as.scores.add(a.Status.Score)
+	}
+}
+
+func (as *animeStats) result() AnimeStatistics {
+	st := as.st
+	st.NumDaysWatching = round2(st.NumDaysWatching)
+	st.NumDaysCompleted = round2(st.NumDaysCompleted)
+	st.NumDaysOnHold = round2(st.NumDaysOnHold)
+	st.NumDaysDropped = round2(st.NumDaysDropped)
+	st.NumDaysWatched = round2(st.NumDaysWatched)
+	st.NumDays = st.NumDaysWatched
+	st.MeanScore = as.scores.mean()
+	return st
+}
+
+type scoreMean struct {
+	sum, n int
+}
+
+func (m *scoreMean) add(score int) {
+	if score > 0 {
+		m.sum += score
+		m.n++
+	}
+}
+
+func (m scoreMean) mean() float64 {
+	if m.n == 0 {
+		return 0
+	}
+	return round2(float64(m.sum) / float64(m.n))
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// ComputeMangaStatistics walks the whole manga list of the user indicated by
+// username (or use @me) and computes their manga statistics. Each page is
+// added to the statistics as it is received so the list is not kept in
+// memory.
+func (s *UserService) ComputeMangaStatistics(ctx context.Context, username string) (*MangaStatistics, error) {
+	var ms mangaStats
+	err := s.WalkMangaList(ctx, username, func(page []UserManga) error {
+		ms.add(page)
+		return nil
+	}, Fields{"list_status{num_times_reread}", "num_chapters", "num_volumes"}, Limit(1000))
+	if err != nil {
+		return nil, err
+	}
+	st := ms.result()
+	return &st, nil
+}
+
+// ComputeAnimeStatistics walks the whole anime list of the user indicated by
+// username (or use @me) and computes their anime statistics locally, page by
+// page like ComputeMangaStatistics. The result can be compared with the
+// AnimeStatistics returned by MyAnimeList using AnimeStatistics.Diff.
+func (s *UserService) ComputeAnimeStatistics(ctx context.Context, username string) (*AnimeStatistics, error) {
+	var as animeStats
+	err := s.WalkAnimeList(ctx, username, func(page []UserAnime) error {
+		as.add(page)
+		return nil
+	}, Fields{"list_status{num_times_rewatched}", "num_episodes", "average_episode_duration"}, Limit(1000))
+	if err != nil {
+		return nil, err
+	}
+	st := as.result()
+	return &st, nil
+}
+
+// StatisticDiff is a statistic that differs between two sets of statistics.
+type StatisticDiff struct {
+	// Field is the JSON name of the statistic, for example "num_episodes".
+	Field string
+	A, B  float64
+}
+
+// Diff returns the statistics that differ by more than tolerance between s
+// and other, in field order. It is typically used to compare statistics
+// computed with NewAnimeStatistics with the ones returned by MyAnimeList.
+func (s AnimeStatistics) Diff(other AnimeStatistics, tolerance float64) []StatisticDiff {
+	var diffs []StatisticDiff
+	va, vb := reflect.ValueOf(s), reflect.ValueOf(other)
+	for i := 0; i < va.NumField(); i++ {
+		var a, b float64
+		switch f := va.Field(i); f.Kind() {
+		case reflect.Int:
+			a, b = float64(f.Int()), float64(vb.Field(i).Int())
+		case reflect.Float64:
+			a, b = f.Float(), vb.Field(i).Float()
+		default:
+			continue
+		}
+		if math.Abs(a-b) > tolerance {
+			name := strings.Split(va.Type().Field(i).Tag.Get("json"), ",")[0]
+			diffs = append(diffs, StatisticDiff{Field: name, A: a, B: b})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,183 @@
+package mal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewAnimeStatistics(t *testing.T) {
+	const minutes = 60
+	list := []UserAnime{
+		{
+			Anime:  Anime{NumEpisodes: 24, AverageEpisodeDuration: 24 * minutes},
+			Status: AnimeListStatus{Status: AnimeStatusCompleted, NumEpisodesWatched: 24, NumTimesRewatched: 1, Score: 9},
+		},
+		{
+			Anime:  Anime{NumEpisodes: 12, AverageEpisodeDuration: 24 * minutes},
+			Status: AnimeListStatus{Status: AnimeStatusWatching, NumEpisodesWatched: 6, Score: 6},
+		},
+		{
+			Anime:  Anime{NumEpisodes: 1, AverageEpisodeDuration: 120 * minutes},
+			Status: AnimeListStatus{Status: AnimeStatusPlanToWatch},
+		},
+		{
+			Anime:  Anime{NumEpisodes: 12, AverageEpisodeDuration: 24 * minutes},
+			Status: AnimeListStatus{Status: AnimeStatusDropped, NumEpisodesWatched: 2},
+		},
+		{
+			Anime:  Anime{NumEpisodes: 13, AverageEpisodeDuration: 24 * minutes},
+			Status: AnimeListStatus{Status: AnimeStatusOnHold, NumEpisodesWatched: 1},
+		},
+	}
+	got := NewAnimeStatistics(list)
+	want := AnimeStatistics{
+		NumItemsWatching:    1,
+		NumItemsCompleted:   1,
+		NumItemsOnHold:      1,
+		NumItemsDropped:     1,
+		NumItemsPlanToWatch: 1,
+		NumItems:            5,
+		NumDaysWatched:      0.95,
+		NumDaysWatching:     0.1,
+		NumDaysCompleted:    0.8,
+		NumDaysOnHold:       0.02,
+		NumDaysDropped:      0.03,
+		NumDays:             0.95,
+		NumEpisodes:         57,
+		NumTimesRewatched:   1,
+		MeanScore:           7.5,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewAnimeStatistics\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestNewMangaStatistics(t *testing.T) {
+	list := []UserManga{
+		{
+			Manga:  Manga{NumChapters: 100, NumVolumes: 10},
+			Status: MangaListStatus{Status: MangaStatusCompleted, NumChaptersRead: 100, NumVolumesRead: 10, NumTimesReread: 2, Score: 10},
+		},
+		{
+			Status: MangaListStatus{Status: MangaStatusReading, NumChaptersRead: 20, NumVolumesRead: 2, Score: 7},
+		},
+		{Status: MangaListStatus{Status: MangaStatusPlanToRead}},
+		{Status: MangaListStatus{Status: MangaStatusOnHold, NumChaptersRead: 3}},
+		{Status: MangaListStatus{Status: MangaStatusDropped, NumChaptersRead: 1, Score: 2}},
+	}
+	got := NewMangaStatistics(list)
+	want := MangaStatistics{
+		NumItemsReading:    1,
+		NumItemsCompleted:  1,
+		NumItemsOnHold:     1,
+		NumItemsDropped:    1,
+		NumItemsPlanToRead: 1,
+		NumItems:           5,
+		NumChaptersRead:    324,
+		NumVolumesRead:     32,
+		NumTimesReread:     2,
+		MeanScore:          6.33,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewMangaStatistics\nhave: %+v\nwant: %+v", got, want)
+	}
+	if got, want := got.NumDaysRead(10*time.Minute), 2.25; got != want {
+		t.Errorf("MangaStatistics.NumDaysRead = %v, want %v", got, want)
+	}
+}
+
+func TestUserServiceComputeMangaStatistics(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/mangalist", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		offset := r.URL.Query().Get("offset")
+		testURLValues(t, r, urlValues{
+			"fields": "list_status{num_times_reread},num_chapters,num_volumes",
+			"limit":  "1000",
+			"offset": offset,
+		})
+		switch offset {
+		case "0":
+			fmt.Fprint(w, `{"data": [{"node": {"id": 1}, "list_status": {"status": "reading", "num_chapters_read": 5, "score": 7}}], "paging": {"next": "?offset=1"}}`)
+		case "1":
+			fmt.Fprint(w, `{"data": [{"node": {"id": 2, "num_chapters": 10}, "list_status": {"status": "completed", "num_chapters_read": 10, "num_times_reread": 1, "score": 8}}]}`)
+		default:
+			t.Errorf("unexpected offset %q", offset)
+		}
+	})
+
+	ctx := context.Background()
+	got, err := client.User.ComputeMangaStatistics(ctx, "foo")
+	if err != nil {
+		t.Fatalf("User.ComputeMangaStatistics returned error: %v", err)
+	}
+	want := &MangaStatistics{NumItemsReading: 1, NumItemsCompleted: 1, NumItems: 2, NumChaptersRead: 25, NumTimesReread: 1, MeanScore: 7.5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("User.ComputeMangaStatistics\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestUserServiceComputeAnimeStatistics(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/animelist", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		testURLValues(t, r, urlValues{
+			"fields": "list_status{num_times_rewatched},num_episodes,average_episode_duration",
+			"limit":  "1000",
+			"offset": "0",
+		})
+		fmt.Fprint(w, `{"data": [{"node": {"id": 1, "num_episodes": 1, "average_episode_duration": 8640}, "list_status": {"status": "completed", "num_episodes_watched": 1, "score": 8}}]}`)
+	})
+
+	ctx := context.Background()
+	got, err := client.User.ComputeAnimeStatistics(ctx, "foo")
+	if err != nil {
+		t.Fatalf("User.ComputeAnimeStatistics returned error: %v", err)
+	}
+	want := &AnimeStatistics{
+		NumItemsCompleted: 1,
+		NumItems:          1,
+		NumDaysWatched:    0.1,
+		NumDaysCompleted:  0.1,
+		NumDays:           0.1,
+		NumEpisodes:       1,
+		MeanScore:         8,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("User.ComputeAnimeStatistics\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestUserServiceComputeAnimeStatisticsError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/animelist", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"mal is down","error":"internal"}`, http.StatusInternalServerError)
+	})
+
+	ctx := context.Background()
+	_, err := client.User.ComputeAnimeStatistics(ctx, "foo")
+	if err == nil {
+		t.Fatal("User.ComputeAnimeStatistics expected internal error, got no error.")
+	}
+	testErrorResponse(t, err, ErrorResponse{Message: "mal is down", Err: "internal"})
+}
+
+func TestAnimeStatisticsDiff(t *testing.T) {
+	local := AnimeStatistics{NumItems: 10, NumDaysWatched: 12.5, MeanScore: 7.5}
+	remote := AnimeStatistics{NumItems: 11, NumDaysWatched: 12.51, MeanScore: 7.5}
+	got := local.Diff(remote, 0.05)
+	want := []StatisticDiff{{Field: "num_items", A: 10, B: 11}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AnimeStatistics.Diff\nhave: %+v\nwant: %+v", got, want)
+	}
+}
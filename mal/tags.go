@@ -0,0 +1,232 @@
+package mal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nstratos/go-myanimelist/mal/internal/pool"
+)
+
+// TagOperation changes the tags of a single list entry. It receives the
+// current tags of the entry and returns the new tags. It must not modify the
+// tags it receives.
+type TagOperation func(tags []string) []string
+
+// RenameTag returns a TagOperation that renames the tag from to the tag to.
+func RenameTag(from, to string) TagOperation {
+	return MergeTags(to, from)
+}
+
+// MergeTags returns a TagOperation that replaces any of the tags with the tag
+// into. Entries that end up with into more than once keep it only once.
+func MergeTags(into string, tags ...string) TagOperation {
+	merge := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		merge[t] = true
+	}
+	return func(old []string) []string {
+		found := false
+		for _, t := range old {
+			if merge[t] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return old
+		}
+		var tags []string
+		added := false
+		for _, t := range old {
+			if merge[t] || t == into {
+				if added {
+					continue
+				}
+				t, added = into, true
+			}
+			tags = append(tags, t)
+		}
+		return tags
+	}
+}
+
+// RemoveTag returns a TagOperation that removes the tag.
+func RemoveTag(tag string) TagOperation {
+	return func(old []string) []string {
+		var tags []string
+		for _, t := range old {
+			if t != tag {
+				tags = append(tags, t)
+			}
+		}
+		return tags
+	}
+}
+
+// AddTag returns a TagOperation that adds the tag to entries that do not
+// already have it.
+func AddTag(tag string) TagOperation {
+	return func(old []string) []string {
+		for _, t := range old {
+			if t == tag {
+				return old
+			}
+		}
+		tags := make([]string, len(old), len(old)+1)
+		copy(tags, old)
+		return append(tags, tag)
+	}
+}
+
+// BulkTagOptions control how tag changes are applied by the BulkUpdateTags
+// methods.
+type BulkTagOptions struct {
+	// DryRun computes the changes without applying them.
+	DryRun bool
+	// Concurrency is the maximum number of updates sent to the API at the
+	// same time. It defaults to 4.
+	Concurrency int
+	// Progress, if set, is called after each change is applied with the number
+	// of changes done so far and the total number of changes. It is never
+	// called concurrently.
+	Progress func(done, total int)
+}
+
+const defaultBulkTagConcurrency = 4
+
+// TagChange describes the change of the tags of a single anime or manga in
+// the user's list.
+type TagChange struct {
+	ID      int
+	Title   string
+	OldTags []string
+	NewTags []string
+	// Err is the error that occurred when applying the change, if any.
+	Err error
+}
+
+// BulkUpdateTags applies the tag operation to every anime in the
+// authenticated user's list for which filter returns true. A nil filter
+// matches every anime. Only anime whose tags actually change are updated and
+// returned. If any update fails, the returned error reports how many and the
+// individual errors can be found in the returned changes.
+func (s *AnimeService) BulkUpdateTags(ctx context.Context, op TagOperation, filter func(UserAnime) bool, opts *BulkTagOptions) ([]TagChange, error) {
+	var changes []TagChange
+	err := s.client.User.WalkAnimeList(ctx, "@me", func(page []UserAnime) error {
+		for _, a := range page {
+			if filter != nil && !filter(a) {
+				continue
+			}
+			if c, ok := newTagChange(a.Anime.ID, a.Anime.Title, a.Status.Tags, op); ok {
+				changes = append(changes, c)
+			}
+		}
+		return nil
+	}, Fields{"list_status{tags}"}, Limit(1000))
+	if err != nil {
+		return nil, err
+	}
+	return changes, applyTagChanges(ctx, changes, opts, func(ctx context.Context, id int, tags Tags) error {
+		_, _, err := s.UpdateMyListStatus(ctx, id, tags)
+		return err
+	})
+}
+
+// BulkUpdateTags applies the tag operation to every manga in the
+// authenticated user's list for which filter returns true. A nil filter
+// matches every manga. Only manga whose tags actually change are updated and
+// returned. If any update fails, the returned error reports how many and the
+// individual errors can be found in the returned changes.
+func (s *MangaService) BulkUpdateTags(ctx context.Context, op TagOperation, filter func(UserManga) bool, opts *BulkTagOptions) ([]TagChange, error) {
+	var changes []TagChange
+	err := s.client.User.WalkMangaList(ctx, "@me", func(page []UserManga) error {
+		for _, m := range page {
+			if filter != nil && !filter(m) {
+				continue
+			}
+			if c, ok := newTagChange(m.Manga.ID, m.Manga.Title, m.Status.Tags, op); ok {
+				changes = append(changes, c)
+			}
+		}
+		return nil
+	}, Fields{"list_status{tags}"}, Limit(1000))
+	if err != nil {
+		return nil, err
+	}
+	return changes, applyTagChanges(ctx, changes, opts, func(ctx context.Context, id int, tags Tags) error {
+		_, _, err := s.UpdateMyListStatus(ctx, id, tags)
+		return err
+	})
+}
+
+func newTagChange(id int, title string, tags []string, op TagOperation) (TagChange, bool) {
+	newTags := op(tags)
+	if equalTags(tags, newTags) {
+		return TagChange{}, false
+	}
+	return TagChange{ID: id, Title: title, OldTags: tags, NewTags: newTags}, true
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func applyTagChanges(ctx context.Context, changes []TagChange, opts *BulkTagOptions, update func(ctx context.Context, id int, tags Tags) error) error {
+	if opts == nil {
+		opts = &BulkTagOptions{}
+	}
+	if opts.DryRun || len(changes) == 0 {
+		return nil
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkTagConcurrency
+	}
+
+	var (
+		mu        sync.Mutex
+		done      int
+		failed    int
+		attempted = make([]bool, len(changes))
+	)
+	report := func(c *TagChange) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if c.Err != nil {
+			failed++
+		}
+		if opts.Progress != nil {
+			opts.Progress(done, len(changes))
+		}
+	}
+	// The updates do not stop at the first error so that every change gets
+	// its own outcome. Only a done ctx stops them.
+	err := pool.Run(ctx, concurrency, len(changes), func(ctx context.Context, i int) error {
+		c := &changes[i]
+		attempted[i] = true
+		c.Err = update(ctx, c.ID, Tags(c.NewTags))
+		report(c)
+		return nil
+	})
+	for i := range changes {
+		if !attempted[i] {
+			changes[i].Err = err
+			report(&changes[i])
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d tag updates failed", failed, len(changes))
+	}
+	return nil
+}
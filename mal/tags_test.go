@@ -0,0 +1,187 @@
+package mal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTagOperations(t *testing.T) {
+	tests := []struct {
+		name string
+		op   TagOperation
+		in   []string
+		want []string
+	}{
+		{"rename", RenameTag("foo", "baz"), []string{"foo", "bar"}, []string{"baz", "bar"}},
+		{"rename missing", RenameTag("qux", "baz"), []string{"foo", "bar"}, []string{"foo", "bar"}},
+		{"rename to existing", RenameTag("foo", "bar"), []string{"foo", "bar"}, []string{"bar"}},
+		{"merge", MergeTags("all", "a", "b"), []string{"a", "x", "b"}, []string{"all", "x"}},
+		{"merge into existing", MergeTags("all", "a"), []string{"x", "all", "a"}, []string{"x", "all"}},
+		{"remove", RemoveTag("bar"), []string{"foo", "bar"}, []string{"foo"}},
+		{"remove last", RemoveTag("foo"), []string{"foo"}, nil},
+		{"add", AddTag("baz"), []string{"foo"}, []string{"foo", "baz"}},
+		{"add existing", AddTag("foo"), []string{"foo"}, []string{"foo"}},
+		{"add to empty", AddTag("foo"), nil, []string{"foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.op(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TagOperation(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnimeServiceBulkUpdateTags(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/@me/animelist", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			testURLValues(t, r, urlValues{
+				"fields": "list_status{tags}",
+				"limit":  "1000",
+				"offset": "0",
+			})
+			fmt.Fprint(w, `{
+			  "data": [
+			    {"node": {"id": 1, "title": "A"}, "list_status": {"tags": ["old", "x"]}},
+			    {"node": {"id": 2, "title": "B"}, "list_status": {"tags": ["x"]}}
+			  ],
+			  "paging": {"next": "?offset=2"}
+			}`)
+		case "2":
+			fmt.Fprint(w, `{
+			  "data": [
+			    {"node": {"id": 3, "title": "C"}, "list_status": {"tags": ["old"]}}
+			  ],
+			  "paging": {"previous": "?offset=0"}
+			}`)
+		default:
+			t.Errorf("unexpected offset %q", r.URL.Query().Get("offset"))
+		}
+	})
+	var mu sync.Mutex
+	var updated []string
+	for _, id := range []int{1, 3} {
+		mux.HandleFunc(fmt.Sprintf("/anime/%d/my_list_status", id), func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, http.MethodPatch)
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			mu.Lock()
+			updated = append(updated, r.URL.Path+" "+r.PostForm.Get("tags"))
+			mu.Unlock()
+			fmt.Fprint(w, `{}`)
+		})
+	}
+
+	var progress []int
+	ctx := context.Background()
+	got, err := client.Anime.BulkUpdateTags(ctx, RenameTag("old", "new"), nil, &BulkTagOptions{
+		Concurrency: 2,
+		Progress:    func(done, total int) { progress = append(progress, done, total) },
+	})
+	if err != nil {
+		t.Fatalf("Anime.BulkUpdateTags returned error: %v", err)
+	}
+	want := []TagChange{
+		{ID: 1, Title: "A", OldTags: []string{"old", "x"}, NewTags: []string{"new", "x"}},
+		{ID: 3, Title: "C", OldTags: []string{"old"}, NewTags: []string{"new"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Anime.BulkUpdateTags returned\nhave: %+v\nwant: %+v", got, want)
+	}
+	sort.Strings(updated)
+	if got, want := strings.Join(updated, "; "), "/anime/1/my_list_status new,x; /anime/3/my_list_status new"; got != want {
+		t.Errorf("updates = %q, want %q", got, want)
+	}
+	if got, want := progress, []int{1, 2, 2, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("progress = %v, want %v", got, want)
+	}
+}
+
+func TestAnimeServiceBulkUpdateTagsDryRun(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/@me/animelist", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+		  "data": [
+		    {"node": {"id": 1, "title": "A"}, "list_status": {"status": "completed"}},
+		    {"node": {"id": 2, "title": "B"}, "list_status": {"status": "watching"}}
+		  ]
+		}`)
+	})
+	mux.HandleFunc("/anime/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Anime.BulkUpdateTags dry run should not update the list")
+	})
+
+	ctx := context.Background()
+	completed := func(a UserAnime) bool { return a.Status.Status == AnimeStatusCompleted }
+	got, err := client.Anime.BulkUpdateTags(ctx, AddTag("done"), completed, &BulkTagOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Anime.BulkUpdateTags returned error: %v", err)
+	}
+	want := []TagChange{{ID: 1, Title: "A", NewTags: []string{"done"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Anime.BulkUpdateTags returned\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestMangaServiceBulkUpdateTagsError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/@me/mangalist", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+		  "data": [
+		    {"node": {"id": 1, "title": "A"}, "list_status": {"tags": ["foo"]}},
+		    {"node": {"id": 2, "title": "B"}, "list_status": {"tags": ["foo", "bar"]}}
+		  ]
+		}`)
+	})
+	mux.HandleFunc("/manga/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		testBody(t, r, "tags=")
+		fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc("/manga/2/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"mal is down","error":"internal"}`, http.StatusInternalServerError)
+	})
+
+	ctx := context.Background()
+	got, err := client.Manga.BulkUpdateTags(ctx, RemoveTag("foo"), nil, nil)
+	if err == nil {
+		t.Fatal("Manga.BulkUpdateTags expected error, got no error.")
+	}
+	if got, want := err.Error(), "1 of 2 tag updates failed"; got != want {
+		t.Errorf("Manga.BulkUpdateTags error = %q, want %q", got, want)
+	}
+	if len(got) != 2 || got[0].Err != nil || got[1].Err == nil {
+		t.Errorf("Manga.BulkUpdateTags returned changes %+v, want only the second to fail", got)
+	}
+}
+
+func TestMangaServiceBulkUpdateTagsListError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/@me/mangalist", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"mal is down","error":"internal"}`, http.StatusInternalServerError)
+	})
+
+	ctx := context.Background()
+	_, err := client.Manga.BulkUpdateTags(ctx, RemoveTag("foo"), nil, nil)
+	if err == nil {
+		t.Fatal("Manga.BulkUpdateTags expected internal error, got no error.")
+	}
+	testErrorResponse(t, err, ErrorResponse{Message: "mal is down", Err: "internal"})
+}
@@ -111,6 +111,35 @@ func (s *UserService) AnimeList(ctx context.Context, username string, options ..
 	return list.Data, resp, nil
 }
 
+// WalkAnimeList calls fn with each page of the anime list of the user
+// indicated by username (or use @me), starting from the offset given by the
+// Offset option if any, until there are no more pages. Use the Limit option to
+// control the size of each page. Walking stops at the first error returned by
+// the API or by fn.
+func (s *UserService) WalkAnimeList(ctx context.Context, username string, fn func(page []UserAnime) error, options ...AnimeListOption) error {
+	offset := 0
+	for _, o := range options {
+		if off, ok := o.(Offset); ok {
+			offset = int(off)
+		}
+	}
+	oo := append(options[:len(options):len(options)], Offset(offset))
+	for {
+		oo[len(oo)-1] = Offset(offset)
+		page, resp, err := s.AnimeList(ctx, username, oo...)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if resp.NextOffset <= offset {
+			return nil
+		}
+		offset = resp.NextOffset
+	}
+}
+
 func optionFromAnimeListOption(o AnimeListOption) optionFunc {
 	return optionFunc(func(v *url.Values) {
 		o.animeListApply(v)
@@ -230,7 +259,15 @@ func (d FinishDate) updateMyMangaListStatusApply(v *url.Values) {
 // UpdateMyListStatus adds the anime specified by animeID to the user's anime
 // list with one or more options added to update the status. If the anime
 // already exists in the list, only the status is updated.
+//
+// The options are validated against the ranges documented by the API before
+// the request is made and a *ValidationError listing every invalid option is
+// returned if any is out of range. Pass SkipValidation(true) to send the
+// values as they are.
 func (s *AnimeService) UpdateMyListStatus(ctx context.Context, animeID int, options ...UpdateMyAnimeListStatusOption) (*AnimeListStatus, *Response, error) {
+	if err := validateAnimeListStatusOptions(options); err != nil {
+		return nil, nil, err
+	}
 	u := fmt.Sprintf("anime/%d/my_list_status", animeID)
 	rawOptions := make([]func(v *url.Values), len(options))
 	for i := range options {
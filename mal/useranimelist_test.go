@@ -212,3 +212,55 @@ func TestAnimeServiceDeleteMyListItemError(t *testing.T) {
 	testResponseStatusCode(t, resp, http.StatusNotFound, "Anime.DeleteMyListItem")
 	testErrorResponse(t, err, ErrorResponse{Message: "anime not found", Err: "not_found"})
 }
+
+func TestUserServiceWalkAnimeList(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/animelist", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		switch offset := r.URL.Query().Get("offset"); offset {
+		case "2":
+			testURLValues(t, r, urlValues{"limit": "2", "offset": "2", "status": "completed"})
+			fmt.Fprint(w, `{"data": [{"node": {"id": 1}}, {"node": {"id": 2}}], "paging": {"next": "?offset=4"}}`)
+		case "4":
+			fmt.Fprint(w, `{"data": [{"node": {"id": 3}}], "paging": {"previous": "?offset=2"}}`)
+		default:
+			t.Errorf("unexpected offset %q", offset)
+		}
+	})
+
+	ctx := context.Background()
+	var got []int
+	err := client.User.WalkAnimeList(ctx, "foo", func(page []UserAnime) error {
+		for _, a := range page {
+			got = append(got, a.Anime.ID)
+		}
+		return nil
+	}, AnimeStatusCompleted, Limit(2), Offset(2))
+	if err != nil {
+		t.Errorf("User.WalkAnimeList returned error: %v", err)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("User.WalkAnimeList walked IDs %v, want %v", got, want)
+	}
+}
+
+func TestUserServiceWalkAnimeListError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/animelist", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"mal is down","error":"internal"}`, 500)
+	})
+
+	ctx := context.Background()
+	err := client.User.WalkAnimeList(ctx, "foo", func(page []UserAnime) error {
+		t.Error("User.WalkAnimeList called fn on error")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("User.WalkAnimeList expected internal error, got no error.")
+	}
+	testErrorResponse(t, err, ErrorResponse{Message: "mal is down", Err: "internal"})
+}
@@ -123,6 +123,35 @@ func (s *UserService) MangaList(ctx context.Context, username string, options ..
 	return list.Data, resp, nil
 }
 
+// WalkMangaList calls fn with each page of the manga list of the user
+// indicated by username (or use @me), starting from the offset given by the
+// Offset option if any, until there are no more pages. Use the Limit option to
+// control the size of each page. Walking stops at the first error returned by
+// the API or by fn.
+func (s *UserService) WalkMangaList(ctx context.Context, username string, fn func(page []UserManga) error, options ...MangaListOption) error {
+	offset := 0
+	for _, o := range options {
+		if off, ok := o.(Offset); ok {
+			offset = int(off)
+		}
+	}
+	oo := append(options[:len(options):len(options)], Offset(offset))
+	for {
+		oo[len(oo)-1] = Offset(offset)
+		page, resp, err := s.MangaList(ctx, username, oo...)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if resp.NextOffset <= offset {
+			return nil
+		}
+		offset = resp.NextOffset
+	}
+}
+
 func optionFromMangaListOption(o MangaListOption) optionFunc {
 	return optionFunc(func(v *url.Values) {
 		o.mangaListApply(v)
@@ -179,7 +208,15 @@ func (r RereadValue) updateMyMangaListStatusApply(v *url.Values) {
 // UpdateMyListStatus adds the manga specified by mangaID to the user's manga
 // list with one or more options added to update the status. If the manga
 // already exists in the list, only the status is updated.
+//
+// The options are validated against the ranges documented by the API before
+// the request is made and a *ValidationError listing every invalid option is
+// returned if any is out of range. Pass SkipValidation(true) to send the
+// values as they are.
 func (s *MangaService) UpdateMyListStatus(ctx context.Context, mangaID int, options ...UpdateMyMangaListStatusOption) (*MangaListStatus, *Response, error) {
+	if err := validateMangaListStatusOptions(options); err != nil {
+		return nil, nil, err
+	}
 	u := fmt.Sprintf("manga/%d/my_list_status", mangaID)
 	rawOptions := make([]func(v *url.Values), len(options))
 	for i := range options {
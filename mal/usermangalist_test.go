@@ -208,3 +208,55 @@ func TestMangaServiceDeleteMyListItemError(t *testing.T) {
 	testResponseStatusCode(t, resp, http.StatusNotFound, "Manga.DeleteMyListItem")
 	testErrorResponse(t, err, ErrorResponse{Message: "manga not found", Err: "not_found"})
 }
+
+func TestUserServiceWalkMangaList(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/mangalist", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, http.MethodGet)
+		switch offset := r.URL.Query().Get("offset"); offset {
+		case "2":
+			testURLValues(t, r, urlValues{"limit": "2", "offset": "2", "status": "completed"})
+			fmt.Fprint(w, `{"data": [{"node": {"id": 1}}, {"node": {"id": 2}}], "paging": {"next": "?offset=4"}}`)
+		case "4":
+			fmt.Fprint(w, `{"data": [{"node": {"id": 3}}], "paging": {"previous": "?offset=2"}}`)
+		default:
+			t.Errorf("unexpected offset %q", offset)
+		}
+	})
+
+	ctx := context.Background()
+	var got []int
+	err := client.User.WalkMangaList(ctx, "foo", func(page []UserManga) error {
+		for _, a := range page {
+			got = append(got, a.Manga.ID)
+		}
+		return nil
+	}, MangaStatusCompleted, Limit(2), Offset(2))
+	if err != nil {
+		t.Errorf("User.WalkMangaList returned error: %v", err)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("User.WalkMangaList walked IDs %v, want %v", got, want)
+	}
+}
+
+func TestUserServiceWalkMangaListError(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/foo/mangalist", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"mal is down","error":"internal"}`, 500)
+	})
+
+	ctx := context.Background()
+	err := client.User.WalkMangaList(ctx, "foo", func(page []UserManga) error {
+		t.Error("User.WalkMangaList called fn on error")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("User.WalkMangaList expected internal error, got no error.")
+	}
+	testErrorResponse(t, err, ErrorResponse{Message: "mal is down", Err: "internal"})
+}
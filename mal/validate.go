@@ -0,0 +1,142 @@
+package mal
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// A FieldError describes a single list update option whose value is outside of
+// the range documented by the MyAnimeList API.
+type FieldError struct {
+	// Field is the name of the API field, for example "score".
+	Field string
+	// Value is the value that was rejected.
+	Value interface{}
+	// Reason explains why the value was rejected.
+	Reason string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %v %s", e.Field, e.Value, e.Reason)
+}
+
+// ValidationError is returned by the UpdateMyListStatus methods when one or
+// more options have invalid values. No request is sent to the API in that
+// case. Use the SkipValidation option to send the values anyway.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i := range e.Errors {
+		msgs[i] = e.Errors[i].Error()
+	}
+	return "invalid list status options: " + strings.Join(msgs, "; ")
+}
+
+// SkipValidation is an option that disables the client-side validation of the
+// other options passed to the UpdateMyListStatus methods, sending their values
+// to the API as they are.
+type SkipValidation bool
+
+func (SkipValidation) updateMyAnimeListStatusApply(v *url.Values) {}
+func (SkipValidation) updateMyMangaListStatusApply(v *url.Values) {}
+
+// validator is implemented by options that can check their own value before a
+// request is made.
+type validator interface {
+	validate() *FieldError
+}
+
+func validateOptions(options []interface{}) error {
+	for _, o := range options {
+		if skip, ok := o.(SkipValidation); ok && bool(skip) {
+			return nil
+		}
+	}
+	var errs []FieldError
+	for _, o := range options {
+		v, ok := o.(validator)
+		if !ok {
+			continue
+		}
+		if err := v.validate(); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	if len(errs) != 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func validateAnimeListStatusOptions(options []UpdateMyAnimeListStatusOption) error {
+	oo := make([]interface{}, len(options))
+	for i := range options {
+		oo[i] = options[i]
+	}
+	return validateOptions(oo)
+}
+
+func validateMangaListStatusOptions(options []UpdateMyMangaListStatusOption) error {
+	oo := make([]interface{}, len(options))
+	for i := range options {
+		oo[i] = options[i]
+	}
+	return validateOptions(oo)
+}
+
+func checkRange(field string, value, min, max int) *FieldError {
+	if value < min || value > max {
+		return &FieldError{Field: field, Value: value, Reason: fmt.Sprintf("is not in range %d-%d", min, max)}
+	}
+	return nil
+}
+
+func checkNonNegative(field string, value int) *FieldError {
+	if value < 0 {
+		return &FieldError{Field: field, Value: value, Reason: "must not be negative"}
+	}
+	return nil
+}
+
+func (s Score) validate() *FieldError        { return checkRange("score", int(s), 0, 10) }
+func (p Priority) validate() *FieldError     { return checkRange("priority", int(p), 0, 2) }
+func (r RewatchValue) validate() *FieldError { return checkRange("rewatch_value", int(r), 0, 5) }
+func (r RereadValue) validate() *FieldError  { return checkRange("reread_value", int(r), 0, 5) }
+func (n NumEpisodesWatched) validate() *FieldError {
+	return checkNonNegative("num_watched_episodes", int(n))
+}
+func (n NumTimesRewatched) validate() *FieldError {
+	return checkNonNegative("num_times_rewatched", int(n))
+}
+func (n NumVolumesRead) validate() *FieldError  { return checkNonNegative("num_volumes_read", int(n)) }
+func (n NumChaptersRead) validate() *FieldError { return checkNonNegative("num_chapters_read", int(n)) }
+func (n NumTimesReread) validate() *FieldError  { return checkNonNegative("num_times_reread", int(n)) }
+
+func (s AnimeStatus) validate() *FieldError {
+	switch s {
+	case AnimeStatusWatching, AnimeStatusCompleted, AnimeStatusOnHold, AnimeStatusDropped, AnimeStatusPlanToWatch:
+		return nil
+	}
+	return &FieldError{Field: "status", Value: s, Reason: "is not a known anime status"}
+}
+
+func (s MangaStatus) validate() *FieldError {
+	switch s {
+	case MangaStatusReading, MangaStatusCompleted, MangaStatusOnHold, MangaStatusDropped, MangaStatusPlanToRead:
+		return nil
+	}
+	return &FieldError{Field: "status", Value: s, Reason: "is not a known manga status"}
+}
+
+func (t Tags) validate() *FieldError {
+	for _, tag := range t {
+		if strings.Contains(tag, ",") {
+			return &FieldError{Field: "tags", Value: tag, Reason: "must not contain commas"}
+		}
+	}
+	return nil
+}
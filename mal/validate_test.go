@@ -0,0 +1,101 @@
+package mal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAnimeServiceUpdateMyListStatusValidation(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/anime/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Anime.UpdateMyListStatus with invalid options should not send a request")
+	})
+
+	ctx := context.Background()
+	_, resp, err := client.Anime.UpdateMyListStatus(ctx, 1,
+		AnimeStatus("binging"),
+		Score(11),
+		Priority(3),
+		RewatchValue(-1),
+		NumEpisodesWatched(-2),
+		NumTimesRewatched(1),
+		Tags{"foo,bar"},
+	)
+	if resp != nil {
+		t.Errorf("Anime.UpdateMyListStatus returned non-nil response: %v", resp)
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Anime.UpdateMyListStatus returned err type %T, want *ValidationError", err)
+	}
+	want := []FieldError{
+		{Field: "status", Value: AnimeStatus("binging"), Reason: "is not a known anime status"},
+		{Field: "score", Value: 11, Reason: "is not in range 0-10"},
+		{Field: "priority", Value: 3, Reason: "is not in range 0-2"},
+		{Field: "rewatch_value", Value: -1, Reason: "is not in range 0-5"},
+		{Field: "num_watched_episodes", Value: -2, Reason: "must not be negative"},
+		{Field: "tags", Value: "foo,bar", Reason: "must not contain commas"},
+	}
+	if got := verr.Errors; !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidationError.Errors\nhave: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestMangaServiceUpdateMyListStatusValidation(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/manga/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Manga.UpdateMyListStatus with invalid options should not send a request")
+	})
+
+	ctx := context.Background()
+	_, _, err := client.Manga.UpdateMyListStatus(ctx, 1,
+		MangaStatus("skimming"),
+		RereadValue(6),
+		NumVolumesRead(-1),
+		NumChaptersRead(-1),
+		NumTimesReread(-1),
+	)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Manga.UpdateMyListStatus returned err type %T, want *ValidationError", err)
+	}
+	const want = "invalid list status options: " +
+		"status: skimming is not a known manga status; " +
+		"reread_value: 6 is not in range 0-5; " +
+		"num_volumes_read: -1 must not be negative; " +
+		"num_chapters_read: -1 must not be negative; " +
+		"num_times_reread: -1 must not be negative"
+	if got := err.Error(); got != want {
+		t.Errorf("ValidationError.Error()\nhave: %q\nwant: %q", got, want)
+	}
+}
+
+func TestUpdateMyListStatusSkipValidation(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/anime/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		testBody(t, r, "score=11")
+		fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc("/manga/1/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		testBody(t, r, "priority=5")
+		fmt.Fprint(w, `{}`)
+	})
+
+	ctx := context.Background()
+	if _, _, err := client.Anime.UpdateMyListStatus(ctx, 1, Score(11), SkipValidation(true)); err != nil {
+		t.Errorf("Anime.UpdateMyListStatus with SkipValidation returned error: %v", err)
+	}
+	if _, _, err := client.Manga.UpdateMyListStatus(ctx, 1, SkipValidation(true), Priority(5)); err != nil {
+		t.Errorf("Manga.UpdateMyListStatus with SkipValidation returned error: %v", err)
+	}
+}